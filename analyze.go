@@ -0,0 +1,119 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"fmt"
+)
+
+type analyzeConfig struct {
+	complete bool
+	unify    bool
+	// resolve is nil to pick the default resolver.
+	resolve func(context.Context, RequirementGraph, ...ResolveOption) (DependencyGraph, error)
+	reqOpts []RequirementsOption
+}
+
+// An AnalyzeOption customizes [Analyze].
+type AnalyzeOption func(*analyzeConfig) error
+
+// WithCompleteRequirements returns an [AnalyzeOption] that builds the requirement graph with
+// [RequirementsComplete] instead of [RequirementsGo].  Unless [WithResolver] is also given, the
+// graph is resolved with [ResolveMvs].
+func WithCompleteRequirements() AnalyzeOption {
+	return func(cfg *analyzeConfig) error {
+		cfg.complete = true
+		return nil
+	}
+}
+
+// WithUnify returns an [AnalyzeOption] that passes the requirement graph through
+// [UnifyRequirements] before resolving it.  Unless [WithResolver] is also given, the graph is
+// resolved with [ResolveMvs].
+func WithUnify() AnalyzeOption {
+	return func(cfg *analyzeConfig) error {
+		cfg.unify = true
+		return nil
+	}
+}
+
+// WithResolver returns an [AnalyzeOption] that resolves the requirement graph with the given
+// function, such as [ResolveGo], [ResolveMvs], or [ResolveSat].
+func WithResolver(resolve func(context.Context, RequirementGraph, ...ResolveOption) (DependencyGraph, error)) AnalyzeOption {
+	return func(cfg *analyzeConfig) error {
+		if resolve == nil {
+			return fmt.Errorf("nil resolver passed to WithResolver")
+		}
+		cfg.resolve = resolve
+		return nil
+	}
+}
+
+// WithRequirementsOptions returns an [AnalyzeOption] that passes the given options to the
+// requirement graph constructor (see [WithCompleteRequirements]).
+func WithRequirementsOptions(opts ...RequirementsOption) AnalyzeOption {
+	return func(cfg *analyzeConfig) error {
+		cfg.reqOpts = append(cfg.reqOpts, opts...)
+		return nil
+	}
+}
+
+// Analyze is a convenience function that runs the usual pipeline on the module identified by mod, a
+// "path[@version]" string where the version may be a [version query]:
+//
+//  1. [ParseModuleId], then [ResolveVersion] unless the version is already fully specified (see
+//     [ModuleId.Check]).
+//  2. [RequirementsGo], or [RequirementsComplete] if [WithCompleteRequirements] is given.
+//  3. [UnifyRequirements] if [WithUnify] is given.
+//  4. [ResolveGo], or [ResolveMvs] if [WithCompleteRequirements] or [WithUnify] is given, or the
+//     resolver given by [WithResolver].
+//
+// The defaults match those of the gomoddepgraph command.  Anything that has to be cleaned up (such
+// as the background goroutine of a [RequirementsComplete] graph) is cleaned up before returning.
+//
+// [version query]: https://go.dev/ref/mod#version-queries
+func Analyze(ctx context.Context, mod string, opts ...AnalyzeOption) (DependencyGraph, error) {
+	cfg := &analyzeConfig{}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+	rootId := ParseModuleId(mod)
+	if err := rootId.Check(); err != nil {
+		if rootId, err = ResolveVersion(ctx, rootId, cfg.reqOpts...); err != nil {
+			return nil, err
+		}
+	}
+	var rg RequirementGraph
+	if cfg.complete {
+		crg, done, err := RequirementsComplete(ctx, rootId, cfg.reqOpts...)
+		if err != nil {
+			return nil, err
+		}
+		// Resolving loads every node the returned [DependencyGraph] needs, so the background loader is
+		// no longer needed once resolution finishes.
+		defer done()
+		rg = crg
+	} else {
+		grg, err := RequirementsGo(ctx, rootId, cfg.reqOpts...)
+		if err != nil {
+			return nil, err
+		}
+		rg = grg
+	}
+	if cfg.unify {
+		urg, err := UnifyRequirements(ctx, rg)
+		if err != nil {
+			return nil, err
+		}
+		rg = urg
+	}
+	resolve := cfg.resolve
+	if resolve == nil {
+		resolve = ResolveGo
+		if cfg.complete || cfg.unify {
+			resolve = ResolveMvs
+		}
+	}
+	return resolve(ctx, rg)
+}
@@ -0,0 +1,54 @@
+package gomoddepgraph_test
+
+import (
+	"testing"
+
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/c@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/c@v1.1.0")},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0"), fm.Require("example.com/c@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/b@v1.0.0"), fm.Require("example.com/c@v1.1.0", false)},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/a@v1.0.0", false),
+			fm.Require("example.com/b@v1.0.0", false)},
+	)
+	want := tGraph{
+		"example.com/root@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		"example.com/a@v1.0.0":    {"example.com/c@v1.1.0": false},
+		"example.com/b@v1.0.0":    {"example.com/c@v1.1.0": false},
+		"example.com/c@v1.1.0":    {},
+	}
+	for _, tc := range []struct {
+		desc string
+		opts []AnalyzeOption
+	}{
+		{desc: "default"},
+		{desc: "complete", opts: []AnalyzeOption{WithCompleteRequirements()}},
+		{desc: "complete sat", opts: []AnalyzeOption{WithCompleteRequirements(), WithResolver(ResolveSat)}},
+		{desc: "unify", opts: []AnalyzeOption{WithUnify()}},
+		{desc: "go sat", opts: []AnalyzeOption{WithResolver(ResolveSat)}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			dg, err := Analyze(gp.Context(), "example.com/root@latest", tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDepGraph(t, dg, want)
+		})
+	}
+	t.Run("incompatible resolver", func(t *testing.T) {
+		t.Parallel()
+		_, err := Analyze(gp.Context(), "example.com/root@v1.0.0",
+			WithCompleteRequirements(), WithResolver(ResolveGo))
+		if err == nil {
+			t.Error("ResolveGo accepted a RequirementsComplete graph")
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package gomoddepgraph
+
+import (
+	"slices"
+)
+
+// ArticulationDependencies returns the dependencies in the given [DependencyGraph] that are pulled
+// into the graph by exactly one edge, considering both direct and surprise dependency edges (see
+// [Deps]).  Removing that one edge (e.g., by dropping a requirement) would remove the returned
+// dependency from the graph, along with any of its own dependencies that are not also reachable
+// some other way.  This helps identify which dependencies are cheapest to eliminate.
+//
+// Dependency cycles are handled by treating each strongly connected component (see [FindCycles]) as
+// a single node: edges within a component are ignored, and every member of a component is returned
+// if exactly one edge enters the component from elsewhere in the graph.  The root (and any
+// dependency in a cycle with the root) is never returned.  The returned dependencies are sorted by
+// [DependencyCompare].
+func ArticulationDependencies(dg DependencyGraph) []Dependency {
+	sccs := stronglyConnectedComponents(dg)
+	comp := map[Dependency]int{}
+	for i, scc := range sccs {
+		for _, d := range scc {
+			comp[d] = i
+		}
+	}
+	inEdges := make([]int, len(sccs))
+	for _, scc := range sccs {
+		for _, p := range scc {
+			for d := range Deps(dg, p) {
+				if comp[d] != comp[p] {
+					inEdges[comp[d]]++
+				}
+			}
+		}
+	}
+	rootComp := comp[dg.Root()]
+	ret := []Dependency(nil)
+	for i, scc := range sccs {
+		if i != rootComp && inEdges[i] == 1 {
+			ret = append(ret, scc...)
+		}
+	}
+	slices.SortFunc(ret, DependencyCompare)
+	return ret
+}
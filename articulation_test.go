@@ -0,0 +1,53 @@
+package gomoddepgraph
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestArticulationDependencies(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		// c is reachable from both a and b.
+		"example.com/a@v1.0.0": {"example.com/c@v1.0.0": false, "example.com/x@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/c@v1.0.0": {},
+		// x and y form a cycle that is only entered via a -> x.
+		"example.com/x@v1.0.0": {"example.com/y@v1.0.0": false},
+		"example.com/y@v1.0.0": {"example.com/x@v1.0.0": false},
+	})
+	got := []string(nil)
+	for _, d := range ArticulationDependencies(dg) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"example.com/a@v1.0.0",
+		"example.com/b@v1.0.0",
+		"example.com/x@v1.0.0",
+		"example.com/y@v1.0.0",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected articulation dependencies (-want +got):\n%s", diff)
+	}
+}
+
+func TestArticulationDependencies_EdgeCases(t *testing.T) {
+	t.Parallel()
+	want := map[string][]string{
+		"empty": nil,
+		// a is in a cycle with the root, and the edge from c to b is within the b-c cycle.
+		"cycles":     {"b", "c"},
+		"multi-root": {"x", "y"},
+	}
+	for _, tc := range edgeCaseGraphs {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			got := shortPaths(ArticulationDependencies(newTestDependencyGraph(t, tc.root, tc.g)))
+			if diff := cmp.Diff(want[tc.desc], got); diff != "" {
+				t.Errorf("unexpected articulation dependencies (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
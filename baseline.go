@@ -0,0 +1,49 @@
+package gomoddepgraph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CompareToBaseline compares the selection set of dg (see [AllDependencies]) to a "known good"
+// baseline read from r, such as one saved from the gomoddepgraph command's raw output format.  Each
+// line of the baseline holds one module as "path version" or "path@version"; blank lines and lines
+// starting with "#" are ignored.
+//
+// In the returned [DependencyGraphDiff], the baseline is the first graph and dg is the second, so
+// OnlyA holds the removed modules, OnlyB holds the added modules, and Changed holds the upgraded
+// and downgraded modules (see [VersionChange.Upgrade]).  This is useful for failing a CI check when
+// the resolved graph drifts from an approved baseline, which can happen without any change to the
+// root module's go.mod (e.g., if a requirement's own requirements change upstream).
+func CompareToBaseline(dg DependencyGraph, r io.Reader) (*DependencyGraphDiff, error) {
+	base := map[string]Dependency{}
+	scn := bufio.NewScanner(r)
+	for n := 1; scn.Scan(); n++ {
+		line := strings.TrimSpace(scn.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var mId ModuleId
+		switch f := strings.Fields(line); len(f) {
+		case 1:
+			mId = ParseModuleId(f[0])
+		case 2:
+			mId = NewModuleId(f[0], f[1])
+		default:
+			return nil, fmt.Errorf("baseline line %d: expected \"path version\" or \"path@version\": %q", n, line)
+		}
+		if err := mId.Check(); err != nil {
+			return nil, fmt.Errorf("baseline line %d: %w", n, err)
+		}
+		if d, ok := base[mId.Path]; ok {
+			return nil, fmt.Errorf("baseline line %d: module %v is already listed as %v", n, mId, d)
+		}
+		base[mId.Path] = newDependency(requirement{mId})
+	}
+	if err := scn.Err(); err != nil {
+		return nil, err
+	}
+	return diffSelections(base, selectedByPath(dg)), nil
+}
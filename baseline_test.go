@@ -0,0 +1,94 @@
+package gomoddepgraph
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompareToBaseline(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0":    {"example.com/new@v1.0.0": false, "example.com/up@v1.2.0": false, "example.com/down@v1.0.0": false, "example.com/same@v1.0.0": false},
+		"example.com/new@v1.0.0":  {},
+		"example.com/up@v1.2.0":   {},
+		"example.com/down@v1.0.0": {},
+		"example.com/same@v1.0.0": {},
+	})
+	baseline := `# approved 2026-01-01
+example.com/r@v1.0.0
+example.com/up v1.1.0
+example.com/down v1.1.0
+
+example.com/same@v1.0.0
+example.com/gone@v1.0.0
+`
+	diff, err := CompareToBaseline(dg, strings.NewReader(baseline))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string(nil)
+	for _, d := range diff.OnlyA {
+		got = append(got, "removed "+d.String())
+	}
+	for _, d := range diff.OnlyB {
+		got = append(got, "added "+d.String())
+	}
+	for _, c := range diff.Changed {
+		got = append(got, fmt.Sprintf("changed %v -> %v (upgrade: %v)", c.A, c.B.Id().Version, c.Upgrade()))
+	}
+	want := []string{
+		"removed example.com/gone@v1.0.0",
+		"added example.com/new@v1.0.0",
+		"changed example.com/down@v1.1.0 -> v1.0.0 (upgrade: false)",
+		"changed example.com/up@v1.1.0 -> v1.2.0 (upgrade: true)",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected baseline diff (-want +got):\n%s", diff)
+	}
+	for _, bad := range []string{"example.com/a v1 extra", "example.com/a@latest", "example.com/a@v1.0.0\nexample.com/a@v1.1.0"} {
+		if _, err := CompareToBaseline(dg, strings.NewReader(bad)); err == nil {
+			t.Errorf("got nil error for baseline %q, want error", bad)
+		}
+	}
+}
+
+func TestCompareToBaseline_EdgeCases(t *testing.T) {
+	t.Parallel()
+	baselines := map[string]string{
+		"empty":  "example.com/r@v1.0.0\n",
+		"cycles": "# nothing approved yet\n",
+		"multi-root": MultiRootId.String() + "\n" +
+			"example.com/x v1.0.0\nexample.com/y v1.0.0\nexample.com/z v1.0.0\n",
+	}
+	want := map[string][]string{
+		"empty":      nil,
+		"cycles":     {"added a", "added b", "added c", "added r"},
+		"multi-root": nil,
+	}
+	for _, tc := range edgeCaseGraphs {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			dg := newTestDependencyGraph(t, tc.root, tc.g)
+			diff, err := CompareToBaseline(dg, strings.NewReader(baselines[tc.desc]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string(nil)
+			for _, p := range shortPaths(diff.OnlyA) {
+				got = append(got, "removed "+p)
+			}
+			for _, p := range shortPaths(diff.OnlyB) {
+				got = append(got, "added "+p)
+			}
+			for _, c := range diff.Changed {
+				got = append(got, fmt.Sprintf("changed %v -> %v", c.A, c.B))
+			}
+			if diff := cmp.Diff(want[tc.desc], got); diff != "" {
+				t.Errorf("unexpected baseline diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package gomoddepgraph
+
+import (
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// BlastRadius returns, for each direct dependency of [DependencyGraph.Root] (see
+// [DependencyGraph.DirectDeps]), the number of dependencies that would no longer be reachable from
+// the root if the root's edge to that direct dependency were removed (e.g., by dropping the
+// requirement).  The count includes the direct dependency itself unless it is also reachable some
+// other way.  Reachability considers both direct and surprise dependency edges (see [Deps]), and
+// the versions are not re-selected, so this is an estimate of the "baggage" each direct dependency
+// brings in.  See also [ArticulationDependencies].
+func BlastRadius(dg DependencyGraph) map[Dependency]int {
+	root := dg.Root()
+	total := reachableWithout(dg, root, nil).Cardinality()
+	ret := map[Dependency]int{}
+	for d := range dg.DirectDeps(root) {
+		ret[d] = total - reachableWithout(dg, root, d).Cardinality()
+	}
+	return ret
+}
+
+// reachableWithout returns the dependencies reachable from root, ignoring root's direct (not
+// surprise) dependency edge to skip if non-nil.
+func reachableWithout(dg DependencyGraph, root, skip Dependency) mapset.Set[Dependency] {
+	seen := mapset.NewThreadUnsafeSet(root)
+	q := []Dependency{root}
+	for len(q) > 0 {
+		p := q[0]
+		q = q[1:]
+		for d, surprise := range Deps(dg, p) {
+			if p == root && d == skip && !surprise {
+				continue
+			}
+			if seen.Add(d) {
+				q = append(q, d)
+			}
+		}
+	}
+	return seen
+}
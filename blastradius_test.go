@@ -0,0 +1,51 @@
+package gomoddepgraph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBlastRadius(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		// x and y are only reachable through a; c is also reachable through b.
+		"example.com/a@v1.0.0": {"example.com/c@v1.0.0": false, "example.com/x@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/c@v1.0.0": {},
+		"example.com/x@v1.0.0": {"example.com/y@v1.0.0": false},
+		"example.com/y@v1.0.0": {},
+	})
+	got := map[string]int{}
+	for d, n := range BlastRadius(dg) {
+		got[strings.TrimPrefix(d.Id().Path, "example.com/")] = n
+	}
+	want := map[string]int{"a": 3, "b": 1}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected blast radius (-want +got):\n%s", diff)
+	}
+}
+
+func TestBlastRadius_EdgeCases(t *testing.T) {
+	t.Parallel()
+	want := map[string]map[string]int{
+		"empty": {},
+		// The root stays reachable via a, so only a itself is lost.
+		"cycles":     {"a": 1, "b": 2},
+		"multi-root": {"x": 1, "y": 1},
+	}
+	for _, tc := range edgeCaseGraphs {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			got := map[string]int{}
+			for d, n := range BlastRadius(newTestDependencyGraph(t, tc.root, tc.g)) {
+				got[strings.TrimPrefix(d.Id().Path, "example.com/")] = n
+			}
+			if diff := cmp.Diff(want[tc.desc], got); diff != "" {
+				t.Errorf("unexpected blast radius (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
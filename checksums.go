@@ -0,0 +1,177 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rhansen/gomoddepgraph/internal/command"
+	"github.com/rhansen/gomoddepgraph/internal/logging"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// A ModuleSum holds a module's checksums in the form recorded in go.sum files.
+type ModuleSum struct {
+	Id ModuleId
+	// Hash is the hash of the module's contents (e.g., "h1:...").
+	Hash string
+	// GoModHash is the hash of the module's go.mod file, recorded in go.sum on the "/go.mod" line.
+	GoModHash string
+}
+
+// ModuleSums downloads every [Dependency] in the given [DependencyGraph] (see [Nodes]) that has a
+// released version and computes its checksums with [dirhash.HashDir] and [dirhash.Hash1], the same
+// way the go command computes the checksums it records in go.sum.  The root, [DevelVersion] and
+// [LocalVersion] nodes, and the synthetic root of a multi-root graph ([MultiRootId]) are skipped
+// because go.sum has no entries for them.  The go.mod hash is computed from the go.mod served by
+// the [module proxy], so it is correct even for a module without a go.mod file (the go command
+// synthesizes one).  The returned slice is sorted by [ModuleIdCompare].
+//
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+func ModuleSums(ctx context.Context, dg DependencyGraph) ([]ModuleSum, error) {
+	ctx = withDepGraphEnv(ctx, dg)
+	root := dg.Root()
+	mIds := []ModuleId(nil)
+	for d := range Nodes(dg) {
+		if mId := d.Id(); d != root && !mId.IsDevel() && !mId.IsLocal() && mId != MultiRootId {
+			mIds = append(mIds, mId)
+		}
+	}
+	slices.SortFunc(mIds, ModuleIdCompare)
+	mds := []*downloadMetadata(nil)
+	// Avoid hitting ARG_MAX.
+	for batch := range slices.Chunk(mIds, 500) {
+		batchMds, err := downloadModules(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		mds = append(mds, batchMds...)
+	}
+	ret := []ModuleSum(nil)
+	for _, md := range mds {
+		mId := NewModuleId(md.Path, md.Version)
+		h, err := dirhash.HashDir(md.Dir, mId.String(), dirhash.Hash1)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", mId, err)
+		}
+		mh, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+			return os.Open(md.GoMod)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", mId, err)
+		}
+		ret = append(ret, ModuleSum{Id: mId, Hash: h, GoModHash: mh})
+	}
+	slices.SortFunc(ret, func(a, b ModuleSum) int { return ModuleIdCompare(a.Id, b.Id) })
+	return ret, nil
+}
+
+// downloadMetadata is the JSON object output by `go mod download -json` for each module.  Unlike
+// `go list -m -json`, the Error field is a plain string.
+type downloadMetadata struct {
+	Path, Version, Dir, GoMod string
+	Error                     string
+}
+
+// downloadModules runs `go mod download -json` for the given modules and returns the reported
+// metadata.
+func downloadModules(ctx context.Context, mIds []ModuleId) ([]*downloadMetadata, error) {
+	downloadConcurrencyLimiter <- struct{}{}
+	defer func() { <-downloadConcurrencyLimiter }()
+	cmd := []string{goBin(ctx), "mod", "download", "-json"}
+	if slog.Default().Enabled(ctx, logging.LevelVerbose) {
+		cmd = append(cmd, "-x")
+	}
+	for _, mId := range mIds {
+		cmd = append(cmd, mId.String())
+	}
+	mdIter, done := command.DecodeJsonStream[*downloadMetadata](ctx, "/", cmd...)
+	mds := slices.Collect(mdIter)
+	for _, md := range mds {
+		if md.Error != "" {
+			done()
+			return nil, moduleLookupError(NewModuleId(md.Path, md.Version), md.Error)
+		}
+	}
+	if err := done(); err != nil {
+		return nil, err
+	}
+	return mds, nil
+}
+
+// A ChecksumIssue describes a discrepancy between a go.sum file and the checksums computed by
+// [VerifyChecksums].
+type ChecksumIssue struct {
+	Id ModuleId
+	// GoMod is true if the issue is with the checksum of the module's go.mod file (the "/go.mod"
+	// line in go.sum) rather than the checksum of the module's contents.
+	GoMod bool
+	// GoSum is the checksum recorded in go.sum.
+	GoSum string
+	// Computed is the checksum computed from the downloaded module.
+	Computed string
+}
+
+func (ci *ChecksumIssue) String() string {
+	what := ci.Id.String()
+	if ci.GoMod {
+		what += "/go.mod"
+	}
+	return fmt.Sprintf("%v: checksum mismatch; go.sum has %v, computed %v", what, ci.GoSum, ci.Computed)
+}
+
+// VerifyChecksums compares the checksums computed by [ModuleSums] for the given [DependencyGraph]
+// against the go.sum file at goSumPath, and returns an issue for every selected module whose
+// content or go.mod checksum recorded in go.sum does not match.  A checksum that go.sum lacks is
+// not an issue: the go command only records the checksums it needs (e.g., only the go.mod checksum
+// of a module whose packages are never built).  Entries in go.sum for modules that are not selected
+// are ignored.  A mismatch indicates a tampered module cache or proxy, or a stale or corrupt
+// go.sum.  The returned issues are sorted by module, with the content checksum issue before the
+// go.mod checksum issue.
+func VerifyChecksums(ctx context.Context, dg DependencyGraph, goSumPath string) ([]ChecksumIssue, error) {
+	goSum, err := readGoSum(goSumPath)
+	if err != nil {
+		return nil, err
+	}
+	sums, err := ModuleSums(ctx, dg)
+	if err != nil {
+		return nil, err
+	}
+	var ret []ChecksumIssue
+	for _, s := range sums {
+		for _, ci := range []ChecksumIssue{
+			{Id: s.Id, GoSum: goSum[s.Id.String()], Computed: s.Hash},
+			{Id: s.Id, GoMod: true, GoSum: goSum[s.Id.String()+"/go.mod"], Computed: s.GoModHash},
+		} {
+			if ci.GoSum != "" && ci.GoSum != ci.Computed {
+				ret = append(ret, ci)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// readGoSum parses a go.sum file into a map from "path@version" or "path@version/go.mod" to the
+// recorded checksum.
+func readGoSum(goSumPath string) (map[string]string, error) {
+	data, err := os.ReadFile(goSumPath)
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		if len(f) != 3 {
+			return nil, fmt.Errorf("%v:%v: malformed line", goSumPath, i+1)
+		}
+		ret[f[0]+"@"+f[1]] = f[2]
+	}
+	return ret, nil
+}
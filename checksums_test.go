@@ -0,0 +1,105 @@
+package gomoddepgraph_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestModuleSums(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/legacy@v1.0.0"), fm.Go(""), fm.Synthetic(true)},
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/dep@v1.0.0", false),
+			fm.Require("example.com/legacy@v1.0.0", false)},
+	)
+	ctx := gp.Context()
+	rg, err := RequirementsGo(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dg, err := ResolveMvs(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ModuleSums(ctx, dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ModuleSum(nil)
+	for _, s := range []string{"example.com/dep@v1.0.0", "example.com/legacy@v1.0.0"} {
+		mId := ParseModuleId(s)
+		h, mh := gp.Hashes(mId)
+		want = append(want, ModuleSum{Id: mId, Hash: h, GoModHash: mh})
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected checksums (-want +got):\n%s", diff)
+	}
+}
+
+func TestModuleSums_LocalReplace(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/b@v1.0.0")},
+	)
+	ctx, dg := resolveLocalReplace(t, gp)
+	got, err := ModuleSums(ctx, dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bId := ParseModuleId("example.com/b@v1.0.0")
+	h, mh := gp.Hashes(bId)
+	want := []ModuleSum{{Id: bId, Hash: h, GoModHash: mh}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected checksums (-want +got):\n%s", diff)
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/a@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/b@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/a@v1.0.0", false),
+			fm.Require("example.com/b@v1.0.0", false)},
+	)
+	ctx := gp.Context()
+	rg, _, err := RequirementsComplete(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dg, err := ResolveMvs(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aId, bId := ParseModuleId("example.com/a@v1.0.0"), ParseModuleId("example.com/b@v1.0.0")
+	aHash, aGoModHash := gp.Hashes(aId)
+	_, bGoModHash := gp.Hashes(bId)
+	const bogus = "h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+	goSum := filepath.Join(t.TempDir(), "go.sum")
+	if err := os.WriteFile(goSum, []byte(
+		"example.com/a v1.0.0 "+aHash+"\n"+
+			"example.com/a v1.0.0/go.mod "+bogus+"\n"+
+			"example.com/b v1.0.0/go.mod "+bGoModHash+"\n"+
+			"example.com/unused v1.0.0 "+bogus+"\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	got, err := VerifyChecksums(ctx, dg, goSum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The missing content checksum of example.com/b is not an issue.
+	want := []ChecksumIssue{
+		{Id: aId, GoMod: true, GoSum: bogus, Computed: aGoModHash},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected issues (-want +got):\n%s", diff)
+	}
+}
@@ -2,17 +2,24 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	_ "embed"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"iter"
 	"log"
 	"log/slog"
 	"maps"
 	"os"
+	"path/filepath"
 	"runtime/debug"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/amterp/color"
@@ -20,29 +27,95 @@ import (
 	gmdg "github.com/rhansen/gomoddepgraph"
 	"github.com/rhansen/gomoddepgraph/internal/command"
 	"github.com/rhansen/gomoddepgraph/internal/logging"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
 )
 
 //go:embed gomoddepgraph.1.in
 var man []byte
 
-var (
-	cyanf    = color.New(color.FgCyan).SprintfFunc()
-	hicyanf  = color.New(color.FgHiCyan).SprintfFunc()
-	hiblackf = color.New(color.FgHiBlack).SprintfFunc()
-)
+// A theme determines how output annotations are colorized.
+type theme struct {
+	// surprisef colorizes the annotation on a surprise dependency that has not been printed yet.
+	surprisef func(format string, a ...any) string
+	// surpriseRepeatf colorizes the annotation on a surprise dependency that was already printed.
+	surpriseRepeatf func(format string, a ...any) string
+	// repeatf colorizes a dependency that was already printed, and other de-emphasized text.
+	repeatf func(format string, a ...any) string
+}
+
+func newTheme(surprise, surpriseRepeat, repeat *color.Color) *theme {
+	return &theme{
+		surprisef:       surprise.SprintfFunc(),
+		surpriseRepeatf: surpriseRepeat.SprintfFunc(),
+		repeatf:         repeat.SprintfFunc(),
+	}
+}
 
-type getReqsFn = func(ctx context.Context, rootId gmdg.ModuleId) (gmdg.RequirementGraph, error)
-type resolveDepsFn = func(ctx context.Context, rg gmdg.RequirementGraph) (gmdg.DependencyGraph, error)
-type outputFn = func(ctx context.Context, sel gmdg.DependencyGraph) error
+type getReqsFn = func(ctx context.Context, rootId gmdg.ModuleId, opts ...gmdg.RequirementsOption) (gmdg.RequirementGraph, error)
+type resolveDepsFn = func(ctx context.Context, rg gmdg.RequirementGraph, opts ...gmdg.ResolveOption) (
+	gmdg.DependencyGraph, error)
+type outputFn = func(ctx context.Context, cfg *config, sel gmdg.DependencyGraph) error
+type reqOutputFn = func(ctx context.Context, cfg *config, rg gmdg.RequirementGraph) error
+type reportFn = func(ctx context.Context, cfg *config, rg gmdg.RequirementGraph, dg gmdg.DependencyGraph) error
 
 type config struct {
-	mods        []string
-	getReqs     *getReqsFn
-	unify       bool
-	resolveDeps *resolveDepsFn
-	output      *outputFn
+	mods           []string
+	goMod          string
+	localDir       string
+	packages       bool
+	getReqs        *getReqsFn
+	unify          bool
+	dryRun         bool
+	resolveDeps    *resolveDepsFn
+	compare        []string
+	verify         bool
+	output         *outputFn
+	format         string
+	showReqs       bool
+	showBoth       bool
+	align          bool
+	layered        bool
+	noSummary      bool
+	noSurprise     bool
+	noRoot         bool
+	numberNodes    bool
+	sortByInDegree bool
+	excludeTools   bool
+	singleMajor    bool
+	strict         bool
+	// resolverSwitch describes why the resolver was implicitly switched from go to mvs, or is empty if
+	// it wasn't (or if --resolver was given afterward).
+	resolverSwitch string
+	toolchain      string
+	netrc          string
+	noWorkspace    bool
+	// modFlag is the go command's -mod flag given by --mod, or empty if not given.
+	modFlag        string
+	goos           string
+	goarch         string
+	tags           []string
+	report         *reportFn
+	theme          *theme
+	warnRetracted  bool
+	warnGoVersion  bool
+	warnMultiMajor bool
+	warnSelfReqs   bool
+	failOnSurprise bool
+	failOnCycle    bool
+	// licenseAllow is the list of licenses allowed by --license-policy, or nil if not given.
+	licenseAllow []string
 }
 
+// Exit statuses for the --fail-on-*, --verify, and --license-policy options.  They are distinct bits
+// so that several conditions can be reported at once.
+const (
+	exitSurprise = 1 << 2
+	exitCycle    = 1 << 3
+	exitVerify   = 1 << 4
+	exitLicense  = 1 << 5
+)
+
 func ver() string {
 	bi, ok := debug.ReadBuildInfo()
 	if !ok || bi.Main.Version == "(devel)" {
@@ -78,7 +151,7 @@ func showMan(ctx context.Context) error {
 }
 
 var allGetReqsFuncs = [...]getReqsFn{
-	gmdg.RequirementsGo,
+	getReqsGo,
 	getReqsComplete,
 }
 
@@ -87,8 +160,12 @@ var allGetReqs = map[string]*getReqsFn{
 	"complete": &allGetReqsFuncs[1],
 }
 
-func getReqsComplete(ctx context.Context, rootId gmdg.ModuleId) (gmdg.RequirementGraph, error) {
-	rg, _, err := gmdg.RequirementsComplete(ctx, rootId)
+func getReqsGo(ctx context.Context, rootId gmdg.ModuleId, opts ...gmdg.RequirementsOption) (gmdg.RequirementGraph, error) {
+	return gmdg.RequirementsGo(ctx, rootId, opts...)
+}
+
+func getReqsComplete(ctx context.Context, rootId gmdg.ModuleId, opts ...gmdg.RequirementsOption) (gmdg.RequirementGraph, error) {
+	rg, _, err := gmdg.RequirementsComplete(ctx, rootId, opts...)
 	return rg, err
 }
 
@@ -108,116 +185,1042 @@ var allOutputFuncs = [...]outputFn{
 	outputTree,
 	outputRaw,
 	outputDot,
+	outputMdTable,
+	outputNix,
+	outputNdjson,
+	outputMatrix,
+	outputPrometheus,
 }
 
 var allOutput = map[string]*outputFn{
-	"tree": &allOutputFuncs[0],
-	"raw":  &allOutputFuncs[1],
-	"dot":  &allOutputFuncs[2],
-}
-
-func outputTree(ctx context.Context, dg gmdg.DependencyGraph) error {
-	surpriseMsg := hicyanf(" (surprise indirect)")
-	surpriseSeenMsg := cyanf(" (surprise indirect)")
-	seenMsg := hiblackf(" (repeat)")
-	seen := mapset.NewSet[gmdg.Dependency]()
-	var visit func(m gmdg.Dependency, surprise bool, indent int) error
-	visit = func(m gmdg.Dependency, surprise bool, indent int) error {
+	"tree":       &allOutputFuncs[0],
+	"raw":        &allOutputFuncs[1],
+	"dot":        &allOutputFuncs[2],
+	"md-table":   &allOutputFuncs[3],
+	"nix":        &allOutputFuncs[4],
+	"ndjson":     &allOutputFuncs[5],
+	"matrix":     &allOutputFuncs[6],
+	"prometheus": &allOutputFuncs[7],
+}
+
+// allReqOutput maps each --format mode that supports --show=requirements to its implementation.
+var allReqOutput = map[string]reqOutputFn{
+	"tree": outputReqTree,
+	"raw":  outputReqRaw,
+	"dot":  outputReqDot,
+}
+
+var allReportFuncs = [...]reportFn{
+	reportSurprises,
+	reportAges,
+	reportUpgrades,
+}
+
+var allReport = map[string]*reportFn{
+	"none":      nil,
+	"surprises": &allReportFuncs[0],
+	"ages":      &allReportFuncs[1],
+	"upgrades":  &allReportFuncs[2],
+}
+
+// printTree prints the graph rooted at root as an indented tree.  The edges callback returns the
+// outgoing edges of a node, mapped to true if the edge should be annotated with the given label.
+func printTree[N interface {
+	comparable
+	fmt.Stringer
+}](cfg *config, root N, edges func(m N) (map[N]bool, error), cmp func(a, b N) int, label string) error {
+	markMsg := cfg.theme.surprisef(" (%s)", label)
+	markSeenMsg := cfg.theme.surpriseRepeatf(" (%s)", label)
+	seenMsg := func(m N) string { return cfg.theme.repeatf(" (repeat)") }
+	// numbers maps each printed node to its index if cfg.numberNodes is true.
+	numbers := map[N]int{}
+	if cfg.numberNodes {
+		seenMsg = func(m N) string {
+			if n, ok := numbers[m]; ok {
+				return cfg.theme.repeatf(" (see [%v])", n)
+			}
+			// The root when --no-root is used.
+			return cfg.theme.repeatf(" (repeat)")
+		}
+	}
+	seen := mapset.NewSet[N]()
+	nEdges, marked, repeats := 0, 0, 0
+	// The tree is printed depth-first using an explicit stack instead of recursion so that
+	// pathologically deep graphs do not exhaust the goroutine stack.  Each frame holds the edges of
+	// a printed node that have not been visited yet.
+	type frame struct {
+		es     map[N]bool
+		ds     []N
+		indent int
+	}
+	stack := []*frame(nil)
+	pushEdges := func(m N, indent int) error {
+		es, err := edges(m)
+		if err != nil {
+			return err
+		}
+		stack = append(stack, &frame{es, slices.SortedFunc(maps.Keys(es), cmp), indent})
+		return nil
+	}
+	// visit prints the given node and returns true if its edges should be visited.
+	visit := func(m N, mark bool, indent int) bool {
 		wasSeen := !seen.Add(m)
+		if wasSeen {
+			repeats++
+		}
 		fmt.Print(strings.Repeat("  ", indent))
+		num := ""
+		if !wasSeen && cfg.numberNodes {
+			numbers[m] = len(numbers) + 1
+			num = fmt.Sprintf(" [%v]", numbers[m])
+		}
 		switch {
-		case !wasSeen && !surprise:
-			fmt.Print(m)
-		case !wasSeen && surprise:
-			fmt.Printf("%v%s", m, surpriseMsg)
-		case wasSeen && !surprise:
-			fmt.Printf("%s%s", hiblackf("%v", m), seenMsg)
-		case wasSeen && surprise:
-			fmt.Printf("%s%s%s", hiblackf("%v", m), seenMsg, surpriseSeenMsg)
+		case !wasSeen && !mark:
+			fmt.Printf("%v%s", m, num)
+		case !wasSeen && mark:
+			fmt.Printf("%v%s%s", m, num, markMsg)
+		case wasSeen && !mark:
+			fmt.Printf("%s%s", cfg.theme.repeatf("%v", m), seenMsg(m))
+		case wasSeen && mark:
+			fmt.Printf("%s%s%s", cfg.theme.repeatf("%v", m), seenMsg(m), markSeenMsg)
 		}
 		fmt.Print("\n")
-		if !wasSeen {
-			deps := maps.Collect(gmdg.Deps(dg, m))
-			for _, d := range slices.SortedFunc(maps.Keys(deps), gmdg.DependencyCompare) {
-				if err := visit(d, deps[d], indent+1); err != nil {
-					return err
-				}
+		return !wasSeen
+	}
+	if cfg.noRoot {
+		// The root is still marked as seen so that edges back to it are annotated as repeats.
+		seen.Add(root)
+		if err := pushEdges(root, 0); err != nil {
+			return err
+		}
+	} else if visit(root, false, 0) {
+		if err := pushEdges(root, 1); err != nil {
+			return err
+		}
+	}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		if len(f.ds) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		d := f.ds[0]
+		f.ds = f.ds[1:]
+		nEdges++
+		if f.es[d] {
+			marked++
+		}
+		if visit(d, f.es[d], f.indent) {
+			if err := pushEdges(d, f.indent+1); err != nil {
+				return err
 			}
 		}
-		return nil
 	}
-	return visit(dg.Root(), false, 0)
+	if !cfg.noSummary {
+		fmt.Print(cfg.theme.repeatf("%v modules, %v edges (%v %s), %v repeats collapsed",
+			seen.Cardinality(), nEdges, marked, strings.Fields(label)[0], repeats), "\n")
+	}
+	return nil
+}
+
+func outputTree(ctx context.Context, cfg *config, dg gmdg.DependencyGraph) error {
+	edges := func(m gmdg.Dependency) (map[gmdg.Dependency]bool, error) {
+		deps := maps.Collect(gmdg.Deps(dg, m))
+		if cfg.noSurprise {
+			maps.DeleteFunc(deps, func(_ gmdg.Dependency, s bool) bool { return s })
+		}
+		return deps, nil
+	}
+	return printTree(cfg, dg.Root(), edges, gmdg.DependencyCompare, "surprise indirect")
+}
+
+// printRaw prints the given modules one per line, aligned in columns if requested.
+func printRaw(cfg *config, mIds []gmdg.ModuleId) {
+	if !cfg.align {
+		for _, mId := range mIds {
+			fmt.Printf("%v\n", mId)
+		}
+		return
+	}
+	w := 0
+	for _, mId := range mIds {
+		w = max(w, len(mId.Path))
+	}
+	for _, mId := range mIds {
+		fmt.Printf("%-*s %s\n", w, mId.Path, mId.Version)
+	}
 }
 
-func outputRaw(ctx context.Context, dg gmdg.DependencyGraph) error {
-	for _, dep := range slices.SortedFunc(gmdg.AllDependencies(dg), gmdg.DependencyCompare) {
-		fmt.Printf("%v\n", dep)
+func outputRaw(ctx context.Context, cfg *config, dg gmdg.DependencyGraph) error {
+	deps := slices.SortedFunc(gmdg.AllDependencies(dg), gmdg.DependencyCompare)
+	if cfg.sortByInDegree {
+		in := gmdg.InDegree(dg)
+		// The sort is stable so that ties stay in DependencyCompare order.
+		slices.SortStableFunc(deps, func(a, b gmdg.Dependency) int { return in[b] - in[a] })
+	}
+	mIds := []gmdg.ModuleId(nil)
+	for _, dep := range deps {
+		if cfg.noRoot && dep == dg.Root() {
+			continue
+		}
+		mIds = append(mIds, dep.Id())
 	}
+	printRaw(cfg, mIds)
 	return nil
 }
 
-func outputDot(ctx context.Context, dg gmdg.DependencyGraph) error {
-	printEdge := func(from, to gmdg.Dependency, surprise bool) {
-		attrs := []string{}
-		if surprise {
-			attrs = append(attrs, "class=\"surprise\"", "style=\"dashed\"")
+func outputDot(ctx context.Context, cfg *config, dg gmdg.DependencyGraph) error {
+	return gmdg.WriteDot(os.Stdout, dg, gmdg.DotOptions{Layered: cfg.layered})
+}
+
+// reqEdges returns the requirements of m, mapped to true for immediate indirect requirements.
+func reqEdges(ctx context.Context, rg gmdg.RequirementGraph) func(m gmdg.Requirement) (map[gmdg.Requirement]bool, error) {
+	return func(m gmdg.Requirement) (map[gmdg.Requirement]bool, error) {
+		if err := rg.Load(ctx, m); err != nil {
+			return nil, err
 		}
-		fmt.Printf("  %q -> %q [%s];\n", from, to, strings.Join(attrs, ","))
+		return maps.Collect(gmdg.Reqs(rg, m)), nil
 	}
-	visited := mapset.NewSet[gmdg.Dependency]()
-	var visit func(m gmdg.Dependency) error
-	visit = func(m gmdg.Dependency) error {
-		if !visited.Add(m) {
-			return nil
+}
+
+func outputReqTree(ctx context.Context, cfg *config, rg gmdg.RequirementGraph) error {
+	return printTree(cfg, rg.Root(), reqEdges(ctx, rg), gmdg.RequirementCompare, "indirect")
+}
+
+func outputReqRaw(ctx context.Context, cfg *config, rg gmdg.RequirementGraph) error {
+	reqs, done := gmdg.AllRequirements(ctx, rg)
+	sorted := slices.SortedFunc(reqs, gmdg.RequirementCompare)
+	if err := done(); err != nil {
+		return err
+	}
+	mIds := []gmdg.ModuleId(nil)
+	for _, r := range sorted {
+		if cfg.noRoot && r == rg.Root() {
+			continue
 		}
-		attrs := []string{fmt.Sprintf("URL=\"https://pkg.go/dev/%v\"", m)}
-		if m == dg.Root() {
-			attrs = append(attrs, "fillcolor=\"black\"", "fontcolor=\"white\"")
+		mIds = append(mIds, r.Id())
+	}
+	printRaw(cfg, mIds)
+	return nil
+}
+
+// outputBoth prints the requirement graph as a tree followed by the dependency graph resolved from
+// it, so that the effect of resolution can be seen at a glance.
+func outputBoth(ctx context.Context, cfg *config, rg gmdg.RequirementGraph, dg gmdg.DependencyGraph) error {
+	fmt.Print(cfg.theme.repeatf("Requirement graph:"), "\n")
+	if err := outputReqTree(ctx, cfg, rg); err != nil {
+		return err
+	}
+	fmt.Print("\n", cfg.theme.repeatf("Dependency graph:"), "\n")
+	return outputTree(ctx, cfg, dg)
+}
+
+func outputReqDot(ctx context.Context, cfg *config, rg gmdg.RequirementGraph) error {
+	return gmdg.WriteRequirementDot(ctx, os.Stdout, rg, gmdg.RequirementDotOptions{})
+}
+
+// outputMdTable prints the selection set as a Markdown table.  The Direct? column is relative to
+// the root module; the Surprise? column indicates whether any module has the dependency as a
+// surprise dependency.
+func outputMdTable(ctx context.Context, cfg *config, dg gmdg.DependencyGraph) error {
+	root := dg.Root()
+	direct := mapset.NewThreadUnsafeSet(slices.Collect(dg.DirectDeps(root))...)
+	surprise := mapset.NewThreadUnsafeSet[gmdg.Dependency]()
+	for _, d := range gmdg.AllSurpriseDependencies(dg) {
+		surprise.Add(d)
+	}
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
 		}
-		fmt.Printf("  %q [%s];\n", m, strings.Join(attrs, ","))
-		ds := maps.Collect(gmdg.Deps(dg, m))
-		for _, d := range slices.SortedFunc(maps.Keys(ds), gmdg.DependencyCompare) {
-			printEdge(m, d, ds[d])
-			if err := visit(d); err != nil {
-				return err
+		return "no"
+	}
+	fmt.Print("| Module | Version | Direct? | Surprise? |\n")
+	fmt.Print("| --- | --- | --- | --- |\n")
+	for _, d := range slices.SortedFunc(gmdg.AllDependencies(dg), gmdg.DependencyCompare) {
+		dir := yesNo(direct.Contains(d))
+		if d == root {
+			if cfg.noRoot {
+				continue
 			}
+			dir = "root"
+		}
+		fmt.Printf("| %s | %s | %s | %s |\n", d.Id().Path, d.Id().Version, dir, yesNo(surprise.Contains(d)))
+	}
+	return nil
+}
+
+// outputMatrix prints the dependency graph as a sparse adjacency matrix:  a header line with the
+// number of nodes and edges, a legend line "i path@version" for each node (indexed from 0 in
+// [gmdg.DependencyCompare] order), and an "i j surprise" line for each edge (sorted by i then j),
+// where surprise is 1 for a surprise dependency edge and 0 otherwise.
+func outputMatrix(ctx context.Context, cfg *config, dg gmdg.DependencyGraph) error {
+	deps := slices.SortedFunc(gmdg.AllDependencies(dg), gmdg.DependencyCompare)
+	index := map[gmdg.Dependency]int{}
+	for i, d := range deps {
+		index[d] = i
+	}
+	type edge struct{ i, j, s int }
+	edges := []edge(nil)
+	for _, p := range deps {
+		for d, s := range gmdg.Deps(dg, p) {
+			e := edge{index[p], index[d], 0}
+			if s {
+				e.s = 1
+			}
+			edges = append(edges, e)
+		}
+	}
+	slices.SortFunc(edges, func(a, b edge) int {
+		if c := cmp.Compare(a.i, b.i); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.j, b.j)
+	})
+	fmt.Printf("%d %d\n", len(deps), len(edges))
+	for i, d := range deps {
+		fmt.Printf("%d %v\n", i, d)
+	}
+	for _, e := range edges {
+		fmt.Printf("%d %d %d\n", e.i, e.j, e.s)
+	}
+	return nil
+}
+
+// outputPrometheus prints summary metrics of the dependency graph in the Prometheus text exposition
+// format, each labeled with the root module's path.  The metrics are gauges, so unlike counters
+// their names do not end in _total.  The HELP and TYPE lines are printed with each sample, which
+// is only valid because parseFlags allows exactly one root module per invocation.
+func outputPrometheus(ctx context.Context, cfg *config, dg gmdg.DependencyGraph) error {
+	stats := gmdg.DependencyStats(dg)
+	label := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(dg.Root().Id().Path)
+	for _, m := range []struct {
+		name, help string
+		v          int
+	}{
+		{"modules", "Number of selected modules, not counting the root module.", stats.Modules},
+		{"surprise_edges", "Number of surprise dependency edges.", stats.SurpriseEdges},
+		{"cycles", "Number of dependency cycles.", stats.Cycles},
+		{"max_depth", "Length of the longest shortest path from the root module to a selected module.", stats.MaxDepth},
+	} {
+		fmt.Printf("# HELP gomoddepgraph_%s %s\n", m.name, m.help)
+		fmt.Printf("# TYPE gomoddepgraph_%s gauge\n", m.name)
+		fmt.Printf("gomoddepgraph_%s{module=\"%s\"} %d\n", m.name, label, m.v)
+	}
+	return nil
+}
+
+// outputNix prints the selection set, excluding the root module, as a Nix list of attribute sets
+// with each module's path, version, and go.sum checksums.
+func outputNix(ctx context.Context, cfg *config, dg gmdg.DependencyGraph) error {
+	sums, err := gmdg.ModuleSums(ctx, dg)
+	if err != nil {
+		return err
+	}
+	fmt.Print("[\n")
+	for _, s := range sums {
+		fmt.Printf("  { path = %s; version = %s; hash = %s; goModHash = %s; }\n",
+			strconv.Quote(s.Id.Path), strconv.Quote(s.Id.Version),
+			strconv.Quote(s.Hash), strconv.Quote(s.GoModHash))
+	}
+	fmt.Print("]\n")
+	return nil
+}
+
+// outputNdjson prints one JSON object per line for each module in the dependency graph as the graph
+// is walked (see [gmdg.WalkDependencyGraph]).  Each object holds the module and its outgoing edges.
+func outputNdjson(ctx context.Context, cfg *config, dg gmdg.DependencyGraph) error {
+	type edge struct {
+		Module   gmdg.ModuleId
+		Surprise bool
+	}
+	type node struct {
+		Module gmdg.ModuleId
+		Deps   []edge
+	}
+	var mu sync.Mutex
+	enc := json.NewEncoder(os.Stdout)
+	return gmdg.WalkDependencyGraph(dg, dg.Root(), func(m gmdg.Dependency) (bool, error) {
+		deps := maps.Collect(gmdg.Deps(dg, m))
+		n := node{Module: m.Id(), Deps: []edge{}}
+		for _, d := range slices.SortedFunc(maps.Keys(deps), gmdg.DependencyCompare) {
+			n.Deps = append(n.Deps, edge{Module: d.Id(), Surprise: deps[d]})
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return true, enc.Encode(n)
+	}, nil)
+}
+
+// A surpriseReason classifies a surprise dependency by why the module closest to the root that has
+// it as a surprise dependency (see [gmdg.ExplainSurprise]) has an "// indirect" requirement on it.
+// The values are in the order the groups are printed by reportSurprises.
+type surpriseReason int
+
+const (
+	// reasonTool means the "// indirect" requirement is on the module of a tool declared in the
+	// dependent's go.mod (see [gmdg.IsTool]).
+	reasonTool surpriseReason = iota
+	// reasonVia means a direct requirement of the dependent (transitively) requires the surprise
+	// dependency's module, so the "// indirect" requirement records (or upgrades) a version that
+	// the dependent's build needs.
+	reasonVia
+	// reasonUnexplained means no direct requirement of the dependent leads to the surprise
+	// dependency's module.
+	reasonUnexplained
+)
+
+func (r surpriseReason) String() string {
+	switch r {
+	case reasonTool:
+		return "Required for a tool:"
+	case reasonVia:
+		return "Required by a direct requirement:"
+	default:
+		return "Not reachable from any direct requirement:"
+	}
+}
+
+// resolvedGraph returns the graph that dg (possibly a view created by this command, such as by
+// [excludeTools] or [singleMajor]) wraps, which is needed by [gmdg.ExplainSurprise].
+func resolvedGraph(dg gmdg.DependencyGraph) gmdg.DependencyGraph {
+	for {
+		switch w := dg.(type) {
+		case *toolsExcluded:
+			dg = w.DependencyGraph
+		case *depsOmitted:
+			dg = w.DependencyGraph
+		default:
+			return dg
+		}
+	}
+}
+
+// A surprise is a surprise dependency along with its explanation and, for [reasonVia], the first
+// direct requirement of the explained dependent whose own requirements (transitively) include the
+// surprise dependency's module.
+type surprise struct {
+	d   gmdg.Dependency
+	e   *gmdg.Explanation
+	via gmdg.Requirement
+}
+
+// groupSurprises returns each surprise dependency in dg grouped by [surpriseReason], sorted by
+// [gmdg.DependencyCompare] within each group, along with the modules that depend on each.
+func groupSurprises(ctx context.Context, rg gmdg.RequirementGraph, dg gmdg.DependencyGraph) (map[surpriseReason][]surprise, map[gmdg.Dependency][]gmdg.Dependency, error) {
+	dependents := map[gmdg.Dependency][]gmdg.Dependency{}
+	for p, d := range gmdg.AllSurpriseDependencies(dg) {
+		dependents[d] = append(dependents[d], p)
+	}
+	groups := map[surpriseReason][]surprise{}
+	for _, d := range slices.SortedFunc(maps.Keys(dependents), gmdg.DependencyCompare) {
+		e, err := gmdg.ExplainSurprise(ctx, resolvedGraph(dg), d)
+		if err != nil {
+			return nil, nil, err
+		}
+		s := surprise{d: d, e: e}
+		reason := reasonTool
+		if p := rg.Req(e.Dependent.Id()); !gmdg.IsTool(rg, p, e.Requirement) {
+			if s.via, err = surpriseVia(ctx, rg, p, d.Id().Path); err != nil {
+				return nil, nil, err
+			}
+			reason = reasonUnexplained
+			if s.via != nil {
+				reason = reasonVia
+			}
+		}
+		groups[reason] = append(groups[reason], s)
+	}
+	return groups, dependents, nil
+}
+
+// reportSurprises prints each surprise dependency, grouped by [surpriseReason] (see
+// groupSurprises), along with the modules that depend on it, the explanation from
+// [gmdg.ExplainSurprise], and (for [reasonVia]) the direct requirement that leads to the surprise
+// dependency's module.  See failStatus for the exit status.
+func reportSurprises(ctx context.Context, cfg *config, rg gmdg.RequirementGraph, dg gmdg.DependencyGraph) error {
+	groups, dependents, err := groupSurprises(ctx, rg, dg)
+	if err != nil {
+		return err
+	}
+	sep := ""
+	for _, reason := range slices.Sorted(maps.Keys(groups)) {
+		fmt.Print(sep, cfg.theme.repeatf("%v", reason), "\n")
+		sep = "\n"
+		for _, s := range groups[reason] {
+			fmt.Printf("%v\n", s.d)
+			for _, p := range slices.SortedFunc(slices.Values(dependents[s.d]), gmdg.DependencyCompare) {
+				fmt.Printf("  %s %v\n", cfg.theme.repeatf("dependent:"), p)
+			}
+			fmt.Printf("  %s %v\n", cfg.theme.repeatf("explanation:"), s.e)
+			if s.via == nil {
+				continue
+			}
+			fmt.Printf("  %s %v", cfg.theme.repeatf("via:"), s.via)
+			if sel := dg.Selected(s.via.Id()); sel != nil && sel.Id() != s.via.Id() {
+				fmt.Printf(" %s", cfg.theme.repeatf("(selected %v)", sel))
+			}
+			fmt.Print("\n")
+		}
+	}
+	return nil
+}
+
+// staleAge is the age at which reportAges flags a dependency as stale.
+const staleAge = 365 * 24 * time.Hour
+
+// reportAges prints each selected dependency along with the age of its version, oldest first.
+// Dependencies older than staleAge are flagged.  Dependencies with unknown age are printed last.
+func reportAges(ctx context.Context, cfg *config, rg gmdg.RequirementGraph, dg gmdg.DependencyGraph) error {
+	ages, err := gmdg.VersionAge(ctx, dg)
+	if err != nil {
+		return err
+	}
+	deps := slices.Collect(gmdg.Nodes(dg))
+	slices.SortFunc(deps, func(a, b gmdg.Dependency) int {
+		aAge, aOk := ages[a]
+		bAge, bOk := ages[b]
+		if aOk != bOk {
+			if aOk {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(bAge, aAge); c != 0 {
+			return c
+		}
+		return gmdg.DependencyCompare(a, b)
+	})
+	for _, d := range deps {
+		age, ok := ages[d]
+		switch {
+		case !ok:
+			fmt.Printf("%v %s\n", d, cfg.theme.repeatf("(unknown age)"))
+		case age >= staleAge:
+			fmt.Printf("%v %dd %s\n", d, int(age/(24*time.Hour)), cfg.theme.surprisef("(stale)"))
+		default:
+			fmt.Printf("%v %dd\n", d, int(age/(24*time.Hour)))
 		}
-		return nil
 	}
-	fmt.Print("digraph {\n")
-	fmt.Print("  outputorder= \"edgesfirst\";\n")
-	fmt.Print("  overlap = prism;\n")
-	fmt.Print("  overlap_scaling = -10;\n")
-	fmt.Print("  node [style=filled,fillcolor=\"white\",shape=box];\n")
-	if err := visit(dg.Root()); err != nil {
+	return nil
+}
+
+func reportUpgrades(ctx context.Context, cfg *config, rg gmdg.RequirementGraph, dg gmdg.DependencyGraph) error {
+	changes, err := gmdg.UpgradedDependencies(ctx, rg, dg)
+	if err != nil {
 		return err
 	}
-	fmt.Print("}\n")
+	for _, c := range changes {
+		fmt.Printf("%v %s\n", c.A, cfg.theme.surprisef("-> %v", c.B.Id().Version))
+	}
 	return nil
 }
 
-func run(ctx context.Context, cfg *config, mod string) error {
+// surpriseVia returns the first (in [gmdg.RequirementCompare] order) direct requirement of p from
+// which a requirement on a module with the given path is reachable in rg, or nil if there is none.
+func surpriseVia(ctx context.Context, rg gmdg.RequirementGraph, p gmdg.Requirement, path string) (gmdg.Requirement, error) {
+	if err := rg.Load(ctx, p); err != nil {
+		return nil, err
+	}
+	for _, r := range slices.SortedFunc(rg.DirectReqs(p), gmdg.RequirementCompare) {
+		seen := mapset.NewThreadUnsafeSet(r)
+		q := []gmdg.Requirement{r}
+		for len(q) > 0 {
+			m := q[0]
+			q = q[1:]
+			if err := rg.Load(ctx, m); err != nil {
+				return nil, err
+			}
+			for mr := range gmdg.Reqs(rg, m) {
+				if mr.Id().Path == path {
+					return r, nil
+				}
+				if seen.Add(mr) {
+					q = append(q, mr)
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// listRequirements prints every module in the requirement graph, which are the modules whose go.mod
+// files must be fetched to analyze the graph, followed by a count.
+func listRequirements(ctx context.Context, rg gmdg.RequirementGraph) error {
+	reqs, done := gmdg.AllRequirements(ctx, rg)
+	sorted := slices.SortedFunc(reqs, gmdg.RequirementCompare)
+	if err := done(); err != nil {
+		return err
+	}
+	for _, r := range sorted {
+		fmt.Printf("%v\n", r)
+	}
+	slog.InfoContext(ctx, "modules in the requirement graph", "count", len(sorted))
+	return nil
+}
+
+// compareResolvers resolves rg with each of the two resolvers named in cfg.compare and prints the
+// differences between the two selection sets.
+func compareResolvers(ctx context.Context, cfg *config, rg gmdg.RequirementGraph) error {
+	na, nb := cfg.compare[0], cfg.compare[1]
+	a, err := (*allResolveDeps[na])(ctx, rg)
+	if err != nil {
+		return err
+	}
+	b, err := (*allResolveDeps[nb])(ctx, rg)
+	if err != nil {
+		return err
+	}
+	diff := gmdg.DiffDependencyGraphs(a, b)
+	printDiff(cfg, na, nb, diff)
+	if !cfg.noSummary {
+		fmt.Print(cfg.theme.repeatf("%v only %s, %v only %s, %v version differences",
+			len(diff.OnlyA), na, len(diff.OnlyB), nb, len(diff.Changed)), "\n")
+	}
+	return nil
+}
+
+// printDiff prints the differences between the selections of the resolvers named na and nb.
+func printDiff(cfg *config, na, nb string, diff *gmdg.DependencyGraphDiff) {
+	for _, d := range diff.OnlyA {
+		fmt.Printf("%v %s\n", d, cfg.theme.repeatf("(only %s)", na))
+	}
+	for _, d := range diff.OnlyB {
+		fmt.Printf("%v %s\n", d, cfg.theme.repeatf("(only %s)", nb))
+	}
+	for _, c := range diff.Changed {
+		fmt.Printf("%s %s %s %s %s\n", c.A.Id().Path, c.A.Id().Version, cfg.theme.repeatf("(%s) vs", na),
+			c.B.Id().Version, cfg.theme.repeatf("(%s)", nb))
+	}
+}
+
+// verifyResolvers checks the resolvers against each other and prints the differences.  The go and
+// mvs resolvers are compared on the same RequirementsGo graph with [gmdg.VerifyAgainstGo]; they are
+// expected to agree, so it returns exitVerify if they don't.  The mvs and sat resolvers are then
+// compared on a RequirementsComplete graph.  The sat resolver may legitimately select different
+// versions, so those differences are printed but do not affect the exit status.  With --gomod only
+// the latter comparison is possible.
+func verifyResolvers(ctx context.Context, cfg *config, mod string) (int, error) {
+	var summary []string
+	status := 0
+	var crg gmdg.RequirementGraph
+	if cfg.goMod != "" {
+		var err error
+		if crg, err = getRootReqs(ctx, cfg, mod); err != nil {
+			return 0, err
+		}
+	} else {
+		mId, err := resolveRoot(ctx, mod)
+		if err != nil {
+			return 0, err
+		}
+		equal, diff, err := gmdg.VerifyAgainstGo(ctx, mId, reqsOpts(cfg)...)
+		if err != nil {
+			return 0, err
+		}
+		if equal {
+			summary = append(summary, "go and mvs agree")
+		} else {
+			fmt.Print(cfg.theme.repeatf("go (-) vs mvs (+):"), "\n", diff)
+			summary = append(summary, "go and mvs disagree")
+			status = exitVerify
+		}
+		var done func()
+		if crg, done, err = gmdg.RequirementsComplete(ctx, mId, reqsOpts(cfg)...); err != nil {
+			return 0, err
+		}
+		defer done()
+	}
+	var err error
+	var dgs [2]gmdg.DependencyGraph
+	for i, name := range []string{"mvs", "sat"} {
+		if dgs[i], err = (*allResolveDeps[name])(ctx, crg); err != nil {
+			return 0, err
+		}
+	}
+	diff := gmdg.DiffDependencyGraphs(dgs[0], dgs[1])
+	printDiff(cfg, "mvs", "sat", diff)
+	summary = append(summary, fmt.Sprintf("sat differs from mvs for %v modules",
+		len(diff.OnlyA)+len(diff.OnlyB)+len(diff.Changed)))
+	if !cfg.noSummary {
+		fmt.Print(cfg.theme.repeatf("%s", strings.Join(summary, ", ")), "\n")
+	}
+	return status, nil
+}
+
+// failStatus returns the exit status requested by the --fail-on-* options for the given graph, or 0
+// if none of the requested conditions are present.  The surprises report (see reportSurprises) also
+// fails if it contains a [reasonUnexplained] surprise dependency; the others are expected.
+func failStatus(ctx context.Context, cfg *config, rg gmdg.RequirementGraph, dg gmdg.DependencyGraph) (int, error) {
+	status := 0
+	if cfg.failOnSurprise {
+		for p, d := range gmdg.AllSurpriseDependencies(dg) {
+			slog.ErrorContext(ctx, "surprise dependency found", "dependent", p, "dependency", d)
+			status |= exitSurprise
+			break
+		}
+	}
+	if cfg.report == allReport["surprises"] {
+		groups, _, err := groupSurprises(ctx, rg, dg)
+		if err != nil {
+			return 0, err
+		}
+		if ss := groups[reasonUnexplained]; len(ss) > 0 {
+			slog.ErrorContext(ctx, "unexplained surprise dependency found", "count", len(ss), "first", ss[0].d)
+			status |= exitSurprise
+		}
+	}
+	if cfg.failOnCycle {
+		if cycles := gmdg.FindCycles(dg); len(cycles) > 0 {
+			slog.ErrorContext(ctx, "dependency cycle found", "count", len(cycles), "first", cycles[0])
+			status |= exitCycle
+		}
+	}
+	if cfg.licenseAllow != nil {
+		failed, err := checkLicenses(ctx, cfg, dg)
+		if err != nil {
+			return 0, err
+		}
+		if failed {
+			status |= exitLicense
+		}
+	}
+	return status, nil
+}
+
+// checkLicenses logs an error for each dependency (see [gmdg.AllDependencies]) whose license (see
+// [gmdg.DetectLicense]) is not allowed by --license-policy, and reports whether there were any.
+// Dependencies whose license is [gmdg.UnknownLicense] are logged after the disallowed ones, with a
+// different message, unless "UNKNOWN" is in the allow-list.  A license with several parts joined
+// by " AND " is allowed only if every part is.  The root module is not checked, nor are
+// [gmdg.DevelVersion] and [gmdg.LocalVersion] dependencies because their contents cannot be
+// downloaded.
+func checkLicenses(ctx context.Context, cfg *config, dg gmdg.DependencyGraph) (bool, error) {
+	licenses, err := gmdg.DetectLicense(ctx, dg)
+	if err != nil {
+		return false, err
+	}
+	var disallowed, unknown []gmdg.Dependency
+	for d := range gmdg.AllDependencies(dg) {
+		l, ok := licenses[d]
+		switch {
+		case !ok || d == dg.Root():
+		case l == gmdg.UnknownLicense:
+			if !slices.Contains(cfg.licenseAllow, l) {
+				unknown = append(unknown, d)
+			}
+		case slices.ContainsFunc(strings.Split(l, " AND "), func(id string) bool {
+			return !slices.Contains(cfg.licenseAllow, id)
+		}):
+			disallowed = append(disallowed, d)
+		}
+	}
+	slices.SortFunc(disallowed, gmdg.DependencyCompare)
+	slices.SortFunc(unknown, gmdg.DependencyCompare)
+	for _, d := range disallowed {
+		slog.ErrorContext(ctx, "dependency's license is not allowed", "module", d, "license", licenses[d])
+	}
+	for _, d := range unknown {
+		slog.ErrorContext(ctx, "dependency's license is unknown", "module", d)
+	}
+	return len(disallowed) > 0 || len(unknown) > 0, nil
+}
+
+// needsSurprises reports whether the selected output, report, warnings, failure checks, and
+// --single-major might depend on surprise dependencies.  Only those that look at the selection set
+// as a whole (see [gmdg.Nodes]), not the graph's topology, are known not to.  --single-major keeps
+// the major version closest to the root counting surprise edges, so without them it could keep a
+// different one.
+func needsSurprises(cfg *config) bool {
+	if cfg.warnMultiMajor || cfg.failOnSurprise || cfg.failOnCycle || cfg.singleMajor || cfg.licenseAllow != nil {
+		return true
+	}
+	if cfg.report != nil {
+		return cfg.report != allReport["ages"] && cfg.report != allReport["upgrades"]
+	}
+	return cfg.output != allOutput["nix"]
+}
+
+// reqsOpts returns the options passed to every requirement graph constructor.
+func reqsOpts(cfg *config) []gmdg.RequirementsOption {
+	if cfg.modFlag == "" {
+		return nil
+	}
+	return []gmdg.RequirementsOption{gmdg.WithModFlag(cfg.modFlag)}
+}
+
+// withModFlag returns a copy of ctx that adds the --mod flag to GOFLAGS for
+// [gmdg.RequirementsForPackages], which runs the go command in the module's own directory and has no
+// option for it.  It must not be used for other calls:  module queries outside the root module
+// fail with -mod=vendor.
+func withModFlag(ctx context.Context, cfg *config) context.Context {
+	if cfg.modFlag == "" {
+		return ctx
+	}
+	env, _ := ctx.Value(command.EnvKey).([]string)
+	if env == nil {
+		env = os.Environ()
+	}
+	goFlags := ""
+	// Like [os/exec.Cmd], the last entry wins if there are duplicates.
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "GOFLAGS="); ok {
+			goFlags = v
+		}
+	}
+	goFlags = strings.TrimSpace(goFlags + " -mod=" + cfg.modFlag)
+	return context.WithValue(ctx, command.EnvKey, append(slices.Clip(env), "GOFLAGS="+goFlags))
+}
+
+// getRootReqs returns the requirement graph rooted at mod, or at the go.mod given by --gomod or as
+// the root module argument if set (in which case mod is ignored).  A go.mod given as the root
+// module argument is analyzed as the main module (see [gmdg.AsMainModule]) unless --packages is
+// given, in which case the graph is built from its packages instead.
+func getRootReqs(ctx context.Context, cfg *config, mod string) (gmdg.RequirementGraph, error) {
+	if cfg.localDir != "" {
+		if cfg.packages {
+			return gmdg.RequirementsForPackages(withModFlag(ctx, cfg), cfg.localDir, "./...")
+		}
+		goModData, err := os.ReadFile(filepath.Join(cfg.localDir, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		path := modfile.ModulePath(goModData)
+		if path == "" {
+			return nil, fmt.Errorf("%v: no module directive", filepath.Join(cfg.localDir, "go.mod"))
+		}
+		return (*cfg.getReqs)(ctx, gmdg.NewModuleId(path, gmdg.DevelVersion), append(reqsOpts(cfg),
+			gmdg.WithRootDir(cfg.localDir), gmdg.AsMainModule(true))...)
+	}
+	if cfg.goMod != "" {
+		var goModData []byte
+		var err error
+		if cfg.goMod == "-" {
+			goModData, err = io.ReadAll(os.Stdin)
+		} else {
+			goModData, err = os.ReadFile(cfg.goMod)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rg, _, err := gmdg.RequirementsFromGoMod(ctx, goModData)
+		return rg, err
+	}
+	mId, err := resolveRoot(ctx, mod)
+	if err != nil {
+		return nil, err
+	}
+	return (*cfg.getReqs)(ctx, mId, reqsOpts(cfg)...)
+}
+
+// resolveRoot parses the root module argument, resolving a version query if necessary.
+func resolveRoot(ctx context.Context, mod string) (gmdg.ModuleId, error) {
 	mId := gmdg.ParseModuleId(mod)
 	if err := mId.Check(); err != nil {
-		if mId, err = gmdg.ResolveVersion(ctx, mId); err != nil {
-			return err
+		return gmdg.ResolveVersion(ctx, mId)
+	}
+	return mId, nil
+}
+
+// toolsExcluded wraps a [gmdg.DependencyGraph] to hide the root's edges to the modules in tools.
+type toolsExcluded struct {
+	gmdg.DependencyGraph
+	tools map[gmdg.Dependency]bool
+}
+
+func (dg *toolsExcluded) filter(m gmdg.Dependency, deps iter.Seq[gmdg.Dependency]) iter.Seq[gmdg.Dependency] {
+	if m != dg.Root() {
+		return deps
+	}
+	return func(yield func(gmdg.Dependency) bool) {
+		for d := range deps {
+			if !dg.tools[d] && !yield(d) {
+				return
+			}
 		}
 	}
-	rg, err := (*cfg.getReqs)(ctx, mId)
+}
+
+func (dg *toolsExcluded) DirectDeps(m gmdg.Dependency) iter.Seq[gmdg.Dependency] {
+	return dg.filter(m, dg.DependencyGraph.DirectDeps(m))
+}
+
+// SurpriseDeps is also filtered because an indirect requirement of the root is a surprise
+// dependency.
+func (dg *toolsExcluded) SurpriseDeps(m gmdg.Dependency) iter.Seq[gmdg.Dependency] {
+	return dg.filter(m, dg.DependencyGraph.SurpriseDeps(m))
+}
+
+// excludeTools returns a view of dg without the root's edges to modules that the root requires only
+// to provide tools (see [gmdg.IsTool]).  Dependencies that are only reachable through those edges
+// are thus omitted from the output.
+func excludeTools(ctx context.Context, rg gmdg.RequirementGraph, dg gmdg.DependencyGraph) (gmdg.DependencyGraph, error) {
+	root := rg.Root()
+	if err := rg.Load(ctx, root); err != nil {
+		return nil, err
+	}
+	tools := map[gmdg.Dependency]bool{}
+	for r, ind := range gmdg.Reqs(rg, root) {
+		// A tool's module that is also imported by the root is a direct requirement.
+		if ind && gmdg.IsTool(rg, root, r) {
+			tools[dg.Selected(r.Id())] = true
+		}
+	}
+	return &toolsExcluded{dg, tools}, nil
+}
+
+// depsOmitted wraps a [gmdg.DependencyGraph] to hide every edge to the modules in omit.
+type depsOmitted struct {
+	gmdg.DependencyGraph
+	omit map[gmdg.Dependency]bool
+}
+
+func (dg *depsOmitted) filter(deps iter.Seq[gmdg.Dependency]) iter.Seq[gmdg.Dependency] {
+	return func(yield func(gmdg.Dependency) bool) {
+		for d := range deps {
+			if !dg.omit[d] && !yield(d) {
+				return
+			}
+		}
+	}
+}
+
+func (dg *depsOmitted) DirectDeps(m gmdg.Dependency) iter.Seq[gmdg.Dependency] {
+	return dg.filter(dg.DependencyGraph.DirectDeps(m))
+}
+
+func (dg *depsOmitted) SurpriseDeps(m gmdg.Dependency) iter.Seq[gmdg.Dependency] {
+	return dg.filter(dg.DependencyGraph.SurpriseDeps(m))
+}
+
+// singleMajor returns a view of dg that keeps only one major version of each project (see
+// [gmdg.GroupByMajor]):  the one closest to the root, counting both direct and surprise edges, with
+// ties going to the lowest major version.  The other major versions are omitted along with the
+// dependencies that are only reachable through them, and are returned sorted by
+// [gmdg.DependencyCompare].
+func singleMajor(dg gmdg.DependencyGraph) (gmdg.DependencyGraph, []gmdg.Dependency) {
+	dist := map[gmdg.Dependency]int{dg.Root(): 0}
+	q := []gmdg.Dependency{dg.Root()}
+	for len(q) > 0 {
+		p := q[0]
+		q = q[1:]
+		for d := range gmdg.Deps(dg, p) {
+			if _, ok := dist[d]; !ok {
+				dist[d] = dist[p] + 1
+				q = append(q, d)
+			}
+		}
+	}
+	omit := map[gmdg.Dependency]bool{}
+	for _, ds := range gmdg.GroupByMajor(dg) {
+		if len(ds) < 2 {
+			continue
+		}
+		// Compare major versions semantically; sorting by path would put /v10 before /v2.
+		keep := slices.MinFunc(ds, func(a, b gmdg.Dependency) int {
+			return cmp.Or(cmp.Compare(dist[a], dist[b]),
+				semver.Compare(semver.Major(a.Id().Version), semver.Major(b.Id().Version)))
+		})
+		for _, d := range ds {
+			if d != keep && d != dg.Root() {
+				omit[d] = true
+			}
+		}
+	}
+	return &depsOmitted{dg, omit}, slices.SortedFunc(maps.Keys(omit), gmdg.DependencyCompare)
+}
+
+func run(ctx context.Context, cfg *config, mod string) (int, error) {
+	if cfg.verify {
+		return verifyResolvers(ctx, cfg, mod)
+	}
+	rg, err := getRootReqs(ctx, cfg, mod)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	if cfg.warnSelfReqs {
+		self, err := gmdg.SelfRequirements(ctx, rg)
+		if err != nil {
+			return 0, err
+		}
+		for _, r := range self {
+			slog.WarnContext(ctx, "module requires another version of itself", "module", r)
+		}
+	}
+	if cfg.dryRun {
+		return 0, listRequirements(ctx, rg)
 	}
 	if cfg.unify {
 		rg, err = gmdg.UnifyRequirements(ctx, rg)
 		if err != nil {
-			return err
+			return 0, err
 		}
 	}
-	dg, err := (*cfg.resolveDeps)(ctx, rg)
+	if cfg.showReqs {
+		return 0, allReqOutput[cfg.format](ctx, cfg, rg)
+	}
+	if cfg.compare != nil {
+		return 0, compareResolvers(ctx, cfg, rg)
+	}
+	resolveOpts := []gmdg.ResolveOption(nil)
+	if !needsSurprises(cfg) {
+		resolveOpts = append(resolveOpts, gmdg.WithoutSurprises())
+	}
+	dg, err := (*cfg.resolveDeps)(ctx, rg, resolveOpts...)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	if cfg.excludeTools {
+		if dg, err = excludeTools(ctx, rg, dg); err != nil {
+			return 0, err
+		}
+	}
+	if cfg.singleMajor {
+		var omitted []gmdg.Dependency
+		dg, omitted = singleMajor(dg)
+		for _, d := range omitted {
+			slog.WarnContext(ctx, "omitting another major version of a project in the dependency graph",
+				"module", d, "project", d.Id().CanonicalPath())
+		}
 	}
-	return (*cfg.output)(ctx, dg)
+	if cfg.warnRetracted {
+		retracted, err := gmdg.Retractions(ctx, dg)
+		if err != nil {
+			return 0, err
+		}
+		for _, d := range slices.SortedFunc(maps.Keys(retracted), gmdg.DependencyCompare) {
+			slog.WarnContext(ctx, "selected version is retracted", "module", d, "rationale", retracted[d])
+		}
+	}
+	if cfg.warnGoVersion {
+		newer, err := gmdg.GoVersionConflicts(ctx, dg)
+		if err != nil {
+			return 0, err
+		}
+		for _, d := range newer {
+			slog.WarnContext(ctx, "dependency's go directive is newer than the root module's",
+				"module", d, "root", dg.Root())
+		}
+	}
+	if cfg.warnMultiMajor {
+		groups := gmdg.GroupByMajor(dg)
+		for _, p := range slices.Sorted(maps.Keys(groups)) {
+			if ds := groups[p]; len(ds) > 1 {
+				slog.WarnContext(ctx, "multiple major versions selected", "project", p, "modules", ds)
+			}
+		}
+	}
+	if cfg.report != nil {
+		err = (*cfg.report)(ctx, cfg, rg, dg)
+	} else if cfg.showBoth {
+		err = outputBoth(ctx, cfg, rg, dg)
+	} else {
+		err = (*cfg.output)(ctx, cfg, dg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return failStatus(ctx, cfg, rg, dg)
 }
 
 var slogLevel = func() *slog.LevelVar {
@@ -293,27 +1296,138 @@ func parseFlags(ctx context.Context) *config {
 	}
 	choiceFlag(&color.NoColor, "color", colorChoices, "auto", nil,
 		"Output colors according to `mode`.")
+	themeChoices := map[string]*theme{
+		"default": newTheme(
+			color.New(color.FgHiCyan), color.New(color.FgCyan), color.New(color.FgHiBlack)),
+		"light": newTheme(
+			color.New(color.FgBlue, color.Bold), color.New(color.FgBlue), color.New(color.FgHiBlack)),
+		"mono": newTheme(
+			color.New(color.Bold), color.New(color.Underline), color.New(color.Faint)),
+	}
+	choiceFlag(&cfg.theme, "theme", themeChoices, "default", nil,
+		"Colorize output using the palette named `name`.")
 	choiceFlag(&cfg.getReqs, "requirements", allGetReqs, "go",
-		func(_ string) error {
+		func(arg string) error {
 			if cfg.getReqs != allGetReqs["go"] && cfg.resolveDeps == allResolveDeps["go"] {
 				cfg.resolveDeps = allResolveDeps["mvs"]
+				cfg.resolverSwitch = "--requirements=" + arg
 			}
 			return nil
 		},
 		"Generate the requirement graph using the algorithm indicated by `mode`.  Implies '--resolver=mvs' if given a non-'go' mode and the resolver is currently 'go'.")
+	flag.StringVar(&cfg.goMod, "gomod", "",
+		"Read the root module's go.mod from `file` ('-' for standard input) instead of fetching it.  The root module argument must be omitted.  Requires '--requirements=complete'.")
+	flag.BoolVar(&cfg.dryRun, "dry-run", false,
+		"Only list the modules in the requirement graph (whose go.mod files must be fetched) and a count, then exit without resolving dependencies.")
+	flag.StringVar(&cfg.toolchain, "toolchain", "",
+		"Run the go command with GOTOOLCHAIN set to `toolchain` (e.g., 'go1.25.0' or 'local').")
+	flag.StringVar(&cfg.netrc, "netrc", "",
+		"Run the go command with NETRC set to `file` so that it reads module proxy credentials from file instead of ~/.netrc.")
+	flag.StringVar(&cfg.goos, "goos", "",
+		"With '--packages', evaluate build constraints with GOOS set to `os` (e.g., 'windows').")
+	flag.StringVar(&cfg.goarch, "goarch", "",
+		"With '--packages', evaluate build constraints with GOARCH set to `arch` (e.g., 'arm64').")
+	flag.Func("tags",
+		"With '--packages', treat the build tags in the comma-separated `list` as satisfied.",
+		func(arg string) error {
+			cfg.tags = slices.DeleteFunc(strings.Split(arg, ","), func(t string) bool { return t == "" })
+			return nil
+		})
+	flag.Func("mod",
+		"Run the go command for the root module with `-mod=mode`, where mode is 'mod', 'readonly', or 'vendor'.  By default Go's choice applies: 'vendor' if a go.mod file argument's directory has a vendor directory, 'readonly' otherwise.",
+		func(arg string) error {
+			switch arg {
+			case "mod", "readonly", "vendor":
+			default:
+				return fmt.Errorf("invalid mode %q; must be one of mod, readonly, vendor", arg)
+			}
+			cfg.modFlag = arg
+			return nil
+		})
+	flag.BoolVar(&cfg.noWorkspace, "no-workspace", false,
+		"With '--packages', run the go command with GOWORK=off so that the module is analyzed on its own, ignoring any go.work workspace it is in.")
+	flag.BoolVar(&cfg.packages, "packages", false,
+		"With a go.mod file argument, build the requirement graph from the module's packages and their imports (see 'go list -deps') instead of from go.mod files.  Implies '--resolver=mvs' if the resolver is currently 'go'.")
 	flag.BoolFunc("u",
 		"Unify requirement versions before resolving.  Implies '--resolver=mvs' if the resolver is currently 'go'.",
 		func(_ string) error {
 			cfg.unify = true
 			if cfg.resolveDeps == allResolveDeps["go"] {
 				cfg.resolveDeps = allResolveDeps["mvs"]
+				cfg.resolverSwitch = "-u"
 			}
 			return nil
 		})
-	choiceFlag(&cfg.resolveDeps, "resolver", allResolveDeps, "go", nil,
+	choiceFlag(&cfg.resolveDeps, "resolver", allResolveDeps, "go",
+		func(_ string) error {
+			cfg.resolverSwitch = ""
+			return nil
+		},
 		"Resolve dependencies using the algorithm indicated by `mode`.")
-	choiceFlag(&cfg.output, "format", allOutput, "tree", nil,
+	flag.BoolVar(&cfg.strict, "strict", false,
+		"Exit with an error instead of implicitly switching from the go resolver to the mvs resolver.")
+	flag.Func("compare-resolvers",
+		"Resolve dependencies with both resolvers in the comma-separated `pair` (e.g., 'mvs,sat') and print the differences between their selections instead of the dependency graph.",
+		func(arg string) error {
+			names := strings.Split(arg, ",")
+			if len(names) != 2 || names[0] == names[1] {
+				return fmt.Errorf("expected two different resolvers separated by a comma")
+			}
+			for _, n := range names {
+				if _, ok := allResolveDeps[n]; !ok {
+					return fmt.Errorf("unknown resolver %q; expected one of: %v",
+						n, strings.Join(slices.Sorted(maps.Keys(allResolveDeps)), ", "))
+				}
+			}
+			cfg.compare = names
+			return nil
+		})
+	flag.BoolVar(&cfg.verify, "verify", false,
+		"Instead of printing the dependency graph, print how the go and mvs resolvers differ on the same '--requirements=go' graph, then how the sat resolver differs from mvs on a '--requirements=complete' graph.  Exit with status 16 if go and mvs differ.")
+	cfg.format = "tree"
+	choiceFlag(&cfg.output, "format", allOutput, cfg.format,
+		func(arg string) error {
+			cfg.format = arg
+			return nil
+		},
 		"Print dependencies according to `mode`.")
+	choiceFlag(&cfg.showReqs, "show", map[string]bool{"dependencies": false, "requirements": true, "both": false},
+		"dependencies",
+		func(arg string) error {
+			cfg.showBoth = arg == "both"
+			return nil
+		},
+		"Print the graph indicated by `graph` instead of the resolved dependency graph.  With 'requirements', print the (possibly unified) requirement graph, annotating immediate indirect requirements.  With 'both', print the requirement graph followed by the dependency graph.")
+	flag.BoolVar(&cfg.noSummary, "no-summary", false,
+		"With '--format=tree' or '--compare-resolvers', don't print the summary line.")
+	flag.BoolVar(&cfg.noSurprise, "no-surprise", false,
+		"With '--format=tree', omit surprise dependencies and only follow direct dependencies.")
+	flag.BoolVar(&cfg.noRoot, "no-root", false,
+		"With '--format=tree', '--format=raw', or '--format=md-table', don't print the root module.  Tree output starts with the root's dependencies.")
+	flag.BoolVar(&cfg.numberNodes, "number-nodes", false,
+		"With '--format=tree', number each module in visit order and print repeats as '(see [N])' instead of '(repeat)'.")
+	flag.BoolVar(&cfg.excludeTools, "exclude-tools", false,
+		"Omit the dependencies that the root module requires only to provide tools declared by its go.mod's tool directives, along with their subtrees.")
+	flag.BoolVar(&cfg.singleMajor, "single-major", false,
+		"For each project with more than one major version in the dependency graph, keep only the one closest to the root and log a warning for each omitted one.")
+	flag.BoolVar(&cfg.sortByInDegree, "sort-by-indegree", false,
+		"With '--format=raw', sort modules by the number of distinct modules that depend on them, most depended on first.")
+	flag.BoolVar(&cfg.align, "align", false,
+		"With '--format=raw', print paths and versions in aligned columns.")
+	flag.BoolVar(&cfg.layered, "layered", false,
+		"With '--format=dot', draw each module in a row according to the length of the longest path from the root to it.")
+	choiceFlag(&cfg.report, "report", allReport, "none", nil,
+		"Instead of printing the dependency graph, print the report indicated by `mode`.")
+	flag.Func("license-policy",
+		"Exit with status 32 if a dependency's license is not in the `policy`, which has the form 'allow=ID,...' where each ID is an SPDX license identifier (e.g., 'allow=MIT,Apache-2.0').  Include 'UNKNOWN' to allow dependencies whose license is not recognized.",
+		func(arg string) error {
+			ids, ok := strings.CutPrefix(arg, "allow=")
+			if !ok {
+				return fmt.Errorf("invalid policy %q; must have the form allow=ID,...", arg)
+			}
+			cfg.licenseAllow = slices.DeleteFunc(strings.Split(ids, ","), func(id string) bool { return id == "" })
+			return nil
+		})
 	flag.BoolFunc("man", "Show the usage manual and exit.", func(_ string) error {
 		if err := showMan(ctx); err != nil {
 			log.Fatal(err)
@@ -342,8 +1456,68 @@ func parseFlags(ctx context.Context) *config {
 		os.Exit(0)
 		return nil
 	})
+	flag.BoolVar(&cfg.failOnSurprise, "fail-on-surprise", false,
+		"Exit with status 4 if the dependency graph contains a surprise dependency.")
+	flag.BoolVar(&cfg.failOnCycle, "fail-on-cycle", false,
+		"Exit with status 8 if the dependency graph contains a dependency cycle.")
+	flag.BoolVar(&cfg.warnGoVersion, "warn-go-version", false,
+		"Log a warning for each selected dependency whose go directive is newer than the root module's.")
+	flag.BoolVar(&cfg.warnMultiMajor, "warn-multi-major", false,
+		"Log a warning for each project with more than one major version in the dependency graph.")
+	flag.BoolVar(&cfg.warnSelfReqs, "warn-self-requirements", false,
+		"Log a warning for each module in the requirement graph that requires, directly or transitively, another version of itself.")
+	flag.BoolVar(&cfg.warnRetracted, "warn-retracted", false,
+		"Log a warning for each selected dependency whose version has been retracted.")
 	flag.Parse()
-	if cfg.resolveDeps == allResolveDeps["go"] {
+	if args := flag.Args(); len(args) == 1 && filepath.Base(args[0]) == "go.mod" {
+		if fi, err := os.Stat(args[0]); err == nil && fi.Mode().IsRegular() {
+			// Analyze the local module the way Go builds it, honoring its replace and exclude
+			// directives.
+			cfg.localDir = filepath.Dir(args[0])
+			if cfg.verify {
+				// The complete requirement graph that --verify compares against ignores the
+				// directives.
+				log.Fatal("the --verify option cannot be used with a go.mod file argument")
+			}
+		}
+	}
+	if cfg.packages {
+		// Only the mvs and sat resolvers work with a graph built from packages.
+		if cfg.localDir == "" {
+			log.Fatal("the --packages option requires a go.mod file argument")
+		}
+		if cfg.getReqs != allGetReqs["go"] {
+			log.Fatal("the --requirements option cannot be used with --packages")
+		}
+		if slices.Contains(cfg.compare, "go") {
+			log.Fatal("the go resolver cannot be used with --packages")
+		}
+		if cfg.resolveDeps == allResolveDeps["go"] {
+			cfg.resolveDeps = allResolveDeps["mvs"]
+			cfg.resolverSwitch = "--packages"
+		}
+	}
+	if !cfg.packages && (cfg.goos != "" || cfg.goarch != "" || cfg.tags != nil) {
+		// Build constraints only affect package imports, not module requirements.
+		log.Fatal("the --goos, --goarch, and --tags options require --packages")
+	}
+	if cfg.resolverSwitch != "" && cfg.compare == nil && !cfg.verify {
+		if cfg.strict {
+			log.Fatalf("%v cannot be used with the go resolver; pass --resolver=mvs or --resolver=sat", cfg.resolverSwitch)
+		}
+		// The mvs resolver should select the same modules as go, but it works from a different
+		// requirement graph, so its output can differ in surprising ways.
+		slog.Log(ctx, logging.LevelNotice, "using the mvs resolver instead of the go resolver",
+			"reason", cfg.resolverSwitch)
+	}
+	if cfg.compare != nil {
+		if slices.Contains(cfg.compare, "go") && (cfg.getReqs != allGetReqs["go"] || cfg.unify) {
+			log.Fatal("the go resolver cannot be compared with --requirements other than go or with -u")
+		}
+		if cfg.showReqs || cfg.report != nil {
+			log.Fatal("the --compare-resolvers option cannot be used with --show=requirements or --report")
+		}
+	} else if cfg.resolveDeps == allResolveDeps["go"] {
 		if cfg.getReqs != allGetReqs["go"] {
 			log.Fatal("the go dependency resolver requires the go requirements collector")
 		}
@@ -351,8 +1525,48 @@ func parseFlags(ctx context.Context) *config {
 			log.Fatal("the -u option cannot be used in combination with the go resolver")
 		}
 	}
+	if cfg.excludeTools && (cfg.unify || cfg.showReqs || cfg.compare != nil) {
+		log.Fatal("the --exclude-tools option cannot be used with -u, --show=requirements, or --compare-resolvers")
+	}
+	if cfg.singleMajor && (cfg.showReqs || cfg.compare != nil) {
+		log.Fatal("the --single-major option cannot be used with --show=requirements or --compare-resolvers")
+	}
+	if cfg.verify && (cfg.unify || cfg.showReqs || cfg.compare != nil || cfg.report != nil || cfg.dryRun) {
+		log.Fatal("the --verify option cannot be used with -u, --show=requirements, --compare-resolvers, --report, or --dry-run")
+	}
+	if cfg.noRoot && (cfg.format == "dot" || cfg.format == "matrix") {
+		log.Fatalf("the --no-root option cannot be used with --format=%v", cfg.format)
+	}
+	if cfg.showReqs {
+		if _, ok := allReqOutput[cfg.format]; !ok {
+			log.Fatalf("--format=%v cannot be used with --show=requirements", cfg.format)
+		}
+		if cfg.report != nil {
+			log.Fatal("the --report option cannot be used with --show=requirements")
+		}
+		if cfg.sortByInDegree {
+			log.Fatal("the --sort-by-indegree option cannot be used with --show=requirements")
+		}
+	}
+	if cfg.showBoth {
+		if cfg.format != "tree" {
+			log.Fatal("--show=both requires --format=tree")
+		}
+		if cfg.report != nil || cfg.compare != nil || cfg.verify {
+			log.Fatal("--show=both cannot be used with --report, --compare-resolvers, or --verify")
+		}
+	}
 	cfg.mods = flag.Args()
-	if len(cfg.mods) != 1 {
+	if cfg.goMod != "" {
+		if len(cfg.mods) != 0 {
+			log.Fatal("a root module cannot be given with --gomod")
+		}
+		if cfg.getReqs != allGetReqs["complete"] {
+			log.Fatal("the --gomod option requires --requirements=complete")
+		}
+		// The root module is read from the go.mod; see getRootReqs.
+		cfg.mods = []string{""}
+	} else if len(cfg.mods) != 1 {
 		log.Fatal("exactly one root module is required")
 	}
 	return cfg
@@ -362,10 +1576,32 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	cfg := parseFlags(ctx)
+	if cfg.toolchain != "" {
+		ctx = gmdg.WithToolchain(ctx, cfg.toolchain)
+	}
+	if cfg.netrc != "" {
+		ctx = gmdg.WithNetrc(ctx, cfg.netrc)
+	}
+	if cfg.noWorkspace {
+		ctx = gmdg.WithoutWorkspace(ctx)
+	}
+	ctx = gmdg.WithBuildConstraints(ctx, cfg.goos, cfg.goarch, cfg.tags...)
+	exit := func(status int) {
+		// The clones are kept for reuse across analyses of the same module, so they must be removed
+		// before exiting.
+		if err := gmdg.PurgeTempClones(); err != nil {
+			slog.WarnContext(ctx, "failed to remove temporary clones", "error", err)
+		}
+		os.Exit(status)
+	}
+	status := 0
 	for _, mod := range cfg.mods {
-		if err := run(ctx, cfg, mod); err != nil {
+		s, err := run(ctx, cfg, mod)
+		if err != nil {
 			slog.ErrorContext(ctx, "failed", "error", err)
-			os.Exit(1)
+			exit(1)
 		}
+		status |= s
 	}
+	exit(status)
 }
@@ -0,0 +1,95 @@
+package gomoddepgraph
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// A Discrepancy is a requirement edge that is present in one of the [RequirementsGo] and
+// [RequirementsComplete] graphs but unexpectedly absent from the other.  See [CrossCheck].
+type Discrepancy struct {
+	From, To ModuleId
+	// MissingFromGo is true if From's go.mod lists the requirement on To but `go mod graph` did not
+	// report it even though it reported other requirements of From.  It is false if `go mod graph`
+	// reported the requirement but From's go.mod does not list it.
+	MissingFromGo bool
+}
+
+func (d Discrepancy) String() string {
+	if d.MissingFromGo {
+		return fmt.Sprintf("%v -> %v: listed in go.mod but not reported by go mod graph", d.From, d.To)
+	}
+	return fmt.Sprintf("%v -> %v: reported by go mod graph but not listed in go.mod", d.From, d.To)
+}
+
+// CrossCheck builds both the [RequirementsGo] and [RequirementsComplete] graphs for the given root
+// module and returns the edges on which they disagree, sorted by [Discrepancy.From] then
+// [Discrepancy.To] (see [ModuleIdCompare]).  The options are passed to both constructors.
+//
+// Differences caused by [pruned] module graphs are expected and not reported:  Go omits the
+// requirements of modules whose go.mod it does not need to load, so a module with no requirements
+// in the [RequirementsGo] graph is not compared.  Otherwise, `go mod graph` reports every
+// requirement listed in a loaded module's go.mod, so any other difference indicates a problem such
+// as an inconsistent [module proxy] or module cache, or a change in Go's pruning behavior.
+//
+// [pruned]: https://go.dev/ref/mod#graph-pruning
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+func CrossCheck(ctx context.Context, rootId ModuleId, opts ...RequirementsOption) ([]Discrepancy, error) {
+	crg, done, err := RequirementsComplete(ctx, rootId, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	var (
+		mu  sync.Mutex
+		ret []Discrepancy
+	)
+	unlisted := withUnlistedReqs(func(p, m ModuleId) {
+		mu.Lock()
+		defer mu.Unlock()
+		ret = append(ret, Discrepancy{From: p, To: m})
+	})
+	grg, err := RequirementsGo(ctx, rootId, append(slices.Clone(opts), WithCompleteGraph(crg), unlisted)...)
+	if err != nil {
+		return nil, err
+	}
+	missing, err := missingFromGo(ctx, crg, grg)
+	if err != nil {
+		return nil, err
+	}
+	ret = append(ret, missing...)
+	slices.SortFunc(ret, func(a, b Discrepancy) int {
+		return cmp.Or(ModuleIdCompare(a.From, b.From), ModuleIdCompare(a.To, b.To))
+	})
+	return ret, nil
+}
+
+// missingFromGo returns the requirements in crg that are absent from grg for each module in grg
+// that has at least one requirement in grg.
+func missingFromGo(ctx context.Context, crg, grg RequirementGraph) ([]Discrepancy, error) {
+	var ret []Discrepancy
+	nodes, done := AllRequirements(ctx, grg)
+	for p := range nodes {
+		reported := map[ModuleId]bool{}
+		for m := range Reqs(grg, p) {
+			reported[m.Id()] = true
+		}
+		if len(reported) == 0 {
+			continue
+		}
+		cp := crg.Req(p.Id())
+		if err := crg.Load(ctx, cp); err != nil {
+			done()
+			return nil, err
+		}
+		for m := range Reqs(crg, cp) {
+			if !reported[m.Id()] {
+				ret = append(ret, Discrepancy{From: p.Id(), To: m.Id(), MissingFromGo: true})
+			}
+		}
+	}
+	return ret, done()
+}
@@ -0,0 +1,35 @@
+package gomoddepgraph
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMissingFromGo(t *testing.T) {
+	t.Parallel()
+	crg := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": true},
+		"example.com/a@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/c@v1.0.0": {},
+	})
+	grg := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		// Missing r -> b, which is unexpected because r's other requirements were reported.
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false},
+		// a's requirements were pruned, which is expected.
+		"example.com/a@v1.0.0": {},
+	})
+	got, err := missingFromGo(t.Context(), crg, grg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Discrepancy{{
+		From:          ParseModuleId("example.com/r@v1.0.0"),
+		To:            ParseModuleId("example.com/b@v1.0.0"),
+		MissingFromGo: true,
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected discrepancies (-want +got):\n%s", diff)
+	}
+}
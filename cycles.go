@@ -0,0 +1,94 @@
+package gomoddepgraph
+
+import (
+	"maps"
+	"slices"
+)
+
+// FindCycles returns the dependency cycles in the given [DependencyGraph], considering both direct
+// and surprise dependency edges (see [Deps]).  Each returned cycle is a strongly connected
+// component of the graph that contains more than one [Dependency] or a [Dependency] that depends
+// on itself.  The [Dependency] objects within each cycle are sorted by [DependencyCompare], and the
+// cycles are sorted by their first [Dependency].  Returns nil if the graph is acyclic.
+func FindCycles(dg DependencyGraph) [][]Dependency {
+	ret := [][]Dependency(nil)
+	for _, scc := range stronglyConnectedComponents(dg) {
+		if len(scc) > 1 || dependsOn(dg, scc[0], scc[0]) {
+			ret = append(ret, scc)
+		}
+	}
+	slices.SortFunc(ret, func(a, b []Dependency) int { return DependencyCompare(a[0], b[0]) })
+	return ret
+}
+
+// dependsOn reports whether d is one of p's direct or surprise dependencies.
+func dependsOn(dg DependencyGraph, p, d Dependency) bool {
+	for m := range Deps(dg, p) {
+		if m == d {
+			return true
+		}
+	}
+	return false
+}
+
+// stronglyConnectedComponents returns the strongly connected components of the given
+// [DependencyGraph] that are reachable from the root, considering both direct and surprise
+// dependency edges.  Every reachable [Dependency] is in exactly one component, even if it is not
+// part of a cycle.  The [Dependency] objects within each component are sorted by
+// [DependencyCompare].  The components are returned in reverse topological order (a component is
+// returned before any component that depends on it).
+func stronglyConnectedComponents(dg DependencyGraph) [][]Dependency {
+	// Tarjan's strongly connected components algorithm, using an explicit call stack instead of
+	// recursion so that pathologically deep graphs do not exhaust the goroutine stack.
+	type nodeState struct{ index, lowLink int }
+	type frame struct {
+		m  Dependency
+		ms *nodeState
+		// ds holds the dependencies of m that have not been examined yet.
+		ds []Dependency
+	}
+	states := map[Dependency]*nodeState{}
+	stack := []Dependency(nil)
+	onStack := map[Dependency]bool{}
+	calls := []*frame(nil)
+	ret := [][]Dependency(nil)
+	enter := func(m Dependency) {
+		ms := &nodeState{len(states), len(states)}
+		states[m] = ms
+		stack = append(stack, m)
+		onStack[m] = true
+		ds := slices.SortedFunc(maps.Keys(maps.Collect(Deps(dg, m))), DependencyCompare)
+		calls = append(calls, &frame{m, ms, ds})
+	}
+	enter(dg.Root())
+	for len(calls) > 0 {
+		f := calls[len(calls)-1]
+		if len(f.ds) > 0 {
+			d := f.ds[0]
+			f.ds = f.ds[1:]
+			if ds := states[d]; ds == nil {
+				enter(d)
+			} else if onStack[d] {
+				f.ms.lowLink = min(f.ms.lowLink, ds.index)
+			}
+			continue
+		}
+		calls = calls[:len(calls)-1]
+		if len(calls) > 0 {
+			p := calls[len(calls)-1].ms
+			p.lowLink = min(p.lowLink, f.ms.lowLink)
+		}
+		if f.ms.lowLink != f.ms.index {
+			continue
+		}
+		i := slices.Index(stack, f.m)
+		scc := slices.Clone(stack[i:])
+		stack = stack[:i]
+		for _, d := range scc {
+			onStack[d] = false
+		}
+		slices.SortFunc(scc, DependencyCompare)
+		ret = append(ret, scc)
+	}
+	return ret
+}
@@ -0,0 +1,146 @@
+package gomoddepgraph
+
+import (
+	"bytes"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInDegree(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/c@v1.0.0": false},
+		"example.com/c@v1.0.0": {"example.com/b@v1.0.0": false},
+	})
+	got := map[string]int{}
+	for d, n := range InDegree(dg) {
+		got[d.Id().Path] = n
+	}
+	want := map[string]int{
+		"example.com/r": 0,
+		"example.com/a": 2,
+		"example.com/b": 2,
+		"example.com/c": 2,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("InDegree() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFindCycles(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/c@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/d@v1.0.0": false},
+		"example.com/d@v1.0.0": {},
+		"example.com/s@v1.0.0": {"example.com/s@v1.0.0": false},
+	})
+	got := [][]string(nil)
+	for _, c := range FindCycles(dg) {
+		s := []string(nil)
+		for _, d := range c {
+			s = append(s, strings.TrimPrefix(d.Id().Path, "example.com/"))
+		}
+		got = append(got, s)
+	}
+	want := [][]string{{"a", "b", "c"}, {"s"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected cycles (-want +got):\n%s", diff)
+	}
+	acyclic := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false},
+		"example.com/a@v1.0.0": {},
+	})
+	if got := FindCycles(acyclic); got != nil {
+		t.Errorf("got cycles %v in acyclic graph, want nil", got)
+	}
+}
+
+// TestDeepChain checks that the depth-first graph walks handle a very long dependency chain.  The
+// goroutine stack is capped so that a walk that recurses once per hop crashes instead of silently
+// growing the stack.  The cap is process-wide, so this test must not run in parallel.
+func TestDeepChain(t *testing.T) {
+	const n = 5000
+	id := func(i int) string { return fmt.Sprintf("example.com/m%d@v1.0.0", i) }
+	g := map[string]map[string]bool{}
+	for i := range n - 1 {
+		g[id(i)] = map[string]bool{id(i + 1): false}
+	}
+	// The last module requires the first so that the whole chain is one cycle.
+	g[id(n-1)] = map[string]bool{id(0): false}
+	dg := newTestDependencyGraph(t, id(0), g)
+
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+	if cycles := FindCycles(dg); len(cycles) != 1 || len(cycles[0]) != n {
+		t.Errorf("got %d cycles, want one cycle of %d modules", len(cycles), n)
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteDot(buf, dg, DotOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Count(buf.String(), " -> "), n; got != want {
+		t.Errorf("got %d DOT edges, want %d", got, want)
+	}
+	depth := 0
+	err := WalkDependencyGraphPaths(dg, dg.Root(), func(path []Dependency) (bool, error) {
+		depth = max(depth, len(path))
+		return true, nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depth != n {
+		t.Errorf("got deepest path of %d modules, want %d", depth, n)
+	}
+}
+
+func TestInDegree_EdgeCases(t *testing.T) {
+	t.Parallel()
+	want := map[string]map[string]int{
+		"empty": {"r": 0},
+		// The root's in-degree is normally 0, but not if it is in a cycle.
+		"cycles":     {"r": 1, "a": 1, "b": 2, "c": 1},
+		"multi-root": {"gomoddepgraph.invalid/multiroot": 0, "x": 1, "y": 1, "z": 2},
+	}
+	for _, tc := range edgeCaseGraphs {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			got := map[string]int{}
+			for d, n := range InDegree(newTestDependencyGraph(t, tc.root, tc.g)) {
+				got[strings.TrimPrefix(d.Id().Path, "example.com/")] = n
+			}
+			if diff := cmp.Diff(want[tc.desc], got); diff != "" {
+				t.Errorf("InDegree() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFindCycles_EdgeCases(t *testing.T) {
+	t.Parallel()
+	want := map[string][][]string{
+		"empty":      nil,
+		"cycles":     {{"a", "r"}, {"b", "c"}},
+		"multi-root": nil,
+	}
+	for _, tc := range edgeCaseGraphs {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			got := [][]string(nil)
+			for _, c := range FindCycles(newTestDependencyGraph(t, tc.root, tc.g)) {
+				got = append(got, shortPaths(c))
+			}
+			if diff := cmp.Diff(want[tc.desc], got); diff != "" {
+				t.Errorf("unexpected cycles (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
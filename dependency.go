@@ -32,3 +32,37 @@ var _ Dependency = dependency{}
 func (d dependency) Id() ModuleId {
 	return d.ModuleId
 }
+
+// localDependency is a [Dependency] on a module with version [LocalVersion].  See
+// [localRequirement].
+type localDependency struct {
+	ModuleId
+	dir string
+}
+
+var _ Dependency = localDependency{}
+
+func (d localDependency) Id() ModuleId {
+	return d.ModuleId
+}
+
+// newDependency returns the [Dependency] that is selected to satisfy the given [Requirement] when
+// the [Requirement]'s version is the selected version.
+func newDependency(r Requirement) Dependency {
+	if lr, ok := r.(localRequirement); ok {
+		return localDependency(lr)
+	}
+	return dependency{r.Id()}
+}
+
+// LocalPath returns the absolute path of the local directory that replaces the given [Dependency]'s
+// module, if its version is [LocalVersion] and the directory is known.  Such dependencies come from
+// [RequirementsForPackages] graphs (possibly unified with [UnifyRequirements]) resolved by
+// [ResolveMvs] or [ResolveSat].
+func LocalPath(d Dependency) (string, bool) {
+	ld, ok := d.(localDependency)
+	if !ok {
+		return "", false
+	}
+	return ld.dir, true
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"fmt"
 	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/rhansen/gomoddepgraph/internal/itertools"
 	"golang.org/x/mod/semver"
+	"golang.org/x/sync/errgroup"
 )
 
 // A DependencyGraph is a directed graph (often cyclic) representing the modules selected to satisfy
@@ -35,6 +40,31 @@ type DependencyGraph interface {
 	SurpriseDeps(m Dependency) iter.Seq[Dependency]
 }
 
+// Dep returns the [Dependency] in the given [DependencyGraph] that has the given [ModuleId], or nil
+// if no such [Dependency] exists.  Unlike [DependencyGraph.Selected], the version must match
+// exactly; a lower version does not match the selected [Dependency].  This is the counterpart of
+// [RequirementGraph.Req] and is useful for getting the node to pass to methods such as
+// [DependencyGraph.DirectDeps].  May return a non-nil [Dependency] that is not reachable from
+// [DependencyGraph.Root] (see [Nodes]).
+//
+// This is a constant-time lookup for the graphs returned from this package's resolvers.  For other
+// [DependencyGraph] implementations it walks the graph.
+func Dep(dg DependencyGraph, mId ModuleId) Dependency {
+	if dg, ok := dg.(*dependencyGraph); ok {
+		d, ok := dg.sel[mId.Path]
+		if !ok || d.Id() != mId {
+			return nil
+		}
+		return d
+	}
+	for d := range Nodes(dg) {
+		if d.Id() == mId {
+			return d
+		}
+	}
+	return nil
+}
+
 // Deps is a convenience function that returns both [DependencyGraph.DirectDeps] and
 // [DependencyGraph.SurpriseDeps], with the mapped value set to true for any surprise dependencies.
 func Deps(dg DependencyGraph, d Dependency) iter.Seq2[Dependency, bool] {
@@ -44,9 +74,14 @@ func Deps(dg DependencyGraph, d Dependency) iter.Seq2[Dependency, bool] {
 }
 
 type dependencyGraph struct {
-	rg       RequirementGraph
-	sel      map[string]Dependency
+	rg  RequirementGraph
+	sel map[string]Dependency
+	// surprise is assigned once, by computeAllSurpriseDeps, after all surprise dependencies have
+	// been computed and before the graph is returned to the caller.  Neither the map nor its sets are
+	// modified afterward, so the graph is safe for concurrent use without synchronization.  Any
+	// future code that mutates a returned graph must add its own synchronization.
 	surprise map[Dependency]mapset.Set[Dependency]
+	prov     Provenance
 }
 
 var _ DependencyGraph = (*dependencyGraph)(nil)
@@ -57,7 +92,7 @@ func (dg *dependencyGraph) Root() Dependency {
 
 func (dg *dependencyGraph) Selected(req ModuleId) Dependency {
 	d, ok := dg.sel[req.Path]
-	if !ok || semver.Compare(d.Id().Version, req.Version) < 0 {
+	if !ok || versionCompare(d.Id().Version, req.Version) < 0 {
 		return nil
 	}
 	return d
@@ -85,6 +120,48 @@ func (dg *dependencyGraph) SurpriseDeps(m Dependency) iter.Seq[Dependency] {
 	return mapset.Elements(dg.surprise[m])
 }
 
+func (dg *dependencyGraph) provenance() Provenance {
+	return dg.prov
+}
+
+// computeAllSurpriseDeps computes the set of surprise dependencies for each dependency in the
+// selection set and assigns the result to dg.surprise.  If the graph was resolved with
+// [WithoutSurprises], each set is left empty.  The results are collected in a separate map that is
+// assigned only after every goroutine has finished, so dg.surprise is never written concurrently
+// with a read.
+// [DependencyGraph.DirectDeps] must work before this is called.
+func (dg *dependencyGraph) computeAllSurpriseDeps(ctx context.Context) error {
+	// TODO: This implementation is O(|V|*(|V|+|E|)), which can be improved.  However, a more
+	// efficient implementation might be tricky due to possible dependency cycles.
+	var mu sync.Mutex
+	surprise := map[Dependency]mapset.Set[Dependency]{}
+	if dg.prov.WithoutSurprises {
+		for _, d := range dg.sel {
+			surprise[d] = mapset.NewThreadUnsafeSet[Dependency]()
+		}
+		dg.surprise = surprise
+		return nil
+	}
+	gr, ctx := errgroup.WithContext(ctx)
+	for _, d := range dg.sel {
+		gr.Go(func() error {
+			s, err := computeSurpriseDeps(ctx, dg.rg, dg, d)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			surprise[d] = s
+			return nil
+		})
+	}
+	if err := gr.Wait(); err != nil {
+		return err
+	}
+	dg.surprise = surprise
+	return nil
+}
+
 // computeSurpriseDeps discovers any surprise dependencies without calling
 // [DependencyGraph.SurpriseDeps].  This can be used to implement [DependencyGraph.SurpriseDeps],
 // but note that [DependencyGraph.DirectDeps] must return the correct direct dependencies for every
@@ -162,17 +239,131 @@ func WalkDependencyGraph(dg DependencyGraph, start Dependency,
 	nodeVisit func(m Dependency) (bool, error),
 	edgeVisit func(p, m Dependency, surprise bool) error) error {
 
-	return walkDependencyGraph(context.Background(), dg, start,
-		func(ctx context.Context, m Dependency) (bool, error) { return nodeVisit(m) },
-		func(ctx context.Context, p, m Dependency, s bool) error { return edgeVisit(p, m, s) })
+	var nv func(ctx context.Context, m Dependency) (bool, error)
+	if nodeVisit != nil {
+		nv = func(ctx context.Context, m Dependency) (bool, error) { return nodeVisit(m) }
+	}
+	var ev func(ctx context.Context, p, m Dependency, s bool) error
+	if edgeVisit != nil {
+		ev = func(ctx context.Context, p, m Dependency, s bool) error { return edgeVisit(p, m, s) }
+	}
+	return walkDependencyGraph(context.Background(), dg, start, nv, ev)
+}
+
+// WalkDirectDeps is like [WalkDependencyGraph] except it only follows [DependencyGraph.DirectDeps]
+// edges; [DependencyGraph.SurpriseDeps] edges are ignored entirely.  The result matches the
+// intuitive notion of "dependencies of dependencies".  A node that is only reachable via a surprise
+// edge is not visited.
+func WalkDirectDeps(dg DependencyGraph, start Dependency,
+	nodeVisit func(m Dependency) (bool, error),
+	edgeVisit func(p, m Dependency) error) error {
+
+	var nv func(ctx context.Context, m Dependency) (bool, error)
+	if nodeVisit != nil {
+		nv = func(ctx context.Context, m Dependency) (bool, error) { return nodeVisit(m) }
+	}
+	var ev func(ctx context.Context, p, m Dependency, _ struct{}) error
+	if edgeVisit != nil {
+		ev = func(ctx context.Context, p, m Dependency, _ struct{}) error { return edgeVisit(p, m) }
+	}
+	edges := func(m Dependency) iter.Seq2[Dependency, struct{}] {
+		return itertools.Attach(dg.DirectDeps(m), struct{}{})
+	}
+	return walkGraph(context.Background(), start, nv, nil, edges, ev)
+}
+
+// WalkDependencyGraphPaths is like [WalkDependencyGraph] except the nodeVisit callback is passed
+// the path from start to the visited node (inclusive of both ends) rather than just the node.  Each
+// node's path is a shortest path by which the walk discovered the node.  A node can be reachable
+// via many paths of the same length; which of those paths is passed to nodeVisit is arbitrary (but
+// consistent across walks of the same graph).  The nodeVisit callback must not modify the path.
+//
+// Unlike [WalkDependencyGraph], nodes are visited one at a time in breadth-first order and the
+// callbacks are not called concurrently.  As with [WalkDependencyGraph], no edgeVisit callback is
+// called for a pair of nodes before the nodeVisit callbacks for the two nodes have both returned,
+// and the walk stops at the first error.
+func WalkDependencyGraphPaths(dg DependencyGraph, start Dependency,
+	nodeVisit func(path []Dependency) (bool, error),
+	edgeVisit func(p, m Dependency, surprise bool) error) error {
+
+	type edge struct {
+		p        Dependency
+		surprise bool
+	}
+	paths := map[Dependency][]Dependency{start: {start}}
+	visited := mapset.NewThreadUnsafeSet[Dependency]()
+	// pending holds the edges to discovered but not yet visited nodes.
+	pending := map[Dependency][]edge{}
+	q := []Dependency{start}
+	for len(q) > 0 {
+		m := q[0]
+		q = q[1:]
+		descend := true
+		if nodeVisit != nil {
+			var err error
+			if descend, err = nodeVisit(slices.Clip(paths[m])); err != nil {
+				return err
+			}
+		}
+		visited.Add(m)
+		if edgeVisit != nil {
+			for _, e := range pending[m] {
+				if err := edgeVisit(e.p, m, e.surprise); err != nil {
+					return err
+				}
+			}
+		}
+		delete(pending, m)
+		if !descend {
+			continue
+		}
+		deps := maps.Collect(Deps(dg, m))
+		for _, d := range slices.SortedFunc(maps.Keys(deps), DependencyCompare) {
+			if _, ok := paths[d]; !ok {
+				paths[d] = append(slices.Clip(paths[m]), d)
+				q = append(q, d)
+			}
+			if edgeVisit == nil {
+				continue
+			}
+			if !visited.Contains(d) {
+				pending[d] = append(pending[d], edge{m, deps[d]})
+			} else if err := edgeVisit(m, d, deps[d]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Nodes yields every [Dependency] in the selection set in an unspecified order.  Unlike
+// [AllDependencies], this does not walk the graph if the [DependencyGraph] already holds the
+// selection set in memory (as is the case for the graphs returned from this package's resolvers),
+// so it is cheaper when only the set of nodes is needed, not the topology.
+//
+// The selection set held in memory can include a module that is not reachable from
+// [DependencyGraph.Root], such as a module that was selected only because an older (unselected)
+// version of another module requires it.  Nodes yields such modules, but [AllDependencies] does
+// not.  For other [DependencyGraph] implementations there is no way to enumerate unreachable
+// modules, so Nodes falls back to [AllDependencies] and yields only the reachable modules.
+func Nodes(dg DependencyGraph) iter.Seq[Dependency] {
+	if dg, ok := dg.(*dependencyGraph); ok {
+		return maps.Values(dg.sel)
+	}
+	return AllDependencies(dg)
 }
 
 // AllDependencies walks the given [DependencyGraph] and yields every [Dependency] it encounters.
 // The [Dependency] objects are yielded in topological order.  Together, these [Dependency] objects
 // form the selection set, which are the modules selected to satisfy the requirements of
-// [DependencyGraph.Root] and the selected dependencies' own requirements.
+// [DependencyGraph.Root] and the selected dependencies' own requirements, except for any selected
+// modules that are not reachable from [DependencyGraph.Root] (see [Nodes]).  If the graph was
+// resolved with [WithoutSurprises], modules that are reachable only via surprise dependencies are
+// not yielded either.
+//
+// Use [AllDependenciesContext] to be able to cancel the walk.
 func AllDependencies(dg DependencyGraph) iter.Seq[Dependency] {
-	deps, done := allNodes(context.Background(), dg, dg.Root(), walkDependencyGraph)
+	deps, done := AllDependenciesContext(context.Background(), dg)
 	return func(yield func(Dependency) bool) {
 		defer func() {
 			if err := done(); err != nil {
@@ -186,3 +377,185 @@ func AllDependencies(dg DependencyGraph) iter.Seq[Dependency] {
 		}
 	}
 }
+
+// AllDependenciesContext is like [AllDependencies] except the walk stops promptly if ctx is done.
+// The returned done callback must be called when done iterating; it returns the first error
+// encountered during the walk, which is the context's error if the walk was stopped by ctx.
+func AllDependenciesContext(ctx context.Context, dg DependencyGraph) (iter.Seq[Dependency], func() error) {
+	return allNodes(ctx, dg, dg.Root(), walkDependencyGraph)
+}
+
+// AllSurpriseDependencies walks the given [DependencyGraph] and yields each (dependent, surprise
+// dependency) pair, where the second [Dependency] is one of the first's
+// [DependencyGraph.SurpriseDeps].  Dependents are yielded in topological order.
+func AllSurpriseDependencies(dg DependencyGraph) iter.Seq2[Dependency, Dependency] {
+	return func(yield func(Dependency, Dependency) bool) {
+		for p := range AllDependencies(dg) {
+			for d := range dg.SurpriseDeps(p) {
+				if !yield(p, d) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// CollectDependencyGraph walks the given [DependencyGraph] and returns it as a plain map.  Each key
+// is the [Dependency.Id] of a node reachable from the root (see [AllDependencies]), and its value
+// maps the [Dependency.Id] of each of the node's dependencies to true if it is a surprise
+// dependency (see [Deps]).  Every node is a key, even if it has no dependencies.  This is
+// convenient for comparing graphs or serializing them.
+func CollectDependencyGraph(dg DependencyGraph) map[ModuleId]map[ModuleId]bool {
+	ret := map[ModuleId]map[ModuleId]bool{}
+	for p := range AllDependencies(dg) {
+		ret[p.Id()] = map[ModuleId]bool{}
+		for d, surprise := range Deps(dg, p) {
+			ret[p.Id()][d.Id()] = surprise
+		}
+	}
+	return ret
+}
+
+// Reachable reports whether to is reachable from from by following direct and surprise dependency
+// edges (see [Deps]).  A [Dependency] is always reachable from itself.  Cycles are handled.
+func Reachable(dg DependencyGraph, from, to Dependency) bool {
+	if from == to {
+		return true
+	}
+	visited := map[Dependency]bool{from: true}
+	q := []Dependency{from}
+	for len(q) > 0 {
+		p := q[0]
+		q = q[1:]
+		for d := range Deps(dg, p) {
+			if d == to {
+				return true
+			}
+			if !visited[d] {
+				visited[d] = true
+				q = append(q, d)
+			}
+		}
+	}
+	return false
+}
+
+// InDegree returns, for each [Dependency] in the graph (see [AllDependencies]), the number of
+// distinct dependencies that have a direct or surprise dependency edge to it (see [Deps]).  The
+// root is included, normally with an in-degree of 0.
+func InDegree(dg DependencyGraph) map[Dependency]int {
+	ret := map[Dependency]int{}
+	for p := range AllDependencies(dg) {
+		ret[p] += 0
+		seen := map[Dependency]bool{}
+		for d := range Deps(dg, p) {
+			if !seen[d] {
+				seen[d] = true
+				ret[d]++
+			}
+		}
+	}
+	return ret
+}
+
+// EqualDependencyGraphs reports whether the two [DependencyGraph] values have the same root, the
+// same set of nodes (see [AllDependencies]), and the same set of edges (including whether each
+// edge is a surprise dependency).  Traversal order does not matter.  If the graphs differ, the
+// returned string is a human-readable description of the differences, one per line, with "-"
+// marking nodes or edges that appear only in a and "+" marking those only in b.
+func EqualDependencyGraphs(a, b DependencyGraph) (bool, string) {
+	describe := func(dg DependencyGraph) mapset.Set[string] {
+		ret := mapset.NewThreadUnsafeSet(fmt.Sprintf("root %v", dg.Root()))
+		for p := range AllDependencies(dg) {
+			ret.Add(fmt.Sprintf("node %v", p))
+			for d, surprise := range Deps(dg, p) {
+				if surprise {
+					ret.Add(fmt.Sprintf("edge %v -> %v (surprise)", p, d))
+				} else {
+					ret.Add(fmt.Sprintf("edge %v -> %v", p, d))
+				}
+			}
+		}
+		return ret
+	}
+	da, db := describe(a), describe(b)
+	if da.Equal(db) {
+		return true, ""
+	}
+	lines := []string(nil)
+	for s := range mapset.Elements(da.Difference(db)) {
+		lines = append(lines, "- "+s)
+	}
+	for s := range mapset.Elements(db.Difference(da)) {
+		lines = append(lines, "+ "+s)
+	}
+	// Sort by the description, not the +/- prefix, so that related lines are adjacent.
+	slices.SortFunc(lines, func(x, y string) int { return strings.Compare(x[2:]+x[:1], y[2:]+y[:1]) })
+	return false, strings.Join(lines, "\n") + "\n"
+}
+
+// A DependencyGraphDiff describes how the selection sets (see [AllDependencies]) of two
+// [DependencyGraph] values differ.  Modules are matched by [ModuleId.Path].
+type DependencyGraphDiff struct {
+	// OnlyA holds the dependencies whose module is selected in the first graph but not the second,
+	// sorted by [DependencyCompare].
+	OnlyA []Dependency
+	// OnlyB holds the dependencies whose module is selected in the second graph but not the first,
+	// sorted by [DependencyCompare].
+	OnlyB []Dependency
+	// Changed holds the modules selected in both graphs at different versions, sorted by module path.
+	Changed []VersionChange
+}
+
+// A VersionChange is a module selected at different versions by two [DependencyGraph] values.
+type VersionChange struct {
+	A, B Dependency
+}
+
+// Upgrade reports whether B's version is newer than A's version (see [semver.Compare]).
+func (c VersionChange) Upgrade() bool {
+	return semver.Compare(c.B.Id().Version, c.A.Id().Version) > 0
+}
+
+// Empty reports whether the two selection sets are identical.
+func (diff *DependencyGraphDiff) Empty() bool {
+	return len(diff.OnlyA) == 0 && len(diff.OnlyB) == 0 && len(diff.Changed) == 0
+}
+
+// DiffDependencyGraphs compares the selection sets of the two graphs.  Unlike
+// [EqualDependencyGraphs], edges are ignored; only which module versions are selected matters.
+// This is useful for comparing the results of different resolvers on the same [RequirementGraph]
+// (e.g., [ResolveMvs] versus [ResolveSat]).
+func DiffDependencyGraphs(a, b DependencyGraph) *DependencyGraphDiff {
+	return diffSelections(selectedByPath(a), selectedByPath(b))
+}
+
+// selectedByPath maps the module path of each dependency in the selection set (see
+// [AllDependencies]) to the dependency.
+func selectedByPath(dg DependencyGraph) map[string]Dependency {
+	ret := map[string]Dependency{}
+	for d := range AllDependencies(dg) {
+		ret[d.Id().Path] = d
+	}
+	return ret
+}
+
+// diffSelections implements [DiffDependencyGraphs] given each graph's [selectedByPath] result.
+func diffSelections(sa, sb map[string]Dependency) *DependencyGraphDiff {
+	diff := &DependencyGraphDiff{}
+	for _, p := range slices.Sorted(maps.Keys(sa)) {
+		da := sa[p]
+		switch db, ok := sb[p]; {
+		case !ok:
+			diff.OnlyA = append(diff.OnlyA, da)
+		case da != db:
+			diff.Changed = append(diff.Changed, VersionChange{A: da, B: db})
+		}
+	}
+	for _, p := range slices.Sorted(maps.Keys(sb)) {
+		if _, ok := sa[p]; !ok {
+			diff.OnlyB = append(diff.OnlyB, sb[p])
+		}
+	}
+	return diff
+}
@@ -0,0 +1,532 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/google/go-cmp/cmp"
+)
+
+// newTestRequirementGraph builds an in-memory [RequirementGraph] rooted at root.  The keys of g are
+// the nodes (in "path@version" form) and the values map each node's requirements to true if the
+// requirement is an immediate indirect requirement.  Every requirement must also be a key of g.
+func newTestRequirementGraph(t testing.TB, root string, g map[string]map[string]bool) *requirementGraph {
+	t.Helper()
+	rg := &requirementGraph{
+		root: requirement{ParseModuleId(root)},
+		reqs: map[Requirement]*requirementGraphReqs{},
+	}
+	for n := range g {
+		rg.reqs[requirement{ParseModuleId(n)}] = &requirementGraphReqs{
+			d: mapset.NewThreadUnsafeSet[Requirement](),
+			i: mapset.NewThreadUnsafeSet[Requirement](),
+		}
+	}
+	for n, edges := range g {
+		for m, ind := range edges {
+			r := requirement{ParseModuleId(m)}
+			if rg.reqs[r] == nil {
+				t.Fatalf("requirement %v of %v is not a node", m, n)
+			}
+			rs := rg.reqs[requirement{ParseModuleId(n)}]
+			if ind {
+				rs.i.Add(r)
+			} else {
+				rs.d.Add(r)
+			}
+		}
+	}
+	if rg.reqs[rg.root] == nil {
+		t.Fatalf("root %v is not a node", root)
+	}
+	return rg
+}
+
+// newTestDependencyGraph resolves the output of [newTestRequirementGraph] with [ResolveMvs].
+func newTestDependencyGraph(t *testing.T, root string, g map[string]map[string]bool) DependencyGraph {
+	t.Helper()
+	dg, err := ResolveMvs(t.Context(), newTestRequirementGraph(t, root, g))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dg
+}
+
+// edgeCaseGraphs are corner cases for the graph analysis tests, in the format accepted by
+// [newTestDependencyGraph]:  a root with no dependencies, a dependency cycle through the root (plus
+// a cycle that does not include the root), and the synthetic root of a multi-root graph (see
+// [MultiRootId]).  Tests look up their expected results by desc.
+var edgeCaseGraphs = []struct {
+	desc string
+	root string
+	g    map[string]map[string]bool
+}{
+	{
+		desc: "empty",
+		root: "example.com/r@v1.0.0",
+		g:    map[string]map[string]bool{"example.com/r@v1.0.0": {}},
+	},
+	{
+		desc: "cycles",
+		root: "example.com/r@v1.0.0",
+		g: map[string]map[string]bool{
+			"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+			"example.com/a@v1.0.0": {"example.com/r@v1.0.0": false},
+			"example.com/b@v1.0.0": {"example.com/c@v1.0.0": false},
+			"example.com/c@v1.0.0": {"example.com/b@v1.0.0": false},
+		},
+	},
+	{
+		desc: "multi-root",
+		root: MultiRootId.String(),
+		g: map[string]map[string]bool{
+			MultiRootId.String():   {"example.com/x@v1.0.0": false, "example.com/y@v1.0.0": false},
+			"example.com/x@v1.0.0": {"example.com/z@v1.0.0": false},
+			"example.com/y@v1.0.0": {"example.com/z@v1.0.0": false},
+			"example.com/z@v1.0.0": {},
+		},
+	},
+}
+
+// shortPaths returns the module paths of the given dependencies without the "example.com/" prefix
+// used by the test graphs.
+func shortPaths(ds []Dependency) []string {
+	ret := []string(nil)
+	for _, d := range ds {
+		ret = append(ret, strings.TrimPrefix(d.Id().Path, "example.com/"))
+	}
+	return ret
+}
+
+func TestWalkDependencyGraphPaths(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/c@v1.0.0": {"example.com/r@v1.0.0": false},
+	})
+	pathStr := func(path []Dependency) string {
+		s := []string(nil)
+		for _, d := range path {
+			s = append(s, strings.TrimPrefix(d.Id().Path, "example.com/"))
+		}
+		return strings.Join(s, " ")
+	}
+	for _, tc := range []struct {
+		desc      string
+		noDescend string
+		wantPaths []string
+		wantEdges []string
+	}{
+		{
+			desc:      "all",
+			wantPaths: []string{"r", "r a", "r b", "r a c"},
+			wantEdges: []string{"r a", "r b", "a c", "b c", "c r"},
+		},
+		{
+			desc:      "no descend",
+			noDescend: "a",
+			wantPaths: []string{"r", "r a", "r b", "r b c"},
+			wantEdges: []string{"r a", "r b", "b c", "c r"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			visited := mapset.NewThreadUnsafeSet[Dependency]()
+			gotPaths := []string(nil)
+			gotEdges := []string(nil)
+			if err := WalkDependencyGraphPaths(dg, dg.Root(),
+				func(path []Dependency) (bool, error) {
+					m := path[len(path)-1]
+					visited.Add(m)
+					gotPaths = append(gotPaths, pathStr(path))
+					return m.Id().Path != "example.com/"+tc.noDescend, nil
+				},
+				func(p, m Dependency, surprise bool) error {
+					if !visited.Contains(p) || !visited.Contains(m) {
+						t.Errorf("edge %v -> %v visited before its nodes", p, m)
+					}
+					gotEdges = append(gotEdges, pathStr([]Dependency{p, m}))
+					return nil
+				}); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantPaths, gotPaths); diff != "" {
+				t.Errorf("unexpected paths (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantEdges, gotEdges); diff != "" {
+				t.Errorf("unexpected edges (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAllSurpriseDependencies(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": true},
+		"example.com/a@v1.0.0": {},
+		"example.com/s@v1.0.0": {},
+	})
+	got := []string(nil)
+	for p, d := range AllSurpriseDependencies(dg) {
+		got = append(got, p.String()+" "+d.String())
+	}
+	want := []string{"example.com/r@v1.0.0 example.com/s@v1.0.0"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected surprise dependencies (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithoutSurprises(t *testing.T) {
+	t.Parallel()
+	rg := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": true},
+		"example.com/a@v1.0.0": {},
+		"example.com/s@v1.0.0": {},
+	})
+	for _, resolve := range []func(context.Context, RequirementGraph, ...ResolveOption) (DependencyGraph, error){
+		ResolveMvs, ResolveSat,
+	} {
+		dg, err := resolve(t.Context(), rg, WithoutSurprises())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ProvenanceOf(dg).WithoutSurprises {
+			t.Errorf("Provenance.WithoutSurprises is false, want true")
+		}
+		if got := len(slices.Collect(Nodes(dg))); got != 3 {
+			t.Errorf("got %v selected dependencies, want 3", got)
+		}
+		for p, d := range AllSurpriseDependencies(dg) {
+			t.Errorf("got surprise dependency %v of %v, want none", d, p)
+		}
+	}
+}
+
+func TestNodes_Unreachable(t *testing.T) {
+	t.Parallel()
+	// c is selected only because a@v1.0.0 requires it, but a@v1.1.0 is selected instead.
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/a@v1.1.0": {},
+		"example.com/b@v1.0.0": {"example.com/a@v1.1.0": false},
+		"example.com/c@v1.0.0": {},
+	})
+	c := dg.Selected(NewModuleId("example.com/c", "v1.0.0"))
+	if c == nil {
+		t.Fatalf("example.com/c is not selected")
+	}
+	nodes := mapset.NewThreadUnsafeSet(slices.Collect(Nodes(dg))...)
+	all := mapset.NewThreadUnsafeSet(slices.Collect(AllDependencies(dg))...)
+	if got, want := nodes.Difference(all).ToSlice(), []Dependency{c}; !slices.Equal(got, want) {
+		t.Errorf("got %v in Nodes but not AllDependencies, want %v", got, want)
+	}
+	if !all.IsSubset(nodes) {
+		t.Errorf("AllDependencies yielded %v, which are not in Nodes", all.Difference(nodes))
+	}
+}
+
+// TestDependencyGraph_ConcurrentReads reads a resolved graph from many goroutines at once.  Run
+// with -race to detect unsynchronized access to the graph's internal state.
+func TestDependencyGraph_ConcurrentReads(t *testing.T) {
+	t.Parallel()
+	rg := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": true},
+		"example.com/a@v1.0.0": {"example.com/b@v1.0.0": false, "example.com/t@v1.0.0": true},
+		"example.com/b@v1.0.0": {"example.com/a@v1.0.0": false},
+		"example.com/s@v1.0.0": {},
+		"example.com/t@v1.0.0": {},
+	})
+	for _, resolve := range []func(context.Context, RequirementGraph, ...ResolveOption) (DependencyGraph, error){
+		ResolveMvs, ResolveSat,
+	} {
+		dg, err := resolve(t.Context(), rg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		describe := func() string {
+			edges := []string(nil)
+			for p, d := range AllSurpriseDependencies(dg) {
+				edges = append(edges, p.String()+" "+d.String())
+			}
+			slices.Sort(edges)
+			return strings.Join(edges, "\n")
+		}
+		want := describe()
+		got := make([]string, 100)
+		var wg sync.WaitGroup
+		for i := range got {
+			wg.Go(func() { got[i] = describe() })
+		}
+		wg.Wait()
+		for i, g := range got {
+			if g != want {
+				t.Errorf("reader %v got surprise dependencies %q, want %q", i, g, want)
+			}
+		}
+	}
+}
+
+func TestWalkDependencyGraph_NilCallbacks(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": true},
+		"example.com/a@v1.0.0": {},
+		"example.com/s@v1.0.0": {},
+	})
+	var mu sync.Mutex
+	nodes := 0
+	if err := WalkDependencyGraph(dg, dg.Root(), func(Dependency) (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		nodes++
+		return true, nil
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := nodes, 3; got != want {
+		t.Errorf("visited %v nodes, want %v", got, want)
+	}
+	if err := WalkDependencyGraph(dg, dg.Root(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWalkDirectDeps(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": true},
+		"example.com/a@v1.0.0": {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0": {},
+		"example.com/s@v1.0.0": {},
+	})
+	var mu sync.Mutex
+	nodes := []string(nil)
+	edges := []string(nil)
+	err := WalkDirectDeps(dg, dg.Root(),
+		func(m Dependency) (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			nodes = append(nodes, m.String())
+			return true, nil
+		},
+		func(p, m Dependency) error {
+			mu.Lock()
+			defer mu.Unlock()
+			edges = append(edges, p.String()+" "+m.String())
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(nodes)
+	slices.Sort(edges)
+	wantNodes := []string{"example.com/a@v1.0.0", "example.com/b@v1.0.0", "example.com/r@v1.0.0"}
+	if diff := cmp.Diff(wantNodes, nodes); diff != "" {
+		t.Errorf("unexpected nodes (-want +got):\n%s", diff)
+	}
+	wantEdges := []string{
+		"example.com/a@v1.0.0 example.com/b@v1.0.0",
+		"example.com/r@v1.0.0 example.com/a@v1.0.0",
+	}
+	if diff := cmp.Diff(wantEdges, edges); diff != "" {
+		t.Errorf("unexpected edges (-want +got):\n%s", diff)
+	}
+}
+
+func TestReachable(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/d@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/c@v1.0.0": false},
+		"example.com/c@v1.0.0": {},
+		"example.com/d@v1.0.0": {},
+	})
+	dep := func(p string) Dependency { return dg.Selected(NewModuleId("example.com/"+p, "")) }
+	for _, tc := range []struct {
+		from, to string
+		want     bool
+	}{
+		{from: "r", to: "r", want: true},
+		{from: "r", to: "c", want: true},
+		{from: "a", to: "c", want: true},
+		{from: "b", to: "a", want: true},
+		{from: "c", to: "a", want: false},
+		{from: "a", to: "d", want: false},
+		{from: "d", to: "r", want: false},
+	} {
+		if got := Reachable(dg, dep(tc.from), dep(tc.to)); got != tc.want {
+			t.Errorf("Reachable(%v, %v) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestDep(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/b@v1.1.0": false},
+		"example.com/b@v1.0.0": {},
+		"example.com/b@v1.1.0": {},
+	})
+	for _, g := range []struct {
+		desc string
+		dg   DependencyGraph
+	}{
+		{"dependencyGraph", dg},
+		// A wrapper hides the concrete type, so Dep must walk the graph.
+		{"wrapper", struct{ DependencyGraph }{dg}},
+	} {
+		t.Run(g.desc, func(t *testing.T) {
+			for _, tc := range []struct {
+				mId  string
+				want string
+			}{
+				{"example.com/r@v1.0.0", "example.com/r@v1.0.0"},
+				{"example.com/b@v1.1.0", "example.com/b@v1.1.0"},
+				{"example.com/b@v1.0.0", ""},
+				{"example.com/b@v1.2.0", ""},
+				{"example.com/x@v1.0.0", ""},
+			} {
+				got := ""
+				if d := Dep(g.dg, ParseModuleId(tc.mId)); d != nil {
+					got = d.Id().String()
+				}
+				if got != tc.want {
+					t.Errorf("Dep(%v) = %q, want %q", tc.mId, got, tc.want)
+				}
+			}
+			mId := ParseModuleId("example.com/a@v1.0.0")
+			if got, want := Dep(g.dg, mId), dg.Selected(mId); got != want {
+				t.Errorf("Dep() = %v, want the selected %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEqualDependencyGraphs(t *testing.T) {
+	t.Parallel()
+	g := map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": true},
+		"example.com/a@v1.0.0": {},
+		"example.com/s@v1.0.0": {},
+	}
+	a := newTestDependencyGraph(t, "example.com/r@v1.0.0", g)
+	if eq, diff := EqualDependencyGraphs(a, newTestDependencyGraph(t, "example.com/r@v1.0.0", g)); !eq {
+		t.Errorf("identical graphs are not equal:\n%s", diff)
+	}
+	b := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": false},
+		"example.com/a@v1.0.0": {},
+		"example.com/s@v1.0.0": {},
+	})
+	eq, got := EqualDependencyGraphs(a, b)
+	if eq {
+		t.Errorf("different graphs are equal")
+	}
+	want := "- edge example.com/r@v1.0.0 -> example.com/s@v1.0.0 (surprise)\n" +
+		"+ edge example.com/r@v1.0.0 -> example.com/s@v1.0.0\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected difference description (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffDependencyGraphs(t *testing.T) {
+	t.Parallel()
+	a := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		"example.com/a@v1.0.0": {},
+		"example.com/b@v1.0.0": {},
+	})
+	b := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.1.0": false, "example.com/c@v1.0.0": false},
+		"example.com/a@v1.1.0": {},
+		"example.com/c@v1.0.0": {},
+	})
+	got := DiffDependencyGraphs(a, b)
+	str := func(ds []Dependency) []string {
+		ret := []string(nil)
+		for _, d := range ds {
+			ret = append(ret, d.String())
+		}
+		return ret
+	}
+	if diff := cmp.Diff([]string{"example.com/b@v1.0.0"}, str(got.OnlyA)); diff != "" {
+		t.Errorf("unexpected OnlyA (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"example.com/c@v1.0.0"}, str(got.OnlyB)); diff != "" {
+		t.Errorf("unexpected OnlyB (-want +got):\n%s", diff)
+	}
+	if len(got.Changed) != 1 || got.Changed[0].A.String() != "example.com/a@v1.0.0" ||
+		got.Changed[0].B.String() != "example.com/a@v1.1.0" {
+		t.Errorf("got Changed %v, want a single change from example.com/a@v1.0.0 to v1.1.0", got.Changed)
+	}
+	if got.Empty() {
+		t.Errorf("Empty() returned true for differing graphs")
+	}
+	if !DiffDependencyGraphs(a, a).Empty() {
+		t.Errorf("Empty() returned false for identical graphs")
+	}
+}
+
+func TestAllDependenciesContext(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false},
+		"example.com/a@v1.0.0": {},
+	})
+	deps, done := AllDependenciesContext(t.Context(), dg)
+	if got, want := len(slices.Collect(deps)), 2; got != want {
+		t.Errorf("got %v dependencies, want %v", got, want)
+	}
+	if err := done(); err != nil {
+		t.Errorf("got error %q, want nil", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	deps, done = AllDependenciesContext(ctx, dg)
+	for range deps {
+	}
+	if got, want := done(), context.Canceled; !errors.Is(got, want) {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+}
+
+func BenchmarkComputeSurprise(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			// The root directly requires n modules, each of which indirectly requires a common module
+			// (thus a surprise dependency of each of the n modules).
+			g := map[string]map[string]bool{
+				"example.com/r@v1.0.0": {},
+				"example.com/c@v1.0.0": {},
+			}
+			for i := range n {
+				m := fmt.Sprintf("example.com/b%v@v1.0.0", i)
+				g["example.com/r@v1.0.0"][m] = false
+				g[m] = map[string]bool{"example.com/c@v1.0.0": true}
+			}
+			ctx := b.Context()
+			dg, err := ResolveMvs(ctx, newTestRequirementGraph(b, "example.com/r@v1.0.0", g))
+			if err != nil {
+				b.Fatal(err)
+			}
+			for b.Loop() {
+				if err := dg.(*dependencyGraph).computeAllSurpriseDeps(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
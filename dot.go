@@ -0,0 +1,156 @@
+package gomoddepgraph
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DotOptions customizes the output of [WriteDot].
+type DotOptions struct {
+	// NodeAttrs, if non-nil, returns additional Graphviz attributes for the given node (e.g.,
+	// "fillcolor").  They override the default attributes of the same name.
+	NodeAttrs func(d Dependency) map[string]string
+	// EdgeAttrs, if non-nil, returns additional Graphviz attributes for the edge from p to m.  They
+	// override the default attributes of the same name.
+	EdgeAttrs func(p, m Dependency, surprise bool) map[string]string
+	// Layered, if true, puts the nodes of each layer returned from [Layers] in a "rank=same"
+	// subgraph so that Graphviz draws the layers in rows, which makes for a cleaner diagram.
+	Layered bool
+}
+
+// WriteDot writes the given [DependencyGraph] to w in the [Graphviz DOT language].  Each node links
+// to the module's page on pkg.go.dev, the root node is drawn in inverted colors, and surprise
+// dependency edges are dashed and have class "surprise".  Nodes are written in depth-first order
+// starting at the root, visiting dependencies in [DependencyCompare] order.  The exact format is
+// subject to change.
+//
+// [Graphviz DOT language]: https://graphviz.org/doc/info/lang.html
+func WriteDot(w io.Writer, dg DependencyGraph, opts DotOptions) error {
+	edges := func(m Dependency) (map[Dependency]bool, error) { return maps.Collect(Deps(dg, m)), nil }
+	var layers [][]Dependency
+	if opts.Layered {
+		layers = Layers(dg)
+	}
+	return writeDot(w, dg.Root(), edges, DependencyCompare, "surprise", opts.NodeAttrs, opts.EdgeAttrs, layers)
+}
+
+// RequirementDotOptions customizes the output of [WriteRequirementDot].
+type RequirementDotOptions struct {
+	// NodeAttrs, if non-nil, returns additional Graphviz attributes for the given node.  They
+	// override the default attributes of the same name.
+	NodeAttrs func(r Requirement) map[string]string
+	// EdgeAttrs, if non-nil, returns additional Graphviz attributes for the edge from p to m.  They
+	// override the default attributes of the same name.
+	EdgeAttrs func(p, m Requirement, indirect bool) map[string]string
+}
+
+// WriteRequirementDot is like [WriteDot] except it writes a [RequirementGraph].  Immediate indirect
+// requirement edges are dashed and have class "indirect".  Each visited [Requirement] is loaded
+// (see [RequirementGraph.Load]).
+func WriteRequirementDot(ctx context.Context, w io.Writer, rg RequirementGraph, opts RequirementDotOptions) error {
+	edges := func(m Requirement) (map[Requirement]bool, error) {
+		if err := rg.Load(ctx, m); err != nil {
+			return nil, err
+		}
+		return maps.Collect(Reqs(rg, m)), nil
+	}
+	return writeDot(w, rg.Root(), edges, RequirementCompare, "indirect", opts.NodeAttrs, opts.EdgeAttrs, nil)
+}
+
+func writeDot[N interface {
+	comparable
+	Id() ModuleId
+}](w io.Writer, root N,
+	edges func(m N) (map[N]bool, error),
+	cmp func(a, b N) int,
+	markClass string,
+	nodeAttrs func(m N) map[string]string,
+	edgeAttrs func(p, m N, mark bool) map[string]string,
+	layers [][]N) error {
+
+	buf := &bytes.Buffer{}
+	fmtAttrs := func(dflt, extra map[string]string) string {
+		maps.Copy(dflt, extra)
+		attrs := []string{}
+		for _, k := range slices.Sorted(maps.Keys(dflt)) {
+			attrs = append(attrs, fmt.Sprintf("%s=%q", k, dflt[k]))
+		}
+		return strings.Join(attrs, ",")
+	}
+	// The graph is walked depth-first using an explicit stack instead of recursion so that
+	// pathologically deep graphs do not exhaust the goroutine stack.  Each frame holds the edges of
+	// a written node that have not been written yet.
+	type frame struct {
+		m  N
+		es map[N]bool
+		ds []N
+	}
+	stack := []*frame(nil)
+	visited := map[N]bool{}
+	visit := func(m N) error {
+		visited[m] = true
+		attrs := map[string]string{"URL": fmt.Sprintf("https://pkg.go.dev/%v", m.Id())}
+		if m == root {
+			attrs["fillcolor"] = "black"
+			attrs["fontcolor"] = "white"
+		}
+		var extra map[string]string
+		if nodeAttrs != nil {
+			extra = nodeAttrs(m)
+		}
+		fmt.Fprintf(buf, "  %q [%s];\n", m.Id().String(), fmtAttrs(attrs, extra))
+		es, err := edges(m)
+		if err != nil {
+			return err
+		}
+		stack = append(stack, &frame{m, es, slices.SortedFunc(maps.Keys(es), cmp)})
+		return nil
+	}
+	buf.WriteString("digraph {\n")
+	buf.WriteString("  outputorder= \"edgesfirst\";\n")
+	buf.WriteString("  overlap = prism;\n")
+	buf.WriteString("  overlap_scaling = -10;\n")
+	buf.WriteString("  node [style=filled,fillcolor=\"white\",shape=box];\n")
+	if err := visit(root); err != nil {
+		return err
+	}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		if len(f.ds) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		d := f.ds[0]
+		f.ds = f.ds[1:]
+		attrs := map[string]string{}
+		if f.es[d] {
+			attrs["class"] = markClass
+			attrs["style"] = "dashed"
+		}
+		var extra map[string]string
+		if edgeAttrs != nil {
+			extra = edgeAttrs(f.m, d, f.es[d])
+		}
+		fmt.Fprintf(buf, "  %q -> %q [%s];\n", f.m.Id().String(), d.Id().String(), fmtAttrs(attrs, extra))
+		if !visited[d] {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+	}
+	for _, l := range layers {
+		buf.WriteString("  { rank=same;")
+		for _, m := range l {
+			fmt.Fprintf(buf, " %q;", m.Id().String())
+		}
+		buf.WriteString(" }\n")
+	}
+	buf.WriteString("}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,191 @@
+package gomoddepgraph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteDot(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": true},
+		"example.com/a@v1.0.0": {},
+		"example.com/s@v1.0.0": {},
+	})
+	buf := &bytes.Buffer{}
+	err := WriteDot(buf, dg, DotOptions{
+		NodeAttrs: func(d Dependency) map[string]string {
+			if d.Id().Path == "example.com/a" {
+				return map[string]string{"fillcolor": "red", "tooltip": "team a"}
+			}
+			return nil
+		},
+		EdgeAttrs: func(p, m Dependency, surprise bool) map[string]string {
+			if surprise {
+				return map[string]string{"style": "dotted"}
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `digraph {
+  outputorder= "edgesfirst";
+  overlap = prism;
+  overlap_scaling = -10;
+  node [style=filled,fillcolor="white",shape=box];
+  "example.com/r@v1.0.0" [URL="https://pkg.go.dev/example.com/r@v1.0.0",fillcolor="black",fontcolor="white"];
+  "example.com/r@v1.0.0" -> "example.com/a@v1.0.0" [];
+  "example.com/a@v1.0.0" [URL="https://pkg.go.dev/example.com/a@v1.0.0",fillcolor="red",tooltip="team a"];
+  "example.com/r@v1.0.0" -> "example.com/s@v1.0.0" [class="surprise",style="dotted"];
+  "example.com/s@v1.0.0" [URL="https://pkg.go.dev/example.com/s@v1.0.0"];
+}
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected DOT output (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteDot_Layered(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0": {},
+	})
+	buf := &bytes.Buffer{}
+	if err := WriteDot(buf, dg, DotOptions{Layered: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := `  { rank=same; "example.com/r@v1.0.0"; }
+  { rank=same; "example.com/a@v1.0.0"; }
+  { rank=same; "example.com/b@v1.0.0"; }
+}
+`
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("got DOT output:\n%s\nwant suffix:\n%s", got, want)
+	}
+}
+
+func TestWriteRequirementDot(t *testing.T) {
+	t.Parallel()
+	rg := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": true},
+		"example.com/a@v1.0.0": {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0": {},
+	})
+	buf := &bytes.Buffer{}
+	err := WriteRequirementDot(t.Context(), buf, rg, RequirementDotOptions{
+		NodeAttrs: func(r Requirement) map[string]string {
+			if r.Id().Path == "example.com/b" {
+				return map[string]string{"tooltip": "team b"}
+			}
+			return nil
+		},
+		EdgeAttrs: func(p, m Requirement, indirect bool) map[string]string {
+			if indirect {
+				return map[string]string{"color": "gray"}
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `digraph {
+  outputorder= "edgesfirst";
+  overlap = prism;
+  overlap_scaling = -10;
+  node [style=filled,fillcolor="white",shape=box];
+  "example.com/r@v1.0.0" [URL="https://pkg.go.dev/example.com/r@v1.0.0",fillcolor="black",fontcolor="white"];
+  "example.com/r@v1.0.0" -> "example.com/a@v1.0.0" [];
+  "example.com/a@v1.0.0" [URL="https://pkg.go.dev/example.com/a@v1.0.0"];
+  "example.com/a@v1.0.0" -> "example.com/b@v1.0.0" [];
+  "example.com/b@v1.0.0" [URL="https://pkg.go.dev/example.com/b@v1.0.0",tooltip="team b"];
+  "example.com/r@v1.0.0" -> "example.com/b@v1.0.0" [class="indirect",color="gray",style="dashed"];
+}
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected DOT output (-want +got):\n%s", diff)
+	}
+}
+
+// TestWriteRequirementDot_Leaves checks that requirements whose own requirements are not fetched
+// (see [WithMaxDepth] and [WithIgnoredPaths]) are written as nodes without outgoing edges.  The
+// root's requirements come from go.mod data and no other module is fetched, so no proxy is needed.
+func TestWriteRequirementDot_Leaves(t *testing.T) {
+	t.Parallel()
+	const goMod = `module example.com/r
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.0.0 // indirect
+	example.com/sdk v1.0.0
+)
+`
+	header := `digraph {
+  outputorder= "edgesfirst";
+  overlap = prism;
+  overlap_scaling = -10;
+  node [style=filled,fillcolor="white",shape=box];
+  "example.com/r@(devel)" [URL="https://pkg.go.dev/example.com/r@(devel)",fillcolor="black",fontcolor="white"];
+`
+	for _, tc := range []struct {
+		desc string
+		opts []RequirementsOption
+		want string
+	}{
+		{
+			desc: "truncated",
+			opts: []RequirementsOption{WithMaxDepth(1)},
+			want: header + `  "example.com/r@(devel)" -> "example.com/a@v1.0.0" [];
+  "example.com/a@v1.0.0" [URL="https://pkg.go.dev/example.com/a@v1.0.0",class="truncated"];
+  "example.com/r@(devel)" -> "example.com/b@v1.0.0" [class="indirect",style="dashed"];
+  "example.com/b@v1.0.0" [URL="https://pkg.go.dev/example.com/b@v1.0.0",class="truncated"];
+  "example.com/r@(devel)" -> "example.com/sdk@v1.0.0" [];
+  "example.com/sdk@v1.0.0" [URL="https://pkg.go.dev/example.com/sdk@v1.0.0",class="truncated"];
+}
+`,
+		},
+		{
+			desc: "ignored",
+			opts: []RequirementsOption{WithIgnoredPaths("example.com")},
+			want: header + `  "example.com/r@(devel)" -> "example.com/a@v1.0.0" [];
+  "example.com/a@v1.0.0" [URL="https://pkg.go.dev/example.com/a@v1.0.0"];
+  "example.com/r@(devel)" -> "example.com/b@v1.0.0" [class="indirect",style="dashed"];
+  "example.com/b@v1.0.0" [URL="https://pkg.go.dev/example.com/b@v1.0.0"];
+  "example.com/r@(devel)" -> "example.com/sdk@v1.0.0" [];
+  "example.com/sdk@v1.0.0" [URL="https://pkg.go.dev/example.com/sdk@v1.0.0"];
+}
+`,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			rg, done, err := RequirementsFromGoMod(t.Context(), []byte(goMod), tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer done()
+			buf := &bytes.Buffer{}
+			err = WriteRequirementDot(t.Context(), buf, rg, RequirementDotOptions{
+				NodeAttrs: func(r Requirement) map[string]string {
+					if Truncated(rg, r) {
+						return map[string]string{"class": "truncated"}
+					}
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Errorf("unexpected DOT output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package gomoddepgraph_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/rhansen/gomoddepgraph"
+	"github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+// diamondModules are the fake modules used by the resolver examples.  Module a requires b and c,
+// which require different versions of d, and the two versions of d require different modules.
+var diamondModules = [][]fakemodule.Option{
+	{fakemodule.Id("example.com/e@v1.0.0")},
+	{fakemodule.Id("example.com/f@v1.0.0")},
+	{fakemodule.Id("example.com/d@v1.0.0"), fakemodule.Require("example.com/e@v1.0.0", false)},
+	{fakemodule.Id("example.com/d@v1.1.0"), fakemodule.Require("example.com/f@v1.0.0", false)},
+	{fakemodule.Id("example.com/b@v1.0.0"), fakemodule.Require("example.com/d@v1.0.0", false)},
+	{fakemodule.Id("example.com/c@v1.0.0"), fakemodule.Require("example.com/d@v1.1.0", false)},
+	{fakemodule.Id("example.com/a@v1.0.0"),
+		fakemodule.Require("example.com/b@v1.0.0", false),
+		fakemodule.Require("example.com/c@v1.0.0", false)},
+}
+
+// printSelection prints the selection set in a deterministic order.
+func printSelection(dg gomoddepgraph.DependencyGraph) {
+	for _, d := range slices.SortedFunc(gomoddepgraph.Nodes(dg), gomoddepgraph.DependencyCompare) {
+		fmt.Println(d)
+	}
+}
+
+func ExampleResolveMvs() {
+	ctx, done := withFakeModules(context.Background(), diamondModules...)
+	defer done()
+	rg, rgDone, err := gomoddepgraph.RequirementsComplete(ctx, gomoddepgraph.ParseModuleId("example.com/a@v1.0.0"))
+	if err != nil {
+		panic(err)
+	}
+	defer rgDone()
+
+	// MVS selects the newest required version of each module, but the requirements of the older
+	// versions still count, so e is selected even though only d@v1.0.0 requires it.
+	dg, err := gomoddepgraph.ResolveMvs(ctx, rg)
+	if err != nil {
+		panic(err)
+	}
+	printSelection(dg)
+
+	// Output:
+	// example.com/a@v1.0.0
+	// example.com/b@v1.0.0
+	// example.com/c@v1.0.0
+	// example.com/d@v1.1.0
+	// example.com/e@v1.0.0
+	// example.com/f@v1.0.0
+}
+
+func ExampleResolveSat() {
+	ctx, done := withFakeModules(context.Background(), diamondModules...)
+	defer done()
+	rg, rgDone, err := gomoddepgraph.RequirementsComplete(ctx, gomoddepgraph.ParseModuleId("example.com/a@v1.0.0"))
+	if err != nil {
+		panic(err)
+	}
+	defer rgDone()
+
+	// The SAT resolver only needs the selected versions' requirements to be satisfied, so it does
+	// not select e.
+	dg, err := gomoddepgraph.ResolveSat(ctx, rg)
+	if err != nil {
+		panic(err)
+	}
+	printSelection(dg)
+
+	// Output:
+	// example.com/a@v1.0.0
+	// example.com/b@v1.0.0
+	// example.com/c@v1.0.0
+	// example.com/d@v1.1.0
+	// example.com/f@v1.0.0
+}
+
+func ExampleUnifyRequirements() {
+	ctx, done := withFakeModules(context.Background(), diamondModules...)
+	defer done()
+	rg, rgDone, err := gomoddepgraph.RequirementsComplete(ctx, gomoddepgraph.ParseModuleId("example.com/a@v1.0.0"))
+	if err != nil {
+		panic(err)
+	}
+	defer rgDone()
+
+	// Unification rewrites b's requirement on d@v1.0.0 to d@v1.1.0, so d@v1.0.0 and its requirement
+	// on e drop out of the graph.
+	urg, err := gomoddepgraph.UnifyRequirements(ctx, rg)
+	if err != nil {
+		panic(err)
+	}
+	b := urg.Req(gomoddepgraph.ParseModuleId("example.com/b@v1.0.0"))
+	if err := urg.Load(ctx, b); err != nil {
+		panic(err)
+	}
+	for r := range urg.DirectReqs(b) {
+		fmt.Printf("b requires %v\n", r)
+	}
+	dg, err := gomoddepgraph.ResolveMvs(ctx, urg)
+	if err != nil {
+		panic(err)
+	}
+	printSelection(dg)
+
+	// Output:
+	// b requires example.com/d@v1.1.0
+	// example.com/a@v1.0.0
+	// example.com/b@v1.0.0
+	// example.com/c@v1.0.0
+	// example.com/d@v1.1.0
+	// example.com/f@v1.0.0
+}
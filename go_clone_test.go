@@ -0,0 +1,239 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rhansen/gomoddepgraph/internal/command"
+)
+
+// TestMain removes the idle filtered clones left behind by the tests.
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if err := PurgeTempClones(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		code = 1
+	}
+	os.Exit(code)
+}
+
+// Not parallel because it calls PurgeTempClones, which would remove other tests' idle clones.
+func TestTempFilteredClone_Shared(t *testing.T) {
+	src := t.TempDir()
+	goModFile := filepath.Join(src, "go.mod")
+	if err := os.WriteFile(goModFile, []byte("module example.com/shared\n\ngo 1.26.0\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	mId := NewModuleId("example.com/shared", DevelVersion)
+	dir1, done1, err := tempFilteredClone("", mId, goModFile, src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir2, done2, err := tempFilteredClone("", mId, goModFile, src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir1 != dir2 {
+		t.Errorf("got different clones %v and %v, want the same clone", dir1, dir2)
+	}
+	if err := done1(); err != nil {
+		t.Fatal(err)
+	}
+	// Calling done more than once must not release another reference.
+	if err := done1(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir2, "go.mod")); err != nil {
+		t.Errorf("clone removed while still in use: %v", err)
+	}
+	if err := done2(); err != nil {
+		t.Fatal(err)
+	}
+	// The idle clone is kept for reuse.
+	dir5, done5, err := tempFilteredClone("", mId, goModFile, src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir5 != dir2 {
+		t.Errorf("got clone %v after release, want idle clone %v", dir5, dir2)
+	}
+	// A clone in use is not purged.
+	if err := PurgeTempClones(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir5, "go.mod")); err != nil {
+		t.Errorf("clone purged while still in use: %v", err)
+	}
+	if err := done5(); err != nil {
+		t.Fatal(err)
+	}
+	if err := PurgeTempClones(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir5); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got error %v for purged clone, want %v", err, os.ErrNotExist)
+	}
+
+	// An idle clone whose directory was removed by something else is recreated.
+	dir6, done6, err := tempFilteredClone("", mId, goModFile, src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := done6(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(dir6); err != nil {
+		t.Fatal(err)
+	}
+	dir7, done7, err := tempFilteredClone("", mId, goModFile, src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done7()
+	if _, err := os.Stat(filepath.Join(dir7, "go.mod")); err != nil {
+		t.Errorf("removed idle clone not recreated: %v", err)
+	}
+
+	// A changed go.mod gets a new clone.
+	dir3, done3, err := tempFilteredClone("", mId, goModFile, src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done3()
+	if err := os.WriteFile(goModFile, []byte("module example.com/shared\n\ngo 1.25.0\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	dir4, done4, err := tempFilteredClone("", mId, goModFile, src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done4()
+	if dir3 == dir4 {
+		t.Errorf("clone %v reused after go.mod changed", dir3)
+	}
+}
+
+// Not parallel because it calls PurgeTempClones, which would remove other tests' idle clones.
+func TestTempFilteredClone_TempDir(t *testing.T) {
+	src := t.TempDir()
+	goModFile := filepath.Join(src, "go.mod")
+	if err := os.WriteFile(goModFile, []byte("module example.com/tmpdir\n\ngo 1.26.0\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	tmpDir := t.TempDir()
+	dir, done, err := tempFilteredClone(tmpDir, NewModuleId("example.com/tmpdir", DevelVersion), goModFile, src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := filepath.Dir(dir); got != tmpDir {
+		t.Errorf("clone created in %v, want %v", got, tmpDir)
+	}
+	if err := done(); err != nil {
+		t.Fatal(err)
+	}
+	if err := PurgeTempClones(); err != nil {
+		t.Fatal(err)
+	}
+	if ents, err := os.ReadDir(tmpDir); err != nil || len(ents) != 0 {
+		t.Errorf("got entries %v (error %v) in %v after purge, want none", ents, err, tmpDir)
+	}
+}
+
+func TestTempDir(t *testing.T) {
+	t.Parallel()
+	envCtx := context.WithValue(t.Context(), command.EnvKey, []string{TempDirEnv + "=/from/env"})
+	for _, tc := range []struct {
+		desc string
+		ctx  context.Context
+		want string
+	}{
+		{desc: "default", ctx: context.WithValue(t.Context(), command.EnvKey, []string{}), want: ""},
+		{desc: "env", ctx: envCtx, want: "/from/env"},
+		{desc: "WithTempDir", ctx: WithTempDir(envCtx, "/from/ctx"), want: "/from/ctx"},
+	} {
+		if got := tempDir(tc.ctx); got != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.desc, got, tc.want)
+		}
+	}
+}
+
+func TestTempFilteredClone_MainModuleDir(t *testing.T) {
+	t.Parallel()
+	goMod := []byte("module example.com/main\n\ngo 1.26.0\n\nreplace example.com/dep => ./dep\n")
+	mId := NewModuleId("example.com/main", DevelVersion)
+	dirs := []string(nil)
+	for _, src := range []string{t.TempDir(), t.TempDir()} {
+		goModFile := filepath.Join(src, "go.mod")
+		if err := os.WriteFile(goModFile, goMod, 0666); err != nil {
+			t.Fatal(err)
+		}
+		dir, done, err := tempFilteredClone("", mId, goModFile, src, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer done()
+		got, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "=> " + filepath.Join(src, "dep"); !strings.Contains(string(got), want) {
+			t.Errorf("clone of %v has go.mod:\n%s\nwant %q", goModFile, got, want)
+		}
+		dirs = append(dirs, dir)
+	}
+	if dirs[0] == dirs[1] {
+		t.Errorf("clone %v shared by main modules in different directories", dirs[0])
+	}
+
+	// A relative goSumDir is resolved against the working directory.
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "go.mod"), goMod, 0666); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(wd, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, done, err := tempFilteredClone("", mId, filepath.Join(rel, "go.mod"), rel, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	got, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "=> " + filepath.Join(src, "dep"); !strings.Contains(string(got), want) {
+		t.Errorf("clone of relative %v has go.mod:\n%s\nwant %q", rel, got, want)
+	}
+}
+
+func TestTempFilteredClone_SeparateTempDirs(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	goModFile := filepath.Join(src, "go.mod")
+	if err := os.WriteFile(goModFile, []byte("module example.com/tmpdirs\n\ngo 1.26.0\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	mId := NewModuleId("example.com/tmpdirs", DevelVersion)
+	for range 2 {
+		tmpDir := t.TempDir()
+		dir, done, err := tempFilteredClone(tmpDir, mId, goModFile, src, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer done()
+		if got := filepath.Dir(dir); got != tmpDir {
+			t.Errorf("clone created in %v, want %v", got, tmpDir)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package gomoddepgraph_test
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/rhansen/gomoddepgraph"
+	"github.com/rhansen/gomoddepgraph/internal/command"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestWithPrivateModules(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/dep@v1.0.0", false)},
+	).HTTP()
+	// Re-enable the checksum database so that only the private module settings prevent the go
+	// command from verifying the fake modules against it.
+	env := append(gp.Context().Value(command.EnvKey).([]string), "GOSUMDB=sum.golang.org")
+	ctx := context.WithValue(t.Context(), command.EnvKey, env)
+	opt := WithPrivateModules(PrivateModules{Private: "example.com", NoProxy: "none"})
+	rootId, err := ResolveVersion(ctx, ParseModuleId("example.com/root@latest"), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rg, err := RequirementsGo(ctx, rootId, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReqGraph(ctx, t, rg, tGraph{
+		"example.com/root@v1.0.0": {"example.com/dep@v1.0.0": false},
+		"example.com/dep@v1.0.0":  {},
+	})
+	// The settings are recorded in the graph, so they apply to the `go` commands run later for it.
+	dg, err := ResolveGo(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDepGraph(t, dg, tGraph{
+		"example.com/root@v1.0.0": {"example.com/dep@v1.0.0": false},
+		"example.com/dep@v1.0.0":  {},
+	})
+	if _, err := ModuleSums(ctx, dg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithGoBin(t *testing.T) {
+	t.Parallel()
+	goPath, err := exec.LookPath("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gp := fm.NewTestFakeGoProxy(t).Add(fm.Id("example.com/root@v1.0.0"))
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "marker")
+	shim := filepath.Join(dir, "go-shim")
+	script := "#!/bin/sh\necho \"$@\" >>'" + marker + "'\nexec '" + goPath + "' \"$@\"\n"
+	if err := os.WriteFile(shim, []byte(script), 0777); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		desc string
+		ctx  func() context.Context
+	}{
+		{
+			desc: "context",
+			ctx:  func() context.Context { return WithGoBin(gp.Context(), shim) },
+		},
+		{
+			desc: "environment",
+			ctx: func() context.Context {
+				env := append(gp.Context().Value(command.EnvKey).([]string), GoBinEnv+"="+shim)
+				return context.WithValue(t.Context(), command.EnvKey, env)
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if err := os.Remove(marker); err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			got, err := ResolveVersion(tc.ctx(), ParseModuleId("example.com/root@latest"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := ParseModuleId("example.com/root@v1.0.0"); got != want {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			if _, err := os.Stat(marker); err != nil {
+				t.Errorf("go shim was not run: %v", err)
+			}
+		})
+	}
+}
+
+func TestWithToolchain(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).Add(fm.Id("example.com/root@v1.0.0"))
+	ctx := WithToolchain(gp.Context(), "local")
+	cmd := command.New(ctx, "", "sh", "-c", `printf %s "$GOTOOLCHAIN"`)
+	cmd.Stdout = nil
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(out), "local"; got != want {
+		t.Errorf("got GOTOOLCHAIN=%q in subprocess, want %q", got, want)
+	}
+	// The fake proxy's environment must be preserved.
+	if _, err := ResolveVersion(ctx, ParseModuleId("example.com/root@latest")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFakeGoProxy_HTTPIgnoresUserSettings(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/dep@v1.0.0", false)},
+	).HTTP()
+	// Settings a developer might have in their environment that would otherwise make the go command
+	// bypass the fake proxy or consult the checksum database.
+	env := gp.Environ(append(os.Environ(),
+		"GOPRIVATE=example.com", "GOFLAGS=-mod=vendor", "GOSUMDB=sum.golang.org"))
+	ctx := context.WithValue(t.Context(), command.EnvKey, env)
+	rootId, err := ResolveVersion(ctx, ParseModuleId("example.com/root@latest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rg, err := RequirementsGo(ctx, rootId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReqGraph(ctx, t, rg, tGraph{
+		"example.com/root@v1.0.0": {"example.com/dep@v1.0.0": false},
+		"example.com/dep@v1.0.0":  {},
+	})
+}
+
+func TestAuthenticatedProxy(t *testing.T) {
+	t.Parallel()
+	const user, password = "alice", "s3cret"
+	for _, tc := range []struct {
+		desc    string
+		setup   func(t *testing.T, ctx context.Context, proxyURL *url.URL) context.Context
+		wantErr bool
+	}{
+		{
+			desc: "netrc",
+			setup: func(t *testing.T, ctx context.Context, proxyURL *url.URL) context.Context {
+				// The go command matches the machine name against the URL's host including the port.
+				netrc := filepath.Join(t.TempDir(), "netrc")
+				data := "machine " + proxyURL.Host + " login " + user + " password " + password + "\n"
+				if err := os.WriteFile(netrc, []byte(data), 0600); err != nil {
+					t.Fatal(err)
+				}
+				return WithNetrc(ctx, netrc)
+			},
+		},
+		{
+			desc: "GOPROXY userinfo",
+			setup: func(t *testing.T, ctx context.Context, proxyURL *url.URL) context.Context {
+				proxyURL.User = url.UserPassword(user, password)
+				env := append(ctx.Value(command.EnvKey).([]string), "GOPROXY="+proxyURL.String())
+				return context.WithValue(ctx, command.EnvKey, env)
+			},
+		},
+		{
+			desc:    "no credentials",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			gp := fm.NewTestFakeGoProxy(t).AddAll(
+				[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+				[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/dep@v1.0.0", false)},
+			).HTTPSBasicAuth(user, password)
+			// Make sure a .netrc in the user's home directory cannot supply (or break) credentials.
+			ctx := WithNetrc(gp.Context(), filepath.Join(t.TempDir(), "nonexistent"))
+			if tc.setup != nil {
+				proxyURL, err := url.Parse(gp.URL())
+				if err != nil {
+					t.Fatal(err)
+				}
+				ctx = tc.setup(t, ctx, proxyURL)
+			}
+			rootId, err := ResolveVersion(ctx, ParseModuleId("example.com/root@latest"))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ResolveVersion() succeeded without credentials (got %v)", rootId)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tGraph{
+				"example.com/root@v1.0.0": {"example.com/dep@v1.0.0": false},
+				"example.com/dep@v1.0.0":  {},
+			}
+			grg, err := RequirementsGo(ctx, rootId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkReqGraph(ctx, t, grg, want)
+			crg, done, err := RequirementsComplete(ctx, rootId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer done()
+			checkReqGraph(ctx, t, crg, want)
+		})
+	}
+}
+
+// Not parallel because it calls PurgeTempClones, which would remove other tests' idle clones.
+func TestWithTempDir(t *testing.T) {
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/dep@v1.0.0", false)},
+	)
+	rootId := ParseModuleId("example.com/root@v1.0.0")
+	tmpDir := t.TempDir()
+	ctx := WithTempDir(gp.Context(), tmpDir)
+	rg, err := RequirementsGo(ctx, rootId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReqGraph(gp.Context(), t, rg, tGraph{
+		"example.com/root@v1.0.0": {"example.com/dep@v1.0.0": false},
+		"example.com/dep@v1.0.0":  {},
+	})
+	ents, err := os.ReadDir(tmpDir)
+	if err != nil || len(ents) != 1 {
+		t.Fatalf("got entries %v (error %v) in %v after RequirementsGo, want one clone", ents, err, tmpDir)
+	}
+	// ResolveGo reuses the clone made by RequirementsGo.
+	if _, err := ResolveGo(ctx, rg); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadDir(tmpDir); err != nil || len(got) != 1 || got[0].Name() != ents[0].Name() {
+		t.Errorf("got entries %v (error %v) in %v after ResolveGo, want only %v", got, err, tmpDir, ents[0])
+	}
+	if err := PurgeTempClones(); err != nil {
+		t.Fatal(err)
+	}
+	if ents, err := os.ReadDir(tmpDir); err != nil || len(ents) != 0 {
+		t.Errorf("got entries %v (error %v) in %v after PurgeTempClones, want none", ents, err, tmpDir)
+	}
+	// The clone cannot be created in a nonexistent directory, which shows that the setting is used.
+	env := append(gp.Context().Value(command.EnvKey).([]string),
+		TempDirEnv+"="+filepath.Join(tmpDir, "nonexistent"))
+	ctx = context.WithValue(t.Context(), command.EnvKey, env)
+	if _, err := RequirementsGo(ctx, rootId); err == nil {
+		t.Errorf("RequirementsGo succeeded with %v set to a nonexistent directory", TempDirEnv)
+	}
+}
+
+// fakeRunner is a [CommandRunner] that answers `go list -m` with canned output and records every
+// command it is asked to run.
+type fakeRunner struct {
+	got [][]string
+}
+
+func (r *fakeRunner) Run(ctx context.Context, wd string, args ...string) error {
+	r.got = append(r.got, args)
+	return nil
+}
+
+func (r *fakeRunner) Pipe(ctx context.Context, wd string, args ...string) (io.ReadCloser, func() error, error) {
+	r.got = append(r.got, args)
+	out := `{"Path": "example.com/m", "Version": "v1.2.3"}`
+	return io.NopCloser(strings.NewReader(out)), func() error { return nil }, nil
+}
+
+func TestWithCommandRunner(t *testing.T) {
+	t.Parallel()
+	r := &fakeRunner{}
+	ctx := WithCommandRunner(WithGoBin(t.Context(), "fakego"), r)
+	got, err := ResolveVersion(ctx, ParseModuleId("example.com/m@latest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ParseModuleId("example.com/m@v1.2.3"); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if len(r.got) != 1 || r.got[0][0] != "fakego" || !slices.Contains(r.got[0], "example.com/m@latest") {
+		t.Errorf("got commands %q, want one fakego command querying example.com/m@latest", r.got)
+	}
+	if DefaultCommandRunner() == nil {
+		t.Error("DefaultCommandRunner returned nil")
+	}
+}
@@ -0,0 +1,98 @@
+package gomoddepgraph
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rhansen/gomoddepgraph/internal/syncmap"
+)
+
+// goModKey identifies a released module's go.mod.  The [module cache] directory is included so that
+// different caches (e.g., backed by different proxies) are never mixed up.
+//
+// [module cache]: https://go.dev/ref/mod#module-cache
+type goModKey struct {
+	modCache string
+	mId      ModuleId
+}
+
+// goModCache holds the go.mod contents read by GoModBytes for graphs that do not keep them.  The
+// go.mod of a released module version never changes, so entries are never invalidated.
+var goModCache syncmap.Map[goModKey, []byte]
+
+// GoModBytes returns the raw contents of the go.mod of the given node in the given graph.  The
+// contents are exactly what the `go` command uses, which for a module without a go.mod is the
+// minimal go.mod synthesized by the [module proxy] or the `go` command.
+//
+// For a [RequirementsComplete] graph (or one from [RequirementsFromGoMod] or
+// [RequirementsCompleteMulti]), the node is loaded (see [RequirementGraph.Load]) and the go.mod
+// file that was read to get its requirements is read again, so nothing is downloaded.  (Only the
+// path is kept in the graph, not the contents.)  For other graphs, the go.mod is located via
+// `go list -m` (downloading it into the [module cache] if necessary) and the result is cached for
+// the life of the process.  The go.mod of a [DevelVersion] root comes from the directory given by
+// [WithRootDir] (or, for a [RequirementsForPackages] graph, from the main module), and the go.mod
+// of a [LocalVersion] module comes from its replacement directory.
+//
+// The returned slice is a copy and may be modified by the caller.
+//
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+// [module cache]: https://go.dev/ref/mod#module-cache
+func GoModBytes(ctx context.Context, rg RequirementGraph, r Requirement) ([]byte, error) {
+	mId := r.Id()
+	if err := mId.Check(); err != nil {
+		return nil, err
+	}
+	if crg, ok := rg.(*requirementGraphComplete); ok {
+		if err := crg.Load(ctx, r); err != nil {
+			return nil, err
+		}
+		if reqs := crg.reqs(r); reqs.goMod != nil {
+			return bytes.Clone(reqs.goMod), nil
+		} else if reqs.goModPath != "" {
+			return os.ReadFile(reqs.goModPath)
+		}
+		// The synthetic root of a multi-root graph and the modules cut off by WithMaxDepth or
+		// WithBestEffort were never read.
+		if r == crg.root && mId == MultiRootId {
+			return nil, fmt.Errorf("%v is synthetic and has no go.mod", mId)
+		}
+	}
+	if lr, ok := r.(localRequirement); ok {
+		return os.ReadFile(filepath.Join(lr.dir, "go.mod"))
+	}
+	if mId.IsDevel() {
+		if grg, ok := rg.(*requirementGraphGo); ok && grg.rootDir != "" && r == grg.root {
+			return os.ReadFile(filepath.Join(grg.rootDir, "go.mod"))
+		}
+		if prg, ok := rg.(*requirementGraph); ok && prg.rootGoMod != "" && r == prg.root {
+			return os.ReadFile(prg.rootGoMod)
+		}
+		return nil, fmt.Errorf("module %v has no released version and its go.mod location is unknown", mId)
+	}
+	if mId.IsLocal() {
+		return nil, fmt.Errorf("module %v is replaced by an unknown local directory", mId)
+	}
+	key := goModKey{getenv(ctx, "GOMODCACHE"), mId}
+	if data, ok := goModCache.Load(key); ok {
+		return bytes.Clone(data), nil
+	}
+	md, err := lsModule(ctx, mId)
+	if err != nil {
+		return nil, err
+	}
+	if err := md.err(); err != nil {
+		return nil, err
+	}
+	if md.GoMod == "" {
+		return nil, fmt.Errorf("module %v: go list did not report a go.mod file", mId)
+	}
+	data, err := os.ReadFile(md.GoMod)
+	if err != nil {
+		return nil, err
+	}
+	goModCache.Store(key, data)
+	return bytes.Clone(data), nil
+}
@@ -0,0 +1,85 @@
+package gomoddepgraph_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestGoModBytes(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/dep@v1.0.0", false)},
+	)
+	ctx := gp.Context()
+	rootId := ParseModuleId("example.com/root@v1.0.0")
+	crg, done, err := RequirementsComplete(ctx, rootId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	grg, err := RequirementsGo(ctx, rootId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, rg := range map[string]RequirementGraph{"complete": crg, "go": grg} {
+		t.Run(name, func(t *testing.T) {
+			for _, mId := range []string{"example.com/root@v1.0.0", "example.com/dep@v1.0.0"} {
+				m := ParseModuleId(mId)
+				want, err := os.ReadFile(filepath.Join(gp.Dir(), m.Path, "@v", m.Version+".mod"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				r := rg.Req(m)
+				got, err := GoModBytes(ctx, rg, r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != string(want) {
+					t.Errorf("GoModBytes(%v) = %q, want %q", m, got, want)
+				}
+				// The caller owns the returned slice.
+				clear(got)
+				if got, err := GoModBytes(ctx, rg, r); err != nil || string(got) != string(want) {
+					t.Errorf("second GoModBytes(%v) = %q, %v; want %q, nil", m, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGoModBytes_DevelRoot(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).Add(fm.Id("example.com/dep@v1.0.0"))
+	ctx := gp.Context()
+	dir := t.TempDir()
+	want := "module example.com/local\n\ngo 1.26.0\n\nrequire example.com/dep v1.0.0\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(want), 0666); err != nil {
+		t.Fatal(err)
+	}
+	rootId := NewModuleId("example.com/local", DevelVersion)
+	crg, done, err := RequirementsComplete(ctx, rootId, WithRootDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	got, err := GoModBytes(ctx, crg, crg.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("GoModBytes(%v) = %q, want %q", rootId, got, want)
+	}
+	mrg, done, err := RequirementsCompleteMulti(ctx, ParseModuleId("example.com/dep@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	if got, err := GoModBytes(ctx, mrg, mrg.Root()); err == nil {
+		t.Errorf("GoModBytes(%v) = %q, want error", MultiRootId, got)
+	}
+}
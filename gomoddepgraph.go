@@ -30,6 +30,14 @@
 //		return err
 //	}
 //
+// Alternatively, [Analyze] does all of the above in one call (see [AnalyzeOption] to choose other
+// requirement graph constructors or resolvers):
+//
+//	dg, err := gomoddepgraph.Analyze(ctx, "github.com/rhansen/gomoddepgraph@latest")
+//	if err != nil {
+//		return err
+//	}
+//
 // You can use [AllDependencies] to get the selected set of [Dependency] objects:
 //
 //	selected := slices.Collect(gomoddepgraph.AllDependencies(dg))
@@ -207,7 +215,8 @@
 // Besides MVS, a module's dependencies can change for other reasons:
 //
 //   - There are some go.mod directives that affect the requirement graph but only take effect when
-//     the module is the [main module] (specifically, [replace] and [exclude]).
+//     the module is the [main module] (specifically, [replace] and [exclude]).  [RequirementsGo]
+//     ignores them unless [AsMainModule] is given.
 //   - Building a module as a dependency introduces another hop in the requirement graph
 //     vs. building the module as the [main module].  This extra hop might affect the output of Go's
 //     [graph pruning] algorithm.
@@ -265,7 +274,7 @@
 //     non-module repositories].
 //   - The surprise dependency provides or is needed by a [tool].  (The `go get -tool` command marks
 //     a tool's module as an indirect requirement and the `go mod tidy` command keeps it marked as
-//     indirect.)
+//     indirect.)  [IsTool] reports whether a requirement was added for a tool.
 //
 // # Go Dependency Resolver Interfaces
 //
@@ -310,7 +319,8 @@
 //     (non-pruned) transitive closure.
 //
 // The `go list -m all` and `go mod graph` commands only examine go.mod.  The `go list all` command
-// only examines imports.
+// only examines imports.  [RequirementsForPackages] builds a [RequirementGraph] from the package
+// view.
 //
 // # Meshing the Go Resolver With Debian Package Dependencies
 //
@@ -2,11 +2,14 @@ package gomoddepgraph_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	. "github.com/rhansen/gomoddepgraph"
+	"github.com/rhansen/gomoddepgraph/internal/command"
 	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
 )
 
@@ -331,6 +334,17 @@ func TestGoModDepGraph(t *testing.T) {
 				}
 				checkReqGraph(ctx, t, rg, tc.want_UnifyRequirements)
 			})
+			t.Run("UnifyRequirementsDeterministic", func(t *testing.T) {
+				t.Parallel()
+				rg, err := rgComplete()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if rg, err = UnifyRequirementsDeterministic(ctx, rg); err != nil {
+					t.Fatal(err)
+				}
+				checkReqGraph(ctx, t, rg, tc.want_UnifyRequirements)
+			})
 			t.Run("ResolveGo", func(t *testing.T) {
 				t.Parallel()
 				rg, err := rgGo()
@@ -373,48 +387,79 @@ func TestGoModDepGraph(t *testing.T) {
 
 func checkReqGraph(ctx context.Context, t *testing.T, rg RequirementGraph, want tGraph) {
 	t.Helper()
-	var mu sync.Mutex
-	got := tGraph{}
-	if err := WalkRequirementGraph(ctx, rg, rg.Root(),
-		func(ctx context.Context, m Requirement) (bool, error) {
-			mu.Lock()
-			defer mu.Unlock()
-			got[m.String()] = tEdges{}
-			return true, nil
-		},
-		func(ctx context.Context, p, m Requirement, ind bool) error {
-			mu.Lock()
-			defer mu.Unlock()
-			got[p.String()][m.String()] = ind
-			return nil
-		}); err != nil {
+	g, err := CollectRequirementGraph(ctx, rg)
+	if err != nil {
 		t.Fatal(err)
 	}
-	if diff := cmp.Diff(want, got); diff != "" {
+	if diff := cmp.Diff(want, toTGraph(g)); diff != "" {
 		t.Errorf("graph differs from expected (-want, +got):\n%s", diff)
 	}
 }
 
 func checkDepGraph(t *testing.T, dg DependencyGraph, want tGraph) {
 	t.Helper()
-	var mu sync.Mutex
-	got := tGraph{}
-	if err := WalkDependencyGraph(dg, dg.Root(),
-		func(m Dependency) (bool, error) {
-			mu.Lock()
-			defer mu.Unlock()
-			got[m.String()] = tEdges{}
-			return true, nil
-		},
-		func(p, m Dependency, surprise bool) error {
-			mu.Lock()
-			defer mu.Unlock()
-			got[p.String()][m.String()] = surprise
-			return nil
-		}); err != nil {
+	if diff := cmp.Diff(want, toTGraph(CollectDependencyGraph(dg))); diff != "" {
+		t.Errorf("graph differs from expected (-want, +got):\n%s", diff)
+	}
+	gotNodes := map[tNode]bool{}
+	for d := range Nodes(dg) {
+		gotNodes[d.String()] = true
+	}
+	wantNodes := map[tNode]bool{}
+	for n := range want {
+		wantNodes[n] = true
+	}
+	if diff := cmp.Diff(wantNodes, gotNodes); diff != "" {
+		t.Errorf("Nodes differs from expected (-want, +got):\n%s", diff)
+	}
+}
+
+// toTGraph converts a graph returned from [CollectRequirementGraph] or [CollectDependencyGraph] to
+// a [tGraph].
+func toTGraph(g map[ModuleId]map[ModuleId]bool) tGraph {
+	ret := tGraph{}
+	for p, es := range g {
+		ret[p.String()] = tEdges{}
+		for m, c := range es {
+			ret[p.String()][m.String()] = c
+		}
+	}
+	return ret
+}
+
+// resolveLocalReplace resolves a (devel) main module example.com/local that requires the module
+// example.com/b@v1.0.0 (which must be served by gp) and example.com/s, which the main module
+// replaces with a local directory.  The returned graph therefore has a [DevelVersion] root and a
+// [LocalVersion] node in addition to example.com/b@v1.0.0.
+func resolveLocalReplace(t *testing.T, gp *fm.TestFakeGoProxy) (context.Context, DependencyGraph) {
+	t.Helper()
+	// -mod=mod lets Go fill in the local module's go.sum.
+	env := append(gp.Environ(os.Environ()), "GOFLAGS=-mod=mod")
+	ctx := context.WithValue(t.Context(), command.EnvKey, env)
+	root := t.TempDir()
+	for fn, data := range map[string]string{
+		"main/go.mod": "module example.com/local\n\ngo 1.26.0\n\n" +
+			"require (\n\texample.com/b v1.0.0\n\texample.com/s v0.0.0\n)\n\n" +
+			"replace example.com/s => ../sibling\n",
+		"main/main.go":   "package main\n\nimport _ \"example.com/s\"\n\nfunc main() {}\n",
+		"sibling/go.mod": "module example.com/s\n\ngo 1.26.0\n\nrequire example.com/b v1.0.0\n",
+		"sibling/s.go":   "package s\n\nimport _ \"example.com/b\"\n",
+	} {
+		fn = filepath.Join(root, fn)
+		if err := os.MkdirAll(filepath.Dir(fn), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rg, err := RequirementsForPackages(ctx, filepath.Join(root, "main"), ".")
+	if err != nil {
 		t.Fatal(err)
 	}
-	if diff := cmp.Diff(want, got); diff != "" {
-		t.Errorf("graph differs from expected (-want, +got):\n%s", diff)
+	dg, err := ResolveMvs(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
 	}
+	return ctx, dg
 }
@@ -0,0 +1,115 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"fmt"
+	"go/version"
+	"slices"
+
+	"golang.org/x/mod/modfile"
+)
+
+// defaultGoVersion is the language version Go assumes for a main module whose go.mod lacks a [go
+// directive].
+//
+// [go directive]: https://go.dev/ref/mod#go-mod-file-go
+const defaultGoVersion = "1.16"
+
+// GoVersion returns the version in the given module's [go directive] (e.g., "1.21" or "1.22.3"),
+// or the empty string if its go.mod does not have a [go directive].  The module is looked up, so
+// it must have a released version; use [GoModBytes] for a [DevelVersion] or [LocalVersion] module.
+//
+// [go directive]: https://go.dev/ref/mod#go-mod-file-go
+func GoVersion(ctx context.Context, mId ModuleId) (string, error) {
+	if err := mId.Check(); err != nil {
+		return "", err
+	}
+	if mId.IsDevel() || mId.IsLocal() {
+		return "", fmt.Errorf("module %v has no released version", mId)
+	}
+	md, err := lsModule(ctx, mId)
+	if err != nil {
+		return "", err
+	}
+	return md.GoVersion, nil
+}
+
+// GoVersionConflicts returns the selected dependencies (see [Nodes]) whose [go directive] names a
+// newer Go language version than the root module's, sorted by [DependencyCompare].  Building the
+// root module with the Go version it declares might fail or require a [toolchain] switch because of
+// these dependencies.  Only language versions are compared, so "1.21.3" does not conflict with
+// "1.21".
+//
+// A dependency without a [go directive] never conflicts.  If the root module lacks a [go
+// directive], Go 1.16 is assumed, matching the go command's behavior.  The go.mod of a
+// [DevelVersion] or [LocalVersion] module is read from disk (see [GoModBytes]) instead of being
+// looked up; this requires a [DependencyGraph] returned from one of this package's resolvers.
+//
+// [go directive]: https://go.dev/ref/mod#go-mod-file-go
+// [toolchain]: https://go.dev/doc/toolchain
+func GoVersionConflicts(ctx context.Context, dg DependencyGraph) ([]Dependency, error) {
+	if dg.Root().Id() == MultiRootId {
+		return nil, fmt.Errorf("the synthetic root of a multi-root graph has no go directive")
+	}
+	mds, err := listReleased(ctx, dg)
+	if err != nil {
+		return nil, err
+	}
+	goVers := map[Dependency]string{}
+	for d, md := range mds {
+		goVers[d] = md.GoVersion
+	}
+	for d := range Nodes(dg) {
+		if mId := d.Id(); mId.IsDevel() || mId.IsLocal() {
+			if goVers[d], err = localGoVersion(ctx, dg, d); err != nil {
+				return nil, err
+			}
+		}
+	}
+	root := dg.Root()
+	rootVer := goVers[root]
+	if rootVer == "" {
+		rootVer = defaultGoVersion
+	}
+	ret := []Dependency(nil)
+	for d, v := range goVers {
+		if d != root && v != "" && version.Compare(goLang(v), goLang(rootVer)) > 0 {
+			ret = append(ret, d)
+		}
+	}
+	slices.SortFunc(ret, DependencyCompare)
+	return ret, nil
+}
+
+// localGoVersion returns the version in the [go directive] of the go.mod on disk of the given
+// [DevelVersion] or [LocalVersion] node, or the empty string if there is none.
+//
+// [go directive]: https://go.dev/ref/mod#go-mod-file-go
+func localGoVersion(ctx context.Context, dg DependencyGraph, d Dependency) (string, error) {
+	idg, ok := dg.(*dependencyGraph)
+	if !ok {
+		return "", fmt.Errorf("cannot read the go.mod of %v in a %T", d, dg)
+	}
+	r := idg.rg.Req(d.Id())
+	if r == nil {
+		return "", fmt.Errorf("no corresponding requirement for dependency %v", d)
+	}
+	data, err := GoModBytes(ctx, idg.rg, r)
+	if err != nil {
+		return "", err
+	}
+	goMod, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return "", err
+	}
+	if goMod.Go == nil {
+		return "", nil
+	}
+	return goMod.Go.Version, nil
+}
+
+// goLang converts a go directive version such as "1.21.3" to a [version] language version such as
+// "go1.21".
+func goLang(v string) string {
+	return version.Lang("go" + v)
+}
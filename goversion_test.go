@@ -0,0 +1,106 @@
+package gomoddepgraph_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestGoVersionConflicts(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/newer@v1.0.0"), fm.Go("1.25")},
+		[]fm.Option{fm.Id("example.com/older@v1.0.0"), fm.Go("1.20")},
+		[]fm.Option{fm.Id("example.com/same@v1.0.0"), fm.Go("1.21.0")},
+		[]fm.Option{fm.Id("example.com/none@v1.0.0"), fm.Go("")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Go("1.21"),
+			fm.Require("example.com/newer@v1.0.0", false),
+			fm.Require("example.com/older@v1.0.0", false),
+			fm.Require("example.com/same@v1.0.0", false),
+			fm.Require("example.com/none@v1.0.0", false)},
+	).Context()
+	rootId := ParseModuleId("example.com/root@v1.0.0")
+	if got, err := GoVersion(ctx, rootId); err != nil {
+		t.Fatal(err)
+	} else if want := "1.21"; got != want {
+		t.Errorf("got root go version %q, want %q", got, want)
+	}
+	// The root module's go directive is older than that of example.com/newer, so Go has to update
+	// the root's go.mod (in the clone) to resolve it.
+	rg, err := RequirementsGo(ctx, rootId, WithModFlag("mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dg, err := ResolveGo(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := GoVersionConflicts(ctx, dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotStr := []string(nil)
+	for _, d := range got {
+		gotStr = append(gotStr, d.String())
+	}
+	want := []string{"example.com/newer@v1.0.0"}
+	if diff := cmp.Diff(want, gotStr); diff != "" {
+		t.Errorf("unexpected go version conflicts (-want +got):\n%s", diff)
+	}
+
+	// The go directive of a (devel) root is read from its local go.mod.
+	dir := t.TempDir()
+	goMod := "module example.com/local\n\ngo 1.21\n\n" +
+		"require (\n\texample.com/newer v1.0.0\n\texample.com/older v1.0.0\n)\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0666); err != nil {
+		t.Fatal(err)
+	}
+	crg, done, err := RequirementsComplete(ctx, NewModuleId("example.com/local", DevelVersion), WithRootDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	if dg, err = ResolveMvs(ctx, crg); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = GoVersionConflicts(ctx, dg); err != nil {
+		t.Fatal(err)
+	}
+	gotStr = nil
+	for _, d := range got {
+		gotStr = append(gotStr, d.String())
+	}
+	if diff := cmp.Diff(want, gotStr); diff != "" {
+		t.Errorf("unexpected go version conflicts with a devel root (-want +got):\n%s", diff)
+	}
+}
+
+func TestGoVersionConflicts_LocalReplace(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/b@v1.0.0"), fm.Go("1.20")},
+	)
+	// The go command raises the main module's go directive to at least that of every dependency, so
+	// there are no conflicts, but the (devel) root and the (local) module must not be looked up.
+	ctx, dg := resolveLocalReplace(t, gp)
+	for _, d := range []Dependency{dg.Root(), dg.Selected(NewModuleId("example.com/s", LocalVersion))} {
+		if _, err := GoVersion(ctx, d.Id()); err == nil {
+			t.Errorf("GoVersion(%v) succeeded; want error", d)
+		}
+	}
+	got, err := GoVersionConflicts(ctx, dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotStr := []string(nil)
+	for _, d := range got {
+		gotStr = append(gotStr, d.String())
+	}
+	if diff := cmp.Diff([]string(nil), gotStr); diff != "" {
+		t.Errorf("unexpected go version conflicts (-want +got):\n%s", diff)
+	}
+}
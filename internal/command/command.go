@@ -19,6 +19,50 @@ type envKeyType struct{}
 // has the form "name=value".
 var EnvKey = envKeyType{}
 
+type runnerKeyType struct{}
+
+// RunnerKey is a [context.Context.WithValue] key that can be used to override how [Run], [Pipe],
+// and [DecodeJsonStream] execute commands.  The value must implement [Runner].
+var RunnerKey = runnerKeyType{}
+
+// A Runner executes commands.  See the exported CommandRunner interface in the top-level package
+// for the contract.
+type Runner interface {
+	Run(ctx context.Context, wd string, args ...string) error
+	Pipe(ctx context.Context, wd string, args ...string) (io.ReadCloser, func() error, error)
+}
+
+// Default is the [Runner] that executes commands for real with [New].
+var Default Runner = defaultRunner{}
+
+type defaultRunner struct{}
+
+func (defaultRunner) Run(ctx context.Context, wd string, args ...string) error {
+	return New(ctx, wd, args...).Run()
+}
+
+func (defaultRunner) Pipe(ctx context.Context, wd string, args ...string) (io.ReadCloser, func() error, error) {
+	cmd := New(ctx, wd, args...)
+	cmd.Stdout = nil
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get stdout pipe for command %q: %w",
+			strings.Join(args, " "), err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to start command %q: %w", strings.Join(args, " "), err)
+	}
+	return out, cmd.Wait, nil
+}
+
+// runner returns the [Runner] to use.
+func runner(ctx context.Context) Runner {
+	if r, _ := ctx.Value(RunnerKey).(Runner); r != nil {
+		return r
+	}
+	return Default
+}
+
 // New constructs a new [exec.Cmd] with the given arguments, leaving its stdout and stderr connected
 // to stdout and stderr.
 func New(ctx context.Context, wd string, args ...string) *exec.Cmd {
@@ -34,20 +78,18 @@ func New(ctx context.Context, wd string, args ...string) *exec.Cmd {
 	return cmd
 }
 
-// Pipe is like [New] except it connects the command's stdout to a pipe and the reading side is
-// returned.
-func Pipe(ctx context.Context, wd string, args ...string) (*exec.Cmd, io.ReadCloser, error) {
-	cmd := New(ctx, wd, args...)
-	cmd.Stdout = nil
-	out, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get stdout pipe for command %q: %w",
-			strings.Join(args, " "), err)
-	}
-	if err := cmd.Start(); err != nil {
-		return nil, nil, fmt.Errorf("failed to start command %q: %w", strings.Join(args, " "), err)
-	}
-	return cmd, out, nil
+// Run runs the given command with the [Runner] selected by ctx (see [RunnerKey]) and waits for it
+// to finish.  With the default [Runner], this is equivalent to calling Run on the [exec.Cmd]
+// returned from [New].
+func Run(ctx context.Context, wd string, args ...string) error {
+	return runner(ctx).Run(ctx, wd, args...)
+}
+
+// Pipe starts the given command with the [Runner] selected by ctx (see [RunnerKey]) and returns
+// the reading side of a pipe connected to the command's stdout.  The returned wait callback must be
+// called after reading is done; it waits for the command to exit.
+func Pipe(ctx context.Context, wd string, args ...string) (io.ReadCloser, func() error, error) {
+	return runner(ctx).Pipe(ctx, wd, args...)
 }
 
 // DecodeJsonStream calls [Pipe] and processes the output as a stream of JSON objects.  The returned
@@ -56,7 +98,7 @@ func Pipe(ctx context.Context, wd string, args ...string) (*exec.Cmd, io.ReadClo
 // receive a SIGPIPE signal.
 func DecodeJsonStream[T any](ctx context.Context, wd string, args ...string) (iter.Seq[T], func() error) {
 	var retErr error
-	var cmd *exec.Cmd
+	var wait func() error
 	var out io.ReadCloser
 	done := func() error {
 		if out != nil {
@@ -65,18 +107,18 @@ func DecodeJsonStream[T any](ctx context.Context, wd string, args ...string) (it
 			}
 			out = nil
 		}
-		if cmd != nil {
-			if err := cmd.Wait(); err != nil && retErr == nil {
+		if wait != nil {
+			if err := wait(); err != nil && retErr == nil {
 				retErr = fmt.Errorf("command %q failed: %w", strings.Join(args, " "), err)
 			}
-			cmd = nil
+			wait = nil
 		}
 		return retErr
 	}
 	return func(yield func(T) bool) {
 		defer done() // In case the caller fails to call it.
 		var err error
-		if cmd, out, err = Pipe(ctx, wd, args...); err != nil {
+		if out, wait, err = Pipe(ctx, wd, args...); err != nil {
 			retErr = err
 			return
 		}
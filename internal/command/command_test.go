@@ -254,7 +254,8 @@ echo '{"Key": "during"}'
 wait
 echo '{"Key": "after"}'
 `
-	ctx, _ := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
 	type T = struct{ Key string }
 	var exitErr *exec.ExitError
 	if err := func() (retErr error) {
@@ -0,0 +1,8 @@
+// Package graphpb contains the Go bindings for the Protocol Buffers messages defined in
+// graph.proto.  The bindings in graph.pb.go are generated by [protoc-gen-go]; do not edit them by
+// hand.
+//
+// [protoc-gen-go]: https://pkg.go.dev/google.golang.org/protobuf/cmd/protoc-gen-go
+package graphpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative graph.proto
@@ -0,0 +1,67 @@
+package graphpb_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rhansen/gomoddepgraph/internal/graphpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// golden is the output of:
+//
+//	protoc --encode=gomoddepgraph.DependencyGraph graph.proto <<EOF
+//	nodes { path: "example.com/a" version: "v1.0.0" }
+//	nodes { path: "example.com/b" version: "v1.2.3" }
+//	edges { to: 1 surprise: true }
+//	edges { from: 1 to: 300 }
+//	EOF
+const golden = "" +
+	"\x0a\x17" + "\x0a\x0dexample.com/a" + "\x12\x06v1.0.0" +
+	"\x0a\x17" + "\x0a\x0dexample.com/b" + "\x12\x06v1.2.3" +
+	"\x12\x04" + "\x10\x01" + "\x18\x01" +
+	"\x12\x05" + "\x08\x01" + "\x10\xac\x02"
+
+var goldenMsg = &graphpb.DependencyGraph{
+	Nodes: []*graphpb.Module{
+		{Path: "example.com/a", Version: "v1.0.0"},
+		{Path: "example.com/b", Version: "v1.2.3"},
+	},
+	Edges: []*graphpb.Edge{
+		{From: 0, To: 1, Surprise: true},
+		{From: 1, To: 300},
+	},
+}
+
+func TestDependencyGraph_Marshal(t *testing.T) {
+	got, err := proto.Marshal(goldenMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]byte(golden), got); diff != "" {
+		t.Errorf("unexpected encoding (-want, +got):\n%s", diff)
+	}
+}
+
+func TestDependencyGraph_Unmarshal(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		b    string
+	}{
+		{"golden", golden},
+		// Fields 15 (varint), 16 (I64), and 17 (I32) are unknown and must be ignored.
+		{"unknown fields", golden + "\x78\x05" + "\x81\x01\x00\x00\x00\x00\x00\x00\x00\x00" +
+			"\x8d\x01\x00\x00\x00\x00"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := &graphpb.DependencyGraph{}
+			if err := proto.Unmarshal([]byte(tc.b), got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(goldenMsg, got, protocmp.Transform(), protocmp.IgnoreUnknown()); diff != "" {
+				t.Errorf("unexpected message (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -16,11 +16,16 @@ func (m *Map[K, V]) LoadOrStore(k K, v V) (V, bool) {
 func (m *Map[K, V]) Load(k K) (V, bool) {
 	vAny, ok := m.syncMap.Load(k)
 	if !ok {
-		vAny = *new(V)
+		// A type assertion on a nil interface value fails even if V is an interface type.
+		return *new(V), false
 	}
 	return vAny.(V), ok
 }
 
+func (m *Map[K, V]) Store(k K, v V) {
+	m.syncMap.Store(k, v)
+}
+
 func (m *Map[K, V]) Range(f func(K, V) bool) {
 	m.syncMap.Range(func(k, v any) bool { return f(k.(K), v.(V)) })
 }
@@ -49,3 +54,7 @@ func (m *Map[K, V]) Swap(k K, v V) (V, bool) {
 func (m *Map[K, V]) CompareAndSwap(k K, vOld, vNew V) bool {
 	return m.syncMap.CompareAndSwap(k, vOld, vNew)
 }
+
+func (m *Map[K, V]) CompareAndDelete(k K, v V) bool {
+	return m.syncMap.CompareAndDelete(k, v)
+}
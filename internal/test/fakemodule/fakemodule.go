@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
@@ -16,6 +17,8 @@ import (
 	"iter"
 	"log/slog"
 	"maps"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
@@ -35,6 +38,11 @@ type config struct {
 	gmdg.ModuleId
 	synthetic bool
 	goMod     *modfile.File
+	// time is the publication time recorded in the .info file, or the zero time for the current
+	// time.
+	time time.Time
+	// files maps the names of extra files in the module's root directory to their contents.
+	files map[string][]byte
 }
 
 func (cfg *config) Check() error {
@@ -193,6 +201,41 @@ func Require(pathVer string, indirect bool) Option {
 	}
 }
 
+// Retract returns an [Option] that adds a [retract] directive to the fake module's go.mod.  The
+// directive retracts the single version ver with the given rationale (which may be empty).
+//
+// [retract]: https://go.dev/ref/mod#go-mod-file-retract
+func Retract(ver, rationale string) Option {
+	return func(cfg *config) error {
+		return cfg.goMod.AddRetract(modfile.VersionInterval{Low: ver, High: ver}, rationale)
+	}
+}
+
+// Time returns an [Option] that sets the fake module's publication time (the Time field of the
+// module proxy's .info response).  Defaults to the time the module is added.
+func Time(t time.Time) Option {
+	return func(cfg *config) error {
+		cfg.time = t
+		return nil
+	}
+}
+
+// File returns an [Option] that adds a file with the given name and contents to the root directory
+// of the fake module's contents (e.g., a LICENSE file).  The name must not be one of the files the
+// fake module always has (go.mod, go.sum, pkg.go, and pkg_test.go).
+func File(name string, data []byte) Option {
+	return func(cfg *config) error {
+		if slices.Contains([]string{"go.mod", "go.sum", "pkg.go", "pkg_test.go"}, name) {
+			return fmt.Errorf("file %q is reserved", name)
+		}
+		if cfg.files == nil {
+			cfg.files = map[string][]byte{}
+		}
+		cfg.files[name] = data
+		return nil
+	}
+}
+
 // Add is a low-level function that creates a new fake module in the given proxy directory.
 // dirHashes maps dependency modules to their directory hashes as returned from [dirhash.HashDir].
 // goModHashes maps dependency modules to their go.mod hashes as returned from
@@ -229,10 +272,14 @@ func Add(ctx context.Context, proxyDir string, dirHashes, goModHashes map[gmdg.M
 	}
 	// Create the $GOPROXY/<modpath>/@v/<modversion>.info file.
 	vb := filepath.Join(vd, cfg.Version)
+	pubTime := cfg.time
+	if pubTime.IsZero() {
+		pubTime = time.Now()
+	}
 	if err := fileSaveJson(vb+".info", &struct {
 		Version string
 		Time    time.Time
-	}{cfg.Version, time.Now()}); err != nil {
+	}{cfg.Version, pubTime}); err != nil {
 		return err
 	}
 	// Create the $GOPROXY/<modpath>/@v/<modversion>.zip file.
@@ -269,6 +316,12 @@ func Add(ctx context.Context, proxyDir string, dirHashes, goModHashes map[gmdg.M
 	if err := fileSave(filepath.Join(zipdir, "pkg_test.go"), []byte(pkgTestSrc)); err != nil {
 		return err
 	}
+	// Create the extra files.
+	for name, data := range cfg.files {
+		if err := fileSave(filepath.Join(zipdir, name), data); err != nil {
+			return err
+		}
+	}
 	// Format the *.go files.
 	if err := command.New(ctx, zipdir, "gofmt", "-w", "-e", ".").Run(); err != nil {
 		return err
@@ -291,7 +344,7 @@ func Add(ctx context.Context, proxyDir string, dirHashes, goModHashes map[gmdg.M
 		// Create go.sum.
 		sums := ""
 		for _, req := range cfg.goMod.Require {
-			d := gmdg.ModuleId{req.Mod}
+			d := gmdg.ModuleId{XModModuleVersion: req.Mod}
 			if dirHashes[d] == "" || goModHashes[d] == "" {
 				return fmt.Errorf("unable to build %v go.sum: hashes for dependency %v not found", cfg, d)
 			}
@@ -350,6 +403,8 @@ func Add(ctx context.Context, proxyDir string, dirHashes, goModHashes map[gmdg.M
 type FakeGoProxy struct {
 	modCacheDir string
 	proxyDir    string
+	proxyURL    string
+	certFile    string
 	dirHashes   map[gmdg.ModuleId]string
 	goModHashes map[gmdg.ModuleId]string
 }
@@ -414,22 +469,122 @@ func (gp *FakeGoProxy) CacheDir() string {
 	return gp.modCacheDir
 }
 
+// Hashes returns the directory hash and go.mod hash of the given fake module, as they would be
+// recorded in go.sum.  Both are the empty string if the module was not added.
+func (gp *FakeGoProxy) Hashes(mId gmdg.ModuleId) (dirHash, goModHash string) {
+	return gp.dirHashes[mId], gp.goModHashes[mId]
+}
+
+// StartHTTP starts an HTTP server that serves [FakeGoProxy.Dir] using the [GOPROXY protocol], and
+// changes [FakeGoProxy.Env] to direct the `go` command to the server instead of the directory.  The
+// returned stop callback shuts down the server and restores the previous `GOPROXY` value.
+//
+// [GOPROXY protocol]: https://go.dev/ref/mod#goproxy-protocol
+func (gp *FakeGoProxy) StartHTTP() (stop func()) {
+	return gp.useServer(httptest.NewServer(http.FileServer(http.Dir(gp.proxyDir))))
+}
+
+// StartHTTPSBasicAuth is like [FakeGoProxy.StartHTTP] except the server uses HTTPS and rejects any
+// request that does not have the given [Basic authentication] credentials.  (The `go` command
+// refuses to send credentials over plain HTTP.)  [FakeGoProxy.Env] directs the `go` command to
+// trust the server's self-signed certificate (via `SSL_CERT_FILE`) but does not include the
+// credentials; supply them via a .netrc file or the userinfo part of the `GOPROXY` URL (see
+// [FakeGoProxy.URL]).
+//
+// [Basic authentication]: https://datatracker.ietf.org/doc/html/rfc7617
+func (gp *FakeGoProxy) StartHTTPSBasicAuth(user, password string) (stop func(), retErr error) {
+	fs := http.FileServer(http.Dir(gp.proxyDir))
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != user || p != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="fakemodule"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fs.ServeHTTP(w, r)
+	}))
+	defer func() {
+		if retErr != nil {
+			srv.Close()
+		}
+	}()
+	f, err := os.CreateTemp("", "fakemodule-cert-*.pem")
+	if err != nil {
+		return nil, err
+	}
+	certFile := f.Name()
+	err = pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(certFile)
+		return nil, err
+	}
+	stopServer := gp.useServer(srv)
+	prevCertFile := gp.certFile
+	gp.certFile = certFile
+	return func() {
+		gp.certFile = prevCertFile
+		stopServer()
+		os.Remove(certFile)
+	}, nil
+}
+
+func (gp *FakeGoProxy) useServer(srv *httptest.Server) (stop func()) {
+	prev := gp.proxyURL
+	gp.proxyURL = srv.URL
+	return func() {
+		gp.proxyURL = prev
+		srv.Close()
+	}
+}
+
+// URL returns the URL of the HTTP server started by [FakeGoProxy.StartHTTP] or
+// [FakeGoProxy.StartHTTPSBasicAuth], or the empty string if the server is not running.
+func (gp *FakeGoProxy) URL() string {
+	return gp.proxyURL
+}
+
 // Env returns [Go environment variable] {name, value} pairs that tell the `go`
 // command to:
 //
-//   - use [FakeGoProxy.Dir] (via `GOPROXY`)
+//   - use [FakeGoProxy.Dir], or the HTTP server started by [FakeGoProxy.StartHTTP] or
+//     [FakeGoProxy.StartHTTPSBasicAuth] (via `GOPROXY`)
+//   - trust the certificate of the HTTPS server started by [FakeGoProxy.StartHTTPSBasicAuth], if
+//     any (via `SSL_CERT_FILE`)
 //   - use [FakeGoProxy.CacheDir] (via `GOMODCACHE`)
 //   - disable checksum verification (via `GOSUMDB`)
 //   - disable VCS downloads (via `GOVCS`)
+//   - ignore any [private module] settings from the user's environment or go env file, which could
+//     otherwise make the `go` command bypass the fake proxy (via `GOPRIVATE`, `GONOPROXY`,
+//     `GONOSUMDB`, and `GOINSECURE`)
+//   - ignore any `GOFLAGS` (such as -mod=mod) from the user's environment or go env file, so that
+//     tests behave the same everywhere
+//
+// These settings work for both the file:// and HTTP modes of the proxy.
 //
 // [Go environment variable]: https://go.dev/ref/mod#environment-variables
+// [private module]: https://go.dev/ref/mod#private-modules
 func (gp *FakeGoProxy) Env() iter.Seq2[string, string] {
-	return maps.All(map[string]string{
+	proxy := "file://" + gp.proxyDir
+	if gp.proxyURL != "" {
+		proxy = gp.proxyURL
+	}
+	env := map[string]string{
+		"GOFLAGS":    "",
+		"GOINSECURE": "",
 		"GOMODCACHE": gp.modCacheDir,
-		"GOPROXY":    "file://" + gp.proxyDir,
+		"GONOPROXY":  "",
+		"GONOSUMDB":  "",
+		"GOPRIVATE":  "",
+		"GOPROXY":    proxy,
 		"GOSUMDB":    "off",
 		"GOVCS":      "*:off",
-	})
+	}
+	if gp.certFile != "" {
+		env["SSL_CERT_FILE"] = gp.certFile
+	}
+	return maps.All(env)
 }
 
 // Environ returns the given environment variables (usually from [os.Environ]) augmented with the
@@ -472,6 +627,48 @@ func (gp *FakeGoProxy) AddAll(ctx context.Context, optss ...[]Option) error {
 	return nil
 }
 
+// AddChain creates n fake modules named prefix/m0 through prefix/m<n-1>, all at version v1.0.0,
+// where each module directly requires the next.  It returns the ID of the first module in the
+// chain.  This is useful for building large fixtures (e.g., for benchmarks).
+func (gp *FakeGoProxy) AddChain(ctx context.Context, prefix string, n int) (gmdg.ModuleId, error) {
+	if n < 1 {
+		return gmdg.ModuleId{}, fmt.Errorf("chain length must be positive, got %v", n)
+	}
+	id := func(i int) string { return fmt.Sprintf("%v/m%v@v1.0.0", prefix, i) }
+	for i := n - 1; i >= 0; i-- {
+		opts := []Option{Id(id(i))}
+		if i < n-1 {
+			opts = append(opts, Require(id(i+1), false))
+		}
+		if err := gp.Add(ctx, opts...); err != nil {
+			return gmdg.ModuleId{}, err
+		}
+	}
+	return gmdg.ParseModuleId(id(0)), nil
+}
+
+// AddFanOut creates n+2 fake modules, all at version v1.0.0:  prefix/root directly requires each
+// of prefix/b0 through prefix/b<n-1>, each of which directly requires prefix/c.  It returns the ID
+// of prefix/root.  This is useful for building large fixtures (e.g., for benchmarks).
+func (gp *FakeGoProxy) AddFanOut(ctx context.Context, prefix string, n int) (gmdg.ModuleId, error) {
+	c := prefix + "/c@v1.0.0"
+	if err := gp.Add(ctx, Id(c)); err != nil {
+		return gmdg.ModuleId{}, err
+	}
+	root := []Option{Id(prefix + "/root@v1.0.0")}
+	for i := range n {
+		b := fmt.Sprintf("%v/b%v@v1.0.0", prefix, i)
+		if err := gp.Add(ctx, Id(b), Require(c, false)); err != nil {
+			return gmdg.ModuleId{}, err
+		}
+		root = append(root, Require(b, false))
+	}
+	if err := gp.Add(ctx, root...); err != nil {
+		return gmdg.ModuleId{}, err
+	}
+	return gmdg.ParseModuleId(prefix + "/root@v1.0.0"), nil
+}
+
 // AddFromDir reads all *.mod files in the given directory and creates a fake module for each.  See
 // [GoMod] for how to specify the module version.
 func (gp *FakeGoProxy) AddFromDir(ctx context.Context, dataDir string) (retErr error) {
@@ -518,10 +715,10 @@ func (gp *FakeGoProxy) AddFromDir(ctx context.Context, dataDir string) (retErr e
 // A TestFakeGoProxy is like [FakeGoProxy] but with a more ergonomic interface meant for unit tests.
 type TestFakeGoProxy struct {
 	FakeGoProxy
-	t *testing.T
+	t testing.TB
 }
 
-func NewTestFakeGoProxy(t *testing.T) *TestFakeGoProxy {
+func NewTestFakeGoProxy(t testing.TB) *TestFakeGoProxy {
 	t.Helper()
 	gp, done, err := NewFakeGoProxy()
 	if err != nil {
@@ -562,6 +759,45 @@ func (gp *TestFakeGoProxy) AddFromDir(dataDir string) *TestFakeGoProxy {
 	return gp
 }
 
+// AddChain is like [FakeGoProxy.AddChain] except it fails the test on error.
+func (gp *TestFakeGoProxy) AddChain(prefix string, n int) gmdg.ModuleId {
+	gp.t.Helper()
+	mId, err := gp.FakeGoProxy.AddChain(gp.t.Context(), prefix, n)
+	if err != nil {
+		gp.t.Fatal(err)
+	}
+	return mId
+}
+
+// AddFanOut is like [FakeGoProxy.AddFanOut] except it fails the test on error.
+func (gp *TestFakeGoProxy) AddFanOut(prefix string, n int) gmdg.ModuleId {
+	gp.t.Helper()
+	mId, err := gp.FakeGoProxy.AddFanOut(gp.t.Context(), prefix, n)
+	if err != nil {
+		gp.t.Fatal(err)
+	}
+	return mId
+}
+
+// HTTP calls [FakeGoProxy.StartHTTP] and arranges for the server to be stopped when the test
+// completes.
+func (gp *TestFakeGoProxy) HTTP() *TestFakeGoProxy {
+	gp.t.Cleanup(gp.StartHTTP())
+	return gp
+}
+
+// HTTPSBasicAuth calls [FakeGoProxy.StartHTTPSBasicAuth] and arranges for the server to be
+// stopped when the test completes.  It fails the test on error.
+func (gp *TestFakeGoProxy) HTTPSBasicAuth(user, password string) *TestFakeGoProxy {
+	gp.t.Helper()
+	stop, err := gp.StartHTTPSBasicAuth(user, password)
+	if err != nil {
+		gp.t.Fatal(err)
+	}
+	gp.t.Cleanup(stop)
+	return gp
+}
+
 func (gp *TestFakeGoProxy) Context() context.Context {
 	return gp.WithEnv(gp.t.Context())
 }
@@ -0,0 +1,44 @@
+package gomoddepgraph
+
+import (
+	"slices"
+)
+
+// Layers assigns each [Dependency] reachable from [DependencyGraph.Root] to a layer equal to the
+// length of the longest path of dependency edges (see [Deps]) from the root to it, and returns the
+// layers in order:  the first layer holds only the root, and every edge that is not part of a cycle
+// points from a lower layer to a higher one.  This is the standard longest-path layering used to
+// draw layered diagrams (see [DotOptions.Layered]).
+//
+// Dependency cycles are handled by treating each strongly connected component (see [FindCycles]) as
+// a single node, so all members of a cycle are in the same layer.  The [Dependency] objects within
+// each layer are sorted by [DependencyCompare].
+func Layers(dg DependencyGraph) [][]Dependency {
+	sccs := stronglyConnectedComponents(dg)
+	comp := map[Dependency]int{}
+	for i, scc := range sccs {
+		for _, d := range scc {
+			comp[d] = i
+		}
+	}
+	// stronglyConnectedComponents returns the components in reverse topological order, so walking
+	// them backward visits every component after all of the components that depend on it.
+	layer := make([]int, len(sccs))
+	for i := len(sccs) - 1; i >= 0; i-- {
+		for _, p := range sccs[i] {
+			for d := range Deps(dg, p) {
+				if c := comp[d]; c != i {
+					layer[c] = max(layer[c], layer[i]+1)
+				}
+			}
+		}
+	}
+	ret := make([][]Dependency, slices.Max(layer)+1)
+	for i, scc := range sccs {
+		ret[layer[i]] = append(ret[layer[i]], scc...)
+	}
+	for _, l := range ret {
+		slices.SortFunc(l, DependencyCompare)
+	}
+	return ret
+}
@@ -0,0 +1,53 @@
+package gomoddepgraph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLayers(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		// d is one hop from r but its longest path from r goes through a, b, and c.
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/d@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/c@v1.0.0": {"example.com/b@v1.0.0": false, "example.com/d@v1.0.0": false},
+		"example.com/d@v1.0.0": {},
+	})
+	got := [][]string(nil)
+	for _, l := range Layers(dg) {
+		s := []string(nil)
+		for _, d := range l {
+			s = append(s, strings.TrimPrefix(d.Id().Path, "example.com/"))
+		}
+		got = append(got, s)
+	}
+	want := [][]string{{"r"}, {"a"}, {"b", "c"}, {"d"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected layers (-want +got):\n%s", diff)
+	}
+}
+
+func TestLayers_EdgeCases(t *testing.T) {
+	t.Parallel()
+	want := map[string][][]string{
+		"empty":      {{"r"}},
+		"cycles":     {{"a", "r"}, {"b", "c"}},
+		"multi-root": {{"gomoddepgraph.invalid/multiroot"}, {"x", "y"}, {"z"}},
+	}
+	for _, tc := range edgeCaseGraphs {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			got := [][]string(nil)
+			for _, l := range Layers(newTestDependencyGraph(t, tc.root, tc.g)) {
+				got = append(got, shortPaths(l))
+			}
+			if diff := cmp.Diff(want[tc.desc], got); diff != "" {
+				t.Errorf("unexpected layers (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// UnknownLicense is the license reported by [DetectLicense] for a module whose license could not be
+// identified.
+const UnknownLicense = "UNKNOWN"
+
+// A licenseRule identifies a license by phrases that appear in its text.
+type licenseRule struct {
+	// id is the license's [SPDX identifier].
+	//
+	// [SPDX identifier]: https://spdx.org/licenses/
+	id string
+	// all are phrases that must all appear in the normalized text (see normalizeLicense).
+	all []string
+	// none are phrases that must not appear in the normalized text.
+	none []string
+}
+
+// licenseRules are tried in order; the first rule that matches a license file identifies it.  More
+// specific rules (e.g., the LGPL) come before the rules they would otherwise also match (e.g., the
+// GPL).
+var licenseRules = []licenseRule{
+	{id: "Apache-2.0", all: []string{"apache license", "version 2.0"}},
+	{id: "MIT", all: []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}},
+	{id: "BSD-3-Clause", all: []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{id: "BSD-2-Clause", all: []string{"redistribution and use in source and binary forms"},
+		none: []string{"neither the name"}},
+	{id: "ISC", all: []string{"permission to use, copy, modify, and",
+		"distribute this software for any purpose with or without fee is hereby granted"}},
+	{id: "MPL-2.0", all: []string{"mozilla public license", "version 2.0"}},
+	{id: "AGPL-3.0", all: []string{"gnu affero general public license", "version 3"}},
+	{id: "LGPL-3.0", all: []string{"gnu lesser general public license", "version 3"}},
+	{id: "LGPL-2.1", all: []string{"gnu lesser general public license", "version 2.1"}},
+	{id: "GPL-3.0", all: []string{"gnu general public license", "version 3"}},
+	{id: "GPL-2.0", all: []string{"gnu general public license", "version 2"}},
+	{id: "Unlicense", all: []string{"this is free and unencumbered software released into the public domain"}},
+	{id: "CC0-1.0", all: []string{"cc0 1.0 universal"}},
+}
+
+// spdxTag matches an SPDX-License-Identifier tag, which takes precedence over licenseRules.
+var spdxTag = regexp.MustCompile(`(?m)SPDX-License-Identifier:\s*(\S+)\s*$`)
+
+// isLicenseFile reports whether a file in a module's root directory with the given name is
+// conventionally a license file (e.g., "LICENSE", "LICENSE.md", "COPYING", or "LICENCE-MIT").
+func isLicenseFile(name string) bool {
+	name = strings.ToLower(name)
+	for _, pfx := range []string{"license", "licence", "copying", "unlicense"} {
+		if strings.HasPrefix(name, pfx) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeLicense lower-cases the given license text and collapses runs of whitespace (including
+// line breaks and comment markers at the start of lines) to a single space so that licenseRules
+// phrases match regardless of how the text is wrapped.
+func normalizeLicense(text string) string {
+	fields := strings.Fields(strings.ToLower(text))
+	fields = slices.DeleteFunc(fields, func(f string) bool { return f == "//" || f == "#" || f == "*" })
+	return strings.Join(fields, " ")
+}
+
+// identifyLicense returns the [SPDX identifier] of the license in the given text, or the empty
+// string if it is not recognized.
+//
+// [SPDX identifier]: https://spdx.org/licenses/
+func identifyLicense(text string) string {
+	if m := spdxTag.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	norm := normalizeLicense(text)
+	for _, r := range licenseRules {
+		if !slices.ContainsFunc(r.all, func(p string) bool { return !strings.Contains(norm, p) }) &&
+			!slices.ContainsFunc(r.none, func(p string) bool { return strings.Contains(norm, p) }) {
+			return r.id
+		}
+	}
+	return ""
+}
+
+// licenseOfDir returns the license of the module whose contents are in the given directory.  See
+// [DetectLicense].
+func licenseOfDir(dir string) (string, error) {
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	ids := []string(nil)
+	for _, ent := range ents {
+		if !ent.Type().IsRegular() || !isLicenseFile(ent.Name()) {
+			continue
+		}
+		text, err := os.ReadFile(filepath.Join(dir, ent.Name()))
+		if err != nil {
+			return "", err
+		}
+		id := identifyLicense(string(text))
+		if id == "" {
+			return UnknownLicense, nil
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return UnknownLicense, nil
+	}
+	slices.Sort(ids)
+	return strings.Join(slices.Compact(ids), " AND "), nil
+}
+
+// DetectLicense downloads every selected [Dependency] in the given [DependencyGraph] (see [Nodes])
+// that has a released version and identifies the license in the license files (LICENSE, COPYING,
+// and the like) in the root directory of the module's contents.  The license is reported as an
+// [SPDX identifier] such as "MIT" or "Apache-2.0", taken from an SPDX-License-Identifier tag if the
+// file has one and otherwise recognized from distinctive phrases of a small set of common licenses.
+// If the module has several license files with different licenses, they are joined with " AND "
+// (e.g., "Apache-2.0 AND MIT"), in sorted order.  A module with no license file, or with a license
+// file that is not recognized, is reported as [UnknownLicense].
+//
+// This is a heuristic, not a legal determination: it does not look at license headers in source
+// files, and a modified license text may be misidentified or not recognized.
+//
+// [DevelVersion] and [LocalVersion] nodes and the synthetic root of a multi-root graph
+// ([MultiRootId]) are not included in the returned map because their contents cannot be
+// downloaded.
+//
+// [SPDX identifier]: https://spdx.org/licenses/
+func DetectLicense(ctx context.Context, dg DependencyGraph) (map[Dependency]string, error) {
+	ctx = withDepGraphEnv(ctx, dg)
+	deps := map[ModuleId]Dependency{}
+	for d := range Nodes(dg) {
+		if mId := d.Id(); !mId.IsDevel() && !mId.IsLocal() && mId != MultiRootId {
+			deps[mId] = d
+		}
+	}
+	mIds := slices.SortedFunc(maps.Keys(deps), ModuleIdCompare)
+	ret := map[Dependency]string{}
+	// Avoid hitting ARG_MAX.
+	for batch := range slices.Chunk(mIds, 500) {
+		mds, err := downloadModules(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, md := range mds {
+			mId := NewModuleId(md.Path, md.Version)
+			d := deps[mId]
+			if d == nil {
+				return nil, fmt.Errorf("go mod download reported unexpected module %v", mId)
+			}
+			if ret[d], err = licenseOfDir(md.Dir); err != nil {
+				return nil, fmt.Errorf("%v: %w", mId, err)
+			}
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,113 @@
+package gomoddepgraph_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+const (
+	mitText = `MIT License
+
+Copyright (c) 2024 Example
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction...
+`
+	apacheText = `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/
+`
+	bsd2Text = `Copyright (c) 2024 Example
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+`
+	bsd3Text = bsd2Text + `
+3. Neither the name of the copyright holder nor the names of its
+   contributors may be used to endorse or promote products derived from
+   this software without specific prior written permission.
+`
+)
+
+func TestDetectLicense(t *testing.T) {
+	t.Parallel()
+	mods := map[string]struct {
+		files map[string]string
+		want  string
+	}{
+		"example.com/mit@v1.0.0":    {map[string]string{"LICENSE": mitText}, "MIT"},
+		"example.com/apache@v1.0.0": {map[string]string{"LICENSE.txt": apacheText}, "Apache-2.0"},
+		"example.com/bsd2@v1.0.0":   {map[string]string{"LICENSE.md": bsd2Text}, "BSD-2-Clause"},
+		"example.com/bsd3@v1.0.0":   {map[string]string{"COPYING": bsd3Text}, "BSD-3-Clause"},
+		"example.com/spdx@v1.0.0": {map[string]string{"LICENSE": "SPDX-License-Identifier: EPL-2.0\n"},
+			"EPL-2.0"},
+		"example.com/dual@v1.0.0": {map[string]string{"LICENSE-MIT": mitText, "LICENSE-APACHE": apacheText},
+			"Apache-2.0 AND MIT"},
+		"example.com/same@v1.0.0": {map[string]string{"LICENSE": mitText, "COPYING": mitText}, "MIT"},
+		"example.com/proprietary@v1.0.0": {map[string]string{"LICENSE": "All rights reserved.\n"},
+			UnknownLicense},
+		"example.com/partial@v1.0.0": {map[string]string{"LICENSE": mitText, "COPYING": "Ask me.\n"},
+			UnknownLicense},
+		"example.com/none@v1.0.0": {map[string]string{"README": mitText}, UnknownLicense},
+	}
+	rootOpts := []fm.Option{fm.Id("example.com/root@v1.0.0"), fm.File("LICENSE", []byte(mitText))}
+	optss := [][]fm.Option(nil)
+	for pathVer, m := range mods {
+		opts := []fm.Option{fm.Id(pathVer)}
+		for name, text := range m.files {
+			opts = append(opts, fm.File(name, []byte(text)))
+		}
+		optss = append(optss, opts)
+		rootOpts = append(rootOpts, fm.Require(pathVer, false))
+	}
+	gp := fm.NewTestFakeGoProxy(t).AddAll(append(optss, rootOpts)...)
+	ctx := gp.Context()
+	rg, err := RequirementsGo(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dg, err := ResolveGo(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DetectLicense(ctx, dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"example.com/root@v1.0.0": "MIT"}
+	for pathVer, m := range mods {
+		want[pathVer] = m.want
+	}
+	gotStr := map[string]string{}
+	for d, l := range got {
+		gotStr[d.Id().String()] = l
+	}
+	if diff := cmp.Diff(want, gotStr); diff != "" {
+		t.Errorf("unexpected licenses (-want +got):\n%s", diff)
+	}
+}
+
+func TestDetectLicense_LocalReplace(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/b@v1.0.0"), fm.File("LICENSE", []byte(mitText))},
+	)
+	ctx, dg := resolveLocalReplace(t, gp)
+	got, err := DetectLicense(ctx, dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotStr := map[string]string{}
+	for d, l := range got {
+		gotStr[d.Id().String()] = l
+	}
+	want := map[string]string{"example.com/b@v1.0.0": "MIT"}
+	if diff := cmp.Diff(want, gotStr); diff != "" {
+		t.Errorf("unexpected licenses (-want +got):\n%s", diff)
+	}
+}
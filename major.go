@@ -0,0 +1,20 @@
+package gomoddepgraph
+
+import "slices"
+
+// GroupByMajor groups the selected dependencies (see [AllDependencies]) of the given
+// [DependencyGraph] by [ModuleId.CanonicalPath].  Each group is sorted by [DependencyCompare].  A
+// group with more than one [Dependency] means that the graph pulls in multiple major versions of
+// the same project (e.g., "example.com/foo" and "example.com/foo/v2"), which is a common source of
+// bloat.
+func GroupByMajor(dg DependencyGraph) map[string][]Dependency {
+	ret := map[string][]Dependency{}
+	for d := range AllDependencies(dg) {
+		p := d.Id().CanonicalPath()
+		ret[p] = append(ret[p], d)
+	}
+	for _, ds := range ret {
+		slices.SortFunc(ds, DependencyCompare)
+	}
+	return ret
+}
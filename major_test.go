@@ -0,0 +1,57 @@
+package gomoddepgraph
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGroupByMajor(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0":      {"example.com/foo@v1.0.0": false, "example.com/a@v1.0.0": false},
+		"example.com/a@v1.0.0":      {"example.com/foo/v2@v2.0.0": false},
+		"example.com/foo@v1.0.0":    {},
+		"example.com/foo/v2@v2.0.0": {},
+	})
+	got := map[string][]string{}
+	for p, ds := range GroupByMajor(dg) {
+		for _, d := range ds {
+			got[p] = append(got[p], d.String())
+		}
+	}
+	want := map[string][]string{
+		"example.com/r":   {"example.com/r@v1.0.0"},
+		"example.com/a":   {"example.com/a@v1.0.0"},
+		"example.com/foo": {"example.com/foo@v1.0.0", "example.com/foo/v2@v2.0.0"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected groups (-want +got):\n%s", diff)
+	}
+}
+
+func TestGroupByMajor_EdgeCases(t *testing.T) {
+	t.Parallel()
+	want := map[string][]string{
+		"empty":      {"r"},
+		"cycles":     {"a", "b", "c", "r"},
+		"multi-root": {"gomoddepgraph.invalid/multiroot", "x", "y", "z"},
+	}
+	for _, tc := range edgeCaseGraphs {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			got := []string(nil)
+			for p, ds := range GroupByMajor(newTestDependencyGraph(t, tc.root, tc.g)) {
+				if len(ds) != 1 {
+					t.Errorf("got %v dependencies for %v, want 1", len(ds), p)
+				}
+				got = append(got, shortPaths(ds)...)
+			}
+			slices.Sort(got)
+			if diff := cmp.Diff(want[tc.desc], got); diff != "" {
+				t.Errorf("unexpected groups (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
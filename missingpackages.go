@@ -0,0 +1,153 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/rhansen/gomoddepgraph/internal/command"
+	"github.com/rhansen/gomoddepgraph/internal/logging"
+	"golang.org/x/mod/modfile"
+)
+
+// MissingPackages returns the import paths of the packages that are imported (directly or
+// transitively) by the root module's packages but not provided by any module in the selection set
+// (see [Nodes] and [ModuleForPackage]), sorted.  A non-empty result means the selection set is
+// inconsistent with the import graph (e.g., because the [RequirementGraph] was incomplete or was
+// modified), which would otherwise surface as a failed build or a panic from
+// [DependencyGraph.DirectDeps].  Test-only imports are not considered.
+//
+// The import graph comes from `go list -deps` run in a temporary copy of the root module whose
+// go.mod requires every selected module at its selected version.  The root module's own [replace]
+// and [exclude] directives are ignored, as they are for [RequirementsGo].  The root module is
+// downloaded from the [module proxy] unless its version is [DevelVersion], in which case the graph
+// must have been resolved from a [RequirementsGo] graph built with [WithRootDir].
+//
+// [replace]: https://go.dev/ref/mod#go-mod-file-replace
+// [exclude]: https://go.dev/ref/mod#go-mod-file-exclude
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+func MissingPackages(ctx context.Context, dg DependencyGraph) (_ []string, retErr error) {
+	ctx = withDepGraphEnv(ctx, dg)
+	root := dg.Root()
+	rootId := root.Id()
+	if rootId == MultiRootId {
+		return nil, fmt.Errorf("MissingPackages does not support multi-root graphs")
+	}
+	var srcDir string
+	switch {
+	case rootId.IsDevel():
+		if dg, ok := dg.(*dependencyGraph); ok {
+			if grg, ok := dg.rg.(*requirementGraphGo); ok {
+				srcDir = grg.rootDir
+			}
+		}
+		if srcDir == "" {
+			return nil, fmt.Errorf("root module %v has no released version and its directory is unknown", rootId)
+		}
+	default:
+		mds, err := downloadModules(ctx, []ModuleId{rootId})
+		if err != nil {
+			return nil, err
+		}
+		srcDir = mds[0].Dir
+	}
+	goMod, err := readGoMod(filepath.Join(srcDir, "go.mod"))
+	if errors.Is(err, os.ErrNotExist) {
+		// A legacy non-module collection of packages.
+		goMod = &modfile.File{}
+		err = goMod.AddModuleStmt(rootId.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if goMod, err = filterGoMod(goMod, srcDir, false); err != nil {
+		return nil, err
+	}
+	selected := map[string]Dependency{}
+	for d := range Nodes(dg) {
+		mId := d.Id()
+		selected[mId.Path] = d
+		switch lp, local := LocalPath(d); {
+		case d == root:
+		case local:
+			// The placeholder version is what `go mod edit` uses for a directory replacement.
+			if err := goMod.AddRequire(mId.Path, "v0.0.0-00010101000000-000000000000"); err != nil {
+				return nil, err
+			}
+			if err := goMod.AddReplace(mId.Path, "", lp, ""); err != nil {
+				return nil, err
+			}
+		case mId.IsDevel() || mId.IsLocal():
+			return nil, fmt.Errorf("module %v has no released version and its directory is unknown", mId)
+		default:
+			if err := goMod.AddRequire(mId.Path, mId.Version); err != nil {
+				return nil, err
+			}
+		}
+	}
+	goModData, err := goMod.Format()
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp(tempDir(ctx), "gomoddepgraph-missing-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmp); retErr == nil {
+			retErr = err
+		}
+	}()
+	if err := copyModuleTree(srcDir, tmp); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "go.mod"), goModData, 0666); err != nil {
+		return nil, err
+	}
+
+	// -mod=mod lets the go command fill in go.sum, and -e reports imports that cannot be resolved as
+	// packages with errors instead of failing.
+	args := []string{goBin(ctx), "list", "-mod=mod", "-deps", "-e", "-json"}
+	if slog.Default().Enabled(ctx, logging.LevelVerbose) {
+		args = append(args, "-x")
+	}
+	args = append(args, "./...")
+	pkgIter, done := command.DecodeJsonStream[*jsonPackage](WithoutWorkspace(ctx), tmp, args...)
+	pkgs := slices.Collect(pkgIter)
+	if err := done(); err != nil {
+		return nil, err
+	}
+	missing := []string(nil)
+	for _, pkg := range pkgs {
+		if pkg.Standard {
+			continue
+		}
+		// The go command might have added a module to the build list to resolve an import, so a package
+		// is only known to be provided if the go command found it in a selected module.
+		if m := pkg.Module; m != nil {
+			if m.Main {
+				continue
+			}
+			if d := selected[m.Path]; d != nil {
+				_, local := LocalPath(d)
+				if d.Id().Version == m.Version || local && m.Replace != nil && m.Replace.Version == "" {
+					continue
+				}
+			}
+		}
+		d, err := moduleForPackage(ctx, dg, pkg.ImportPath)
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			slog.DebugContext(ctx, "no selected module provides package", "package", pkg.ImportPath)
+			missing = append(missing, pkg.ImportPath)
+		}
+	}
+	slices.Sort(missing)
+	return slices.Compact(missing), nil
+}
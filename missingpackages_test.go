@@ -0,0 +1,91 @@
+package gomoddepgraph_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestMissingPackages(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/b@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0"), fm.Require("example.com/b@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/a@v1.0.0", false)},
+	).Context()
+	rootId := ParseModuleId("example.com/root@v1.0.0")
+
+	t.Run("consistent", func(t *testing.T) {
+		t.Parallel()
+		rg, err := RequirementsGo(ctx, rootId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dg, err := ResolveGo(ctx, rg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MissingPackages(ctx, dg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got missing packages %q, want none", got)
+		}
+	})
+
+	t.Run("incomplete", func(t *testing.T) {
+		t.Parallel()
+		// Ignoring a's requirements drops b from the graph, but a's package imports b's package.
+		rg, done, err := RequirementsComplete(ctx, rootId, WithIgnoredPaths("example.com/a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer done()
+		dg, err := ResolveMvs(ctx, rg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MissingPackages(ctx, dg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"example.com/b"}, got); diff != "" {
+			t.Errorf("unexpected missing packages (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("devel", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		goMod := "module example.com/local\n\ngo 1.26.0\n\nrequire example.com/a v1.0.0\n"
+		src := "package local\n\nimport (\n\t_ \"example.com/a\"\n\t_ \"example.com/nope/pkg\"\n)\n"
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "local.go"), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+		// -mod=mod lets Go fill in the local module's go.sum.
+		rg, err := RequirementsGo(ctx, NewModuleId("example.com/local", DevelVersion), WithRootDir(dir),
+			WithModFlag("mod"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dg, err := ResolveGo(ctx, rg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MissingPackages(ctx, dg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"example.com/nope/pkg"}, got); diff != "" {
+			t.Errorf("unexpected missing packages (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package gomoddepgraph
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// ModuleForPackage returns the selected [Dependency] (see [Nodes]) that provides the package with
+// the given import path.  A module can only provide a package if its path is a prefix of the import
+// path (ending at a "/" boundary), and if more than one such module is selected, Go uses the one
+// with the longest path that actually contains the package's directory.  For example, if both
+// "cloud.google.com/go" and "cloud.google.com/go/storage" are selected, the package
+// "cloud.google.com/go/storage/internal" is provided by the latter because [nested modules] are
+// excluded from their parent module's contents.  Likewise, "example.com/m/v2/pkg" is provided by
+// "example.com/m/v2", not by "example.com/m" unless "example.com/m" has a "v2/pkg" directory that
+// is not part of a nested module.
+//
+// Candidate modules are downloaded into the [module cache] (longest path first) as needed to check
+// whether they contain the package's directory.  The directory of a [DevelVersion] module is
+// unknown, so such a module is assumed to contain the package.  Returns an error if no selected
+// module provides the package.
+//
+// [nested modules]: https://go.dev/ref/mod#zip-files
+// [module cache]: https://go.dev/ref/mod#module-cache
+func ModuleForPackage(ctx context.Context, dg DependencyGraph, importPath string) (Dependency, error) {
+	if err := module.CheckImportPath(importPath); err != nil {
+		return nil, err
+	}
+	d, err := moduleForPackage(withDepGraphEnv(ctx, dg), dg, importPath)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, fmt.Errorf("no selected module provides package %v", importPath)
+	}
+	return d, nil
+}
+
+// moduleForPackage implements [ModuleForPackage] except it returns nil (and no error) if no
+// selected module provides the package.
+func moduleForPackage(ctx context.Context, dg DependencyGraph, importPath string) (Dependency, error) {
+	cands := []Dependency(nil)
+	for d := range Nodes(dg) {
+		if p := d.Id().Path; importPath == p || strings.HasPrefix(importPath, p+"/") {
+			cands = append(cands, d)
+		}
+	}
+	slices.SortFunc(cands, func(a, b Dependency) int {
+		return cmp.Compare(len(b.Id().Path), len(a.Id().Path))
+	})
+	for _, d := range cands {
+		mId := d.Id()
+		var dir string
+		if lp, ok := LocalPath(d); ok {
+			dir = lp
+		} else if mId.IsDevel() || mId.IsLocal() {
+			return d, nil
+		} else {
+			mds, err := downloadModules(ctx, []ModuleId{mId})
+			if err != nil {
+				return nil, err
+			}
+			dir = mds[0].Dir
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(importPath, mId.Path), "/")
+		if fi, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rel))); err == nil && fi.IsDir() {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,56 @@
+package gomoddepgraph_test
+
+import (
+	"testing"
+
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestModuleForPackage(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/m@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/m/sub@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/m@v1.0.0", false),
+			fm.Require("example.com/m/sub@v1.0.0", false)},
+	).Context()
+	rg, err := RequirementsGo(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dg, err := ResolveGo(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		pkg, want string
+	}{
+		{"example.com/m", "example.com/m@v1.0.0"},
+		{"example.com/m/sub", "example.com/m/sub@v1.0.0"},
+		// example.com/m has no such directory.
+		{"example.com/m/other", ""},
+		// Major version 2 of example.com/m is not selected.
+		{"example.com/m/v2", ""},
+		{"example.com/nope", ""},
+		{"not a path", ""},
+	} {
+		t.Run(tc.pkg, func(t *testing.T) {
+			t.Parallel()
+			got, err := ModuleForPackage(ctx, dg, tc.pkg)
+			if tc.want == "" {
+				if err == nil {
+					t.Errorf("got %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
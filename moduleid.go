@@ -2,6 +2,7 @@ package gomoddepgraph
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -25,6 +26,24 @@ type ModuleId struct {
 	internal.XModModuleVersion
 }
 
+// DevelVersion is the sentinel version of a module that has no released version, such as the main
+// module of a local checkout.  It mirrors the "(devel)" version Go reports for such modules in
+// build information.  [ModuleId.Check] accepts it, but a module with this version is never looked
+// up on the [module proxy]; constructors that accept it as the root module must be told where to
+// find its go.mod (see [WithRootDir]).
+//
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+const DevelVersion = "(devel)"
+
+// LocalVersion is the sentinel version of a module that the main module's go.mod [replace]s with a
+// directory in the local filesystem (e.g., `replace example.com/m => ../m`).  Such a module has no
+// version on the [module proxy] and is never looked up there.  [ModuleId.Check] accepts it.  Use
+// [LocalPath] to get the directory of a [Dependency] with this version.
+//
+// [replace]: https://go.dev/ref/mod#go-mod-file-replace
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+const LocalVersion = "(local)"
+
 // NewModuleId constructs a new [ModuleId] from its path and version components.
 func NewModuleId(path, ver string) ModuleId {
 	return ModuleId{module.Version{Path: path, Version: ver}}
@@ -36,13 +55,46 @@ func ParseModuleId(pathVer string) ModuleId {
 	return NewModuleId(parts[0], parts[1])
 }
 
+// MarshalText implements [encoding.TextMarshaler].  The text form is "path@version" (or just "path"
+// if the version is the empty string), the same as [ModuleId.String].  Because [ModuleId]
+// implements [encoding.TextMarshaler], it can be used as a JSON object key.
+func (mId ModuleId) MarshalText() ([]byte, error) {
+	return []byte(mId.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].  It is the inverse of
+// [ModuleId.MarshalText]; see [ParseModuleId].
+func (mId *ModuleId) UnmarshalText(text []byte) error {
+	*mId = ParseModuleId(string(text))
+	return nil
+}
+
+// MarshalJSON implements [json.Marshaler].  A [ModuleId] is marshaled as a JSON string in the
+// "path@version" form (see [ModuleId.MarshalText]) rather than as an object.
+func (mId ModuleId) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mId.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].  It is the inverse of [ModuleId.MarshalJSON].
+func (mId *ModuleId) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*mId = ParseModuleId(s)
+	return nil
+}
+
 // Check asserts that the path and version are valid, and the version is canonical (not the empty
-// string or a [version query]).  A [ModuleId] that passes this check is assumed to have a resolved
-// (fully-specified) [ModuleId.Version] field.
+// string or a [version query]), [DevelVersion], or [LocalVersion].  A [ModuleId] that passes this
+// check is assumed to have a resolved (fully-specified) [ModuleId.Version] field.
 //
 // [version query]: https://go.dev/ref/mod#version-queries
 func (mId ModuleId) Check() error {
 	got := mId.Version
+	if mId.IsDevel() || mId.IsLocal() {
+		return module.CheckPath(mId.Path)
+	}
 	if err := module.Check(mId.Path, got); err != nil {
 		return err
 	}
@@ -55,35 +107,126 @@ func (mId ModuleId) Check() error {
 	return nil
 }
 
+// IsDevel reports whether [ModuleId.Version] is [DevelVersion].
+func (mId ModuleId) IsDevel() bool {
+	return mId.Version == DevelVersion
+}
+
+// IsLocal reports whether [ModuleId.Version] is [LocalVersion].
+func (mId ModuleId) IsLocal() bool {
+	return mId.Version == LocalVersion
+}
+
+// CanonicalPath returns [ModuleId.Path] with any [major version suffix] (e.g., "/v2", or ".v2" for
+// gopkg.in paths) removed.  Different major versions of the same project have the same canonical
+// path.
+//
+// [major version suffix]: https://go.dev/ref/mod#major-version-suffixes
+func (mId ModuleId) CanonicalPath() string {
+	prefix, _, ok := module.SplitPathVersion(mId.Path)
+	if !ok {
+		return mId.Path
+	}
+	return prefix
+}
+
+// Major returns the major version prefix of [ModuleId.Version] (e.g., "v2" for "v2.1.0"), or the
+// empty string if the version is not a valid semantic version.
+func (mId ModuleId) Major() string {
+	return semver.Major(mId.Version)
+}
+
 // ModuleIdCompare returns [strings.Compare] using each [ModuleId]'s [ModuleId.Path] if the two
-// paths differ, otherwise it returns [semver.Compare] using each [ModuleId]'s [ModuleId.Version].
+// paths differ, otherwise it compares each [ModuleId]'s [ModuleId.Version] like [semver.Compare]
+// except [LocalVersion] and then [DevelVersion] are ranked above every semantic version.
 func ModuleIdCompare(a, b ModuleId) int {
 	if cmp := strings.Compare(a.Path, b.Path); cmp != 0 {
 		return cmp
 	}
-	return semver.Compare(a.Version, b.Version)
+	return versionCompare(a.Version, b.Version)
+}
+
+// versionCompare is like [semver.Compare] except [LocalVersion] and then [DevelVersion] are ranked
+// above every semantic version.  (semver.Compare ranks them below every valid version.)  A module
+// with one of these versions is the code on disk, so it must win version selection over any
+// released version of the same module.
+func versionCompare(a, b string) int {
+	rank := func(v string) int {
+		switch v {
+		case DevelVersion:
+			return 2
+		case LocalVersion:
+			return 1
+		}
+		return 0
+	}
+	if cmp := rank(a) - rank(b); cmp != 0 || rank(a) != 0 {
+		return cmp
+	}
+	return semver.Compare(a, b)
 }
 
 // ResolveVersion resolves "latest" and other such [version query] strings to the actual version.
-// If the [ModuleId.Version] field is empty, "latest" is assumed.
+// All of the forms accepted by the go command are supported: "latest", version prefixes (e.g.,
+// "v1" or "v1.2"), comparisons (e.g., ">=v1.2.3" or "<v2"), full versions, and revision
+// identifiers (commit hashes, branch and tag names; these require direct access to the module's
+// repository).  Because there is no main module, there is no currently selected version, so
+// "upgrade" and "patch" are equivalent to "latest".  If the [ModuleId.Version] field is empty,
+// "latest" is assumed.  Module path patterns (paths containing "...") are not supported.
+//
+// If the module does not exist or has no version matching the query, the returned error wraps
+// [ErrModuleNotFound].  If ctx is canceled, ResolveVersion returns promptly with an error wrapping
+// the cancellation cause, without waiting for the go command to exit.
+//
+// Of the given options, only [WithPrivateModules] has an effect; the others are ignored.
 //
 // [version query]: https://go.dev/ref/mod#version-queries
-func ResolveVersion(ctx context.Context, mId ModuleId) (ModuleId, error) {
-	if mId.Version == "" {
+func ResolveVersion(ctx context.Context, mId ModuleId, opts ...RequirementsOption) (ModuleId, error) {
+	cfg, err := newRequirementsConfig(opts)
+	if err != nil {
+		return ModuleId{}, err
+	}
+	ctx = withEnv(ctx, cfg.privateEnv...)
+	if strings.Contains(mId.Path, "...") {
+		return ModuleId{}, fmt.Errorf("module path patterns are not supported: %v", mId)
+	}
+	switch mId.Version {
+	case "", "upgrade", "patch":
 		mId.Version = "latest"
 	}
-	cmd := []string{"go", "list", "-json", "-m"}
-	if slog.Default().Enabled(ctx, logging.LevelVerbose) {
-		cmd = []string{"go", "list", "-x", "-json", "-m"}
+	type result struct {
+		ls  []*jsonMetadata
+		err error
 	}
-	cmd = append(cmd, mId.String())
-	lsIter, finished := command.DecodeJsonStream[struct{ Path, Version string }](ctx, "/", cmd...)
-	ls := slices.Collect(lsIter)
-	if err := finished(); err != nil {
-		return ModuleId{}, err
+	// The go command may block on the network for a long time.  It is killed when ctx is canceled,
+	// but don't wait for the resulting EOF before returning.
+	resCh := make(chan result, 1)
+	go func() {
+		lsIter, finished := goListM(ctx, "/", "-e", mId.String())
+		ls := slices.Collect(lsIter)
+		resCh <- result{ls, finished()}
+	}()
+	var res result
+	select {
+	case <-ctx.Done():
+		return ModuleId{}, fmt.Errorf("resolving %v: %w", mId, context.Cause(ctx))
+	case res = <-resCh:
 	}
-	if len(ls) != 1 {
-		return ModuleId{}, fmt.Errorf("got %v results, want 1", len(ls))
+	if res.err != nil {
+		if ctx.Err() != nil {
+			return ModuleId{}, fmt.Errorf("resolving %v: %w", mId, context.Cause(ctx))
+		}
+		return ModuleId{}, res.err
+	}
+	ls := res.ls
+	switch {
+	case len(ls) == 0:
+		return ModuleId{}, fmt.Errorf("%v: %w: go list reported no matching module", mId, ErrModuleNotFound)
+	case len(ls) > 1:
+		return ModuleId{}, fmt.Errorf("%v: ambiguous version query: go list reported %v modules", mId, len(ls))
+	}
+	if err := ls[0].err(); err != nil {
+		return ModuleId{}, err
 	}
 	if ls[0].Path != mId.Path {
 		return ModuleId{}, fmt.Errorf("got path %v, want %v", ls[0].Path, mId.Path)
@@ -91,3 +234,50 @@ func ResolveVersion(ctx context.Context, mId ModuleId) (ModuleId, error) {
 	mId.Version = ls[0].Version
 	return mId, nil
 }
+
+// ListVersionsOptions customizes the output of [ListVersions].
+type ListVersionsOptions struct {
+	// Prereleases includes pre-release versions (e.g., "v1.2.0-rc.1").
+	Prereleases bool
+}
+
+// ListVersions returns the known versions of the module with the given path, as reported by `go
+// list -m -versions`, sorted in ascending [semver.Compare] order.  Retracted versions and
+// [pseudo-versions] are never included (the go command omits them), and pre-release versions are
+// omitted unless requested via [ListVersionsOptions].  This is useful for upgrade planning together
+// with [PreviewUpgrade].
+//
+// [pseudo-versions]: https://go.dev/ref/mod#pseudo-versions
+func ListVersions(ctx context.Context, path string, opts ListVersionsOptions) ([]string, error) {
+	if err := module.CheckPath(path); err != nil {
+		return nil, err
+	}
+	cmd := []string{goBin(ctx), "list", "-json", "-m", "-versions"}
+	if slog.Default().Enabled(ctx, logging.LevelVerbose) {
+		cmd = []string{goBin(ctx), "list", "-x", "-json", "-m", "-versions"}
+	}
+	cmd = append(cmd, path)
+	lsIter, finished := command.DecodeJsonStream[struct {
+		Path     string
+		Versions []string
+	}](ctx, "/", cmd...)
+	ls := slices.Collect(lsIter)
+	if err := finished(); err != nil {
+		return nil, err
+	}
+	if len(ls) != 1 {
+		return nil, fmt.Errorf("got %v results, want 1", len(ls))
+	}
+	if ls[0].Path != path {
+		return nil, fmt.Errorf("got path %v, want %v", ls[0].Path, path)
+	}
+	ret := []string{}
+	for _, v := range ls[0].Versions {
+		if semver.Prerelease(v) != "" && !opts.Prereleases {
+			continue
+		}
+		ret = append(ret, v)
+	}
+	semver.Sort(ret)
+	return ret, nil
+}
@@ -0,0 +1,204 @@
+package gomoddepgraph_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestModuleId_JSON(t *testing.T) {
+	t.Parallel()
+	type T struct {
+		Mod  ModuleId
+		Deps map[ModuleId][]ModuleId
+	}
+	for _, tc := range []struct {
+		desc string
+		v    T
+		json string
+	}{
+		{
+			desc: "path and version",
+			v: T{
+				Mod: NewModuleId("example.com/a", "v1.0.0"),
+				Deps: map[ModuleId][]ModuleId{
+					NewModuleId("example.com/a", "v1.0.0"): {NewModuleId("example.com/b", "v1.2.3")},
+				},
+			},
+			json: `{"Mod":"example.com/a@v1.0.0","Deps":{"example.com/a@v1.0.0":["example.com/b@v1.2.3"]}}`,
+		},
+		{
+			desc: "no version",
+			v:    T{Mod: NewModuleId("example.com/a", "")},
+			json: `{"Mod":"example.com/a","Deps":null}`,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			gotJson, err := json.Marshal(tc.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := string(gotJson), tc.json; got != want {
+				t.Errorf("got JSON %s, want %s", got, want)
+			}
+			var got T
+			if err := json.Unmarshal([]byte(tc.json), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.v, got); diff != "" {
+				t.Errorf("unexpected unmarshaled value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModuleId_CanonicalPathMajor(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		mId           string
+		wantCanonical string
+		wantMajor     string
+	}{
+		{"example.com/foo@v1.2.3", "example.com/foo", "v1"},
+		{"example.com/foo/v2@v2.0.1", "example.com/foo", "v2"},
+		{"gopkg.in/yaml.v3@v3.0.1", "gopkg.in/yaml", "v3"},
+		{"example.com/foo@v2.0.0+incompatible", "example.com/foo", "v2"},
+		{"example.com/foo@latest", "example.com/foo", ""},
+	} {
+		t.Run(tc.mId, func(t *testing.T) {
+			mId := ParseModuleId(tc.mId)
+			if got := mId.CanonicalPath(); got != tc.wantCanonical {
+				t.Errorf("got canonical path %q, want %q", got, tc.wantCanonical)
+			}
+			if got := mId.Major(); got != tc.wantMajor {
+				t.Errorf("got major %q, want %q", got, tc.wantMajor)
+			}
+		})
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).
+		Add(fm.Id("example.com/root@v1.0.0")).
+		Add(fm.Id("example.com/root@v1.1.0")).
+		Add(fm.Id("example.com/root@v1.1.1")).
+		Add(fm.Id("example.com/root@v1.2.0-pre")).
+		Context()
+	for _, tc := range []struct {
+		mod     string
+		want    string
+		wantErr *regexp.Regexp
+	}{
+		{mod: "example.com/root", want: "v1.1.1"},
+		{mod: "example.com/root@", want: "v1.1.1"},
+		{mod: "example.com/root@latest", want: "v1.1.1"},
+		{mod: "example.com/root@upgrade", want: "v1.1.1"},
+		{mod: "example.com/root@patch", want: "v1.1.1"},
+		{mod: "example.com/root@v1", want: "v1.1.1"},
+		{mod: "example.com/root@v1.0", want: "v1.0.0"},
+		{mod: "example.com/root@v1.1.0", want: "v1.1.0"},
+		{mod: "example.com/root@v1.2.0-pre", want: "v1.2.0-pre"},
+		{mod: "example.com/root@>=v1.1.0", want: "v1.1.0"},
+		{mod: "example.com/root@<v1.1.0", want: "v1.0.0"},
+		{mod: "example.com/root@<=v1.1.0", want: "v1.1.0"},
+		{mod: "example.com/root@>v1.1.1", want: "v1.2.0-pre"},
+		{mod: "example.com/...@latest", wantErr: regexp.MustCompile(`patterns are not supported`)},
+	} {
+		t.Run(tc.mod, func(t *testing.T) {
+			t.Parallel()
+			got, err := ResolveVersion(ctx, ParseModuleId(tc.mod))
+			if tc.wantErr != nil {
+				if err == nil || !tc.wantErr.MatchString(err.Error()) {
+					t.Errorf("got error %q, want error matching %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := NewModuleId("example.com/root", tc.want); got != want {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestResolveVersion_NotFound(t *testing.T) {
+	t.Parallel()
+	// The proxy is served over HTTP so that missing modules produce the same 404 errors as a real
+	// proxy.
+	ctx := fm.NewTestFakeGoProxy(t).
+		Add(fm.Id("example.com/root@v1.0.0")).
+		HTTP().
+		Context()
+	for _, mod := range []string{
+		"example.com/root@v2.0.0",
+		"example.com/root@v1.5",
+		"example.com/root@>v1.0.0",
+		"example.com/missing@latest",
+	} {
+		t.Run(mod, func(t *testing.T) {
+			t.Parallel()
+			got, err := ResolveVersion(ctx, ParseModuleId(mod))
+			if !errors.Is(err, ErrModuleNotFound) {
+				t.Errorf("got (%v, %q), want error wrapping %q", got, err, ErrModuleNotFound)
+			}
+		})
+	}
+}
+
+func TestResolveVersion_Canceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(fm.NewTestFakeGoProxy(t).
+		Add(fm.Id("example.com/root@v1.0.0")).
+		Context())
+	cancel()
+	if got, err := ResolveVersion(ctx, ParseModuleId("example.com/root@latest")); !errors.Is(err, context.Canceled) {
+		t.Errorf("got (%v, %q), want error wrapping %q", got, err, context.Canceled)
+	}
+}
+
+func TestListVersions(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).
+		Add(fm.Id("example.com/root@v1.10.0")).
+		Add(fm.Id("example.com/root@v1.2.0")).
+		Add(fm.Id("example.com/root@v1.2.1-rc.1")).
+		// The go command omits pseudo-versions from the list even if the proxy lists them.
+		Add(fm.Id("example.com/root@v0.0.0-20200101000000-0123456789ab")).
+		Context()
+	for _, tc := range []struct {
+		desc string
+		opts ListVersionsOptions
+		want []string
+	}{
+		{desc: "default", want: []string{"v1.2.0", "v1.10.0"}},
+		{
+			desc: "prereleases",
+			opts: ListVersionsOptions{Prereleases: true},
+			want: []string{"v1.2.0", "v1.2.1-rc.1", "v1.10.0"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			got, err := ListVersions(ctx, "example.com/root", tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected versions (-want +got):\n%s", diff)
+			}
+		})
+	}
+	if _, err := ListVersions(ctx, "not a path", ListVersionsOptions{}); err == nil {
+		t.Error("got nil error for invalid path, want error")
+	}
+}
@@ -0,0 +1,90 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"iter"
+)
+
+// PreviewUpgrade shows what the [DependencyGraph] would look like if the root module required the
+// latest version (see [ResolveVersion]) of the module with the given path, without editing any
+// go.mod.  The latest version is injected as an additional direct requirement of the root module
+// and the resulting graph is resolved with [ResolveMvs].  Because MVS selects the maximum required
+// version, this can only upgrade the module, never downgrade it.  The result shows the downstream
+// ripple of the upgrade.
+//
+// Modules already in rg keep the requirements rg reports for them.  Modules that are new to the
+// graph (such as the upgraded version itself) are loaded as if by [RequirementsComplete], so if rg
+// is [pruned] (e.g., from [RequirementsGo]) the new part of the graph is not.
+//
+// [pruned]: https://go.dev/ref/mod#graph-pruning
+func PreviewUpgrade(ctx context.Context, rg RequirementGraph, path string) (DependencyGraph, error) {
+	ctx = withGraphEnv(ctx, rg)
+	mId, err := ResolveVersion(ctx, NewModuleId(path, "latest"))
+	if err != nil {
+		return nil, err
+	}
+	extra, done, err := RequirementsComplete(ctx, mId)
+	if err != nil {
+		return nil, err
+	}
+	// [ResolveMvs] loads every node it needs, and the returned [DependencyGraph] only uses the loaded
+	// results, so the background loader is no longer needed once resolution finishes.
+	defer done()
+	return ResolveMvs(ctx, &requirementGraphUpgrade{base: rg, extra: extra, add: extra.Root()})
+}
+
+// A requirementGraphUpgrade is a [RequirementGraph] that adds a direct requirement to the root of a
+// base graph.  Nodes unknown to the base graph are delegated to extra.
+type requirementGraphUpgrade struct {
+	base, extra RequirementGraph
+	add         Requirement
+}
+
+var _ RequirementGraph = (*requirementGraphUpgrade)(nil)
+
+func (rg *requirementGraphUpgrade) Root() Requirement {
+	return rg.base.Root()
+}
+
+func (rg *requirementGraphUpgrade) Req(mId ModuleId) Requirement {
+	if r := rg.base.Req(mId); r != nil {
+		return r
+	}
+	return rg.extra.Req(mId)
+}
+
+// graph returns the graph that is responsible for the given node.
+func (rg *requirementGraphUpgrade) graph(m Requirement) RequirementGraph {
+	if rg.base.Req(m.Id()) != nil {
+		return rg.base
+	}
+	return rg.extra
+}
+
+func (rg *requirementGraphUpgrade) Load(ctx context.Context, m Requirement) error {
+	return rg.graph(m).Load(ctx, m)
+}
+
+func (rg *requirementGraphUpgrade) DirectReqs(m Requirement) iter.Seq[Requirement] {
+	reqs := rg.graph(m).DirectReqs(m)
+	if m != rg.Root() {
+		return reqs
+	}
+	return func(yield func(Requirement) bool) {
+		for r := range reqs {
+			if !yield(r) {
+				return
+			}
+		}
+		for r := range Reqs(rg.base, m) {
+			if r == rg.add {
+				return
+			}
+		}
+		yield(rg.add)
+	}
+}
+
+func (rg *requirementGraphUpgrade) ImmediateIndirectReqs(m Requirement) iter.Seq[Requirement] {
+	return rg.graph(m).ImmediateIndirectReqs(m)
+}
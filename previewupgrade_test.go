@@ -0,0 +1,31 @@
+package gomoddepgraph_test
+
+import (
+	"testing"
+
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestPreviewUpgrade(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/b@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/a@v1.1.0"), fm.Require("example.com/b@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/a@v1.0.0", false)},
+	).Context()
+	rg, err := RequirementsGo(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dg, err := PreviewUpgrade(ctx, rg, "example.com/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDepGraph(t, dg, tGraph{
+		"example.com/root@v1.0.0": {"example.com/a@v1.1.0": false},
+		"example.com/a@v1.1.0":    {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0":    {},
+	})
+}
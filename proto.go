@@ -0,0 +1,45 @@
+package gomoddepgraph
+
+import (
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/rhansen/gomoddepgraph/internal/graphpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// WriteProto writes the given [DependencyGraph] to w as a serialized Protocol Buffers
+// DependencyGraph message, as defined by the schema in internal/graphpb/graph.proto.  The root
+// [Dependency] is the first node, followed by the remaining nodes (see [AllDependencies]) in
+// [DependencyCompare] order.  Edges refer to nodes by index and are ordered by source then
+// destination node index.
+func WriteProto(w io.Writer, dg DependencyGraph) error {
+	root := dg.Root()
+	nodes := []Dependency{root}
+	for _, d := range slices.SortedFunc(AllDependencies(dg), DependencyCompare) {
+		if d != root {
+			nodes = append(nodes, d)
+		}
+	}
+	idx := map[Dependency]uint32{}
+	msg := &graphpb.DependencyGraph{}
+	for i, d := range nodes {
+		idx[d] = uint32(i)
+		msg.Nodes = append(msg.Nodes, &graphpb.Module{Path: d.Id().Path, Version: d.Id().Version})
+	}
+	for _, p := range nodes {
+		ds := maps.Collect(Deps(dg, p))
+		for _, d := range slices.SortedFunc(maps.Keys(ds), func(a, b Dependency) int {
+			return int(idx[a]) - int(idx[b])
+		}) {
+			msg.Edges = append(msg.Edges, &graphpb.Edge{From: idx[p], To: idx[d], Surprise: ds[d]})
+		}
+	}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,46 @@
+package gomoddepgraph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rhansen/gomoddepgraph/internal/graphpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestWriteProto(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/b@v1.0.0": false, "example.com/s@v1.0.0": true},
+		"example.com/b@v1.0.0": {"example.com/a@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/r@v1.0.0": false},
+		"example.com/s@v1.0.0": {},
+	})
+	buf := &bytes.Buffer{}
+	if err := WriteProto(buf, dg); err != nil {
+		t.Fatal(err)
+	}
+	got := &graphpb.DependencyGraph{}
+	if err := proto.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	want := &graphpb.DependencyGraph{
+		Nodes: []*graphpb.Module{
+			{Path: "example.com/r", Version: "v1.0.0"},
+			{Path: "example.com/a", Version: "v1.0.0"},
+			{Path: "example.com/b", Version: "v1.0.0"},
+			{Path: "example.com/s", Version: "v1.0.0"},
+		},
+		Edges: []*graphpb.Edge{
+			{From: 0, To: 2},
+			{From: 0, To: 3, Surprise: true},
+			{From: 1, To: 0},
+			{From: 2, To: 1},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("unexpected decoded graph (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,81 @@
+package gomoddepgraph
+
+// A RequirementSource identifies the kind of [RequirementGraph] a [DependencyGraph] was resolved
+// from.  The value is the name of the function that built the graph.
+type RequirementSource string
+
+const (
+	// RequirementSourceUnknown is used for graphs from [NewRequirementGraph] and for
+	// [RequirementGraph] implementations outside this package.
+	RequirementSourceUnknown RequirementSource = ""
+	// RequirementSourceGo is used for [pruned] graphs from [RequirementsGo].
+	//
+	// [pruned]: https://go.dev/ref/mod#graph-pruning
+	RequirementSourceGo RequirementSource = "RequirementsGo"
+	// RequirementSourceComplete is used for graphs from [RequirementsComplete],
+	// [RequirementsCompleteMulti], and [RequirementsFromGoMod].
+	RequirementSourceComplete RequirementSource = "RequirementsComplete"
+	// RequirementSourceUnified is used for graphs from [UnifyRequirements] and its variants.
+	RequirementSourceUnified RequirementSource = "UnifyRequirements"
+	// RequirementSourcePackages is used for graphs from [RequirementsForPackages].
+	RequirementSourcePackages RequirementSource = "RequirementsForPackages"
+)
+
+// Provenance records how a [DependencyGraph] was produced.  See [ProvenanceOf].
+type Provenance struct {
+	// Requirements identifies the [RequirementGraph] that was resolved.  Views such as [DirectOnly]
+	// report the source of the underlying graph, and the graph used by [PreviewUpgrade] reports the
+	// source of the graph passed to it.
+	Requirements RequirementSource
+	// Resolver is the name of the function that selected the dependencies: "ResolveGo",
+	// "ResolveMvs", or "ResolveSat".
+	Resolver string
+	// WithoutSurprises is true if the graph was resolved with [WithoutSurprises], in which case it
+	// has no surprise dependencies regardless of the requirements.
+	WithoutSurprises bool
+}
+
+// Pruned reports whether the dependencies were resolved from a [pruned] requirement graph, in which
+// case [DependencyGraph.SurpriseDeps] (and anything derived from it, such as overselection
+// reports) reflects Go's pruned view of the requirements rather than the complete view.
+//
+// [pruned]: https://go.dev/ref/mod#graph-pruning
+func (p Provenance) Pruned() bool {
+	return p.Requirements == RequirementSourceGo
+}
+
+// A provenancer is a [DependencyGraph] that records its [Provenance].
+type provenancer interface {
+	provenance() Provenance
+}
+
+// ProvenanceOf returns which requirement graph builder and resolver produced the given
+// [DependencyGraph], so that consumers can tell whether surprise dependencies reflect Go's [pruned]
+// view of the requirements or the complete view.  The zero [Provenance] is returned for a
+// [DependencyGraph] that was not produced by one of this package's resolvers (such as a wrapper
+// around one).
+//
+// [pruned]: https://go.dev/ref/mod#graph-pruning
+func ProvenanceOf(dg DependencyGraph) Provenance {
+	if p, ok := dg.(provenancer); ok {
+		return p.provenance()
+	}
+	return Provenance{}
+}
+
+// requirementSource returns the [RequirementSource] of rg.
+func requirementSource(rg RequirementGraph) RequirementSource {
+	switch rg := rg.(type) {
+	case *requirementGraph:
+		return rg.source
+	case *requirementGraphGo:
+		return RequirementSourceGo
+	case *requirementGraphComplete:
+		return RequirementSourceComplete
+	case *requirementGraphDirectOnly:
+		return requirementSource(rg.RequirementGraph)
+	case *requirementGraphUpgrade:
+		return requirementSource(rg.base)
+	}
+	return RequirementSourceUnknown
+}
@@ -0,0 +1,89 @@
+package gomoddepgraph_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestProvenance(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/a@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/a@v1.0.0", false)},
+	).Context()
+	rootId := ParseModuleId("example.com/root@v1.0.0")
+	crg, done, err := RequirementsComplete(ctx, rootId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	for _, tc := range []struct {
+		desc    string
+		rg      func() (RequirementGraph, error)
+		resolve func(context.Context, RequirementGraph, ...ResolveOption) (DependencyGraph, error)
+		want    Provenance
+	}{
+		{
+			desc:    "go",
+			rg:      func() (RequirementGraph, error) { return RequirementsGo(ctx, rootId) },
+			resolve: ResolveGo,
+			want:    Provenance{Requirements: RequirementSourceGo, Resolver: "ResolveGo"},
+		},
+		{
+			desc:    "complete",
+			rg:      func() (RequirementGraph, error) { return crg, nil },
+			resolve: ResolveMvs,
+			want:    Provenance{Requirements: RequirementSourceComplete, Resolver: "ResolveMvs"},
+		},
+		{
+			desc:    "direct-only",
+			rg:      func() (RequirementGraph, error) { return DirectOnly(crg), nil },
+			resolve: ResolveMvs,
+			want:    Provenance{Requirements: RequirementSourceComplete, Resolver: "ResolveMvs"},
+		},
+		{
+			desc:    "unified",
+			rg:      func() (RequirementGraph, error) { return UnifyRequirements(ctx, crg) },
+			resolve: ResolveSat,
+			want:    Provenance{Requirements: RequirementSourceUnified, Resolver: "ResolveSat"},
+		},
+		{
+			desc: "in-memory",
+			rg: func() (RequirementGraph, error) {
+				return NewRequirementGraph(rootId, map[ModuleId][]RequirementEdge{rootId: nil})
+			},
+			resolve: ResolveMvs,
+			want:    Provenance{Requirements: RequirementSourceUnknown, Resolver: "ResolveMvs"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			rg, err := tc.rg()
+			if err != nil {
+				t.Fatal(err)
+			}
+			dg, err := tc.resolve(ctx, rg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ProvenanceOf(dg); got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+			if got, want := ProvenanceOf(dg).Pruned(), tc.want.Requirements == RequirementSourceGo; got != want {
+				t.Errorf("got Pruned() %v, want %v", got, want)
+			}
+		})
+	}
+	t.Run("wrapper", func(t *testing.T) {
+		dg, err := ResolveMvs(ctx, crg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wrapped := struct{ DependencyGraph }{dg}
+		if got := ProvenanceOf(wrapped); got != (Provenance{}) {
+			t.Errorf("got %+v for a wrapper, want the zero Provenance", got)
+		}
+	})
+}
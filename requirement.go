@@ -33,3 +33,25 @@ var _ Requirement = requirement{}
 func (r requirement) Id() ModuleId {
 	return r.ModuleId
 }
+
+// localRequirement is a [Requirement] on a module with version [LocalVersion].  dir is the absolute
+// path of the directory that replaces the module.
+type localRequirement struct {
+	ModuleId
+	dir string
+}
+
+var _ Requirement = localRequirement{}
+
+func (r localRequirement) Id() ModuleId {
+	return r.ModuleId
+}
+
+// withId returns a [Requirement] of the same kind as r (e.g., with the same local directory) but
+// with the given [ModuleId].
+func withId(r Requirement, mId ModuleId) Requirement {
+	if lr, ok := r.(localRequirement); ok && mId.IsLocal() {
+		return localRequirement{mId, lr.dir}
+	}
+	return requirement{mId}
+}
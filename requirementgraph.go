@@ -2,8 +2,10 @@ package gomoddepgraph
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"iter"
+	"sync"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/rhansen/gomoddepgraph/internal/itertools"
@@ -11,6 +13,13 @@ import (
 
 // A RequirementGraph is a directed graph (possibly cyclic) representing the transitive closure of
 // module requirements starting with a particular root module.
+//
+// The requirements implied by the go.mod [go] and [toolchain] directives are not modeled:  no
+// [RequirementGraph] contains the "go" or "toolchain" pseudo-modules that `go mod graph` prints.
+// Use [GoVersion] to get a module's go directive.
+//
+// [go]: https://go.dev/ref/mod#go-mod-file-go
+// [toolchain]: https://go.dev/ref/mod#go-mod-file-toolchain
 type RequirementGraph interface {
 	// Root returns the root node in the [RequirementGraph].
 	Root() Requirement
@@ -68,11 +77,25 @@ func Reqs(rg RequirementGraph, r Requirement) iter.Seq2[Requirement, bool] {
 
 type requirementGraphReqs struct {
 	d, i mapset.Set[Requirement]
+	// t is the subset of d and i that provide a tool declared by a go.mod tool directive, or nil if
+	// unknown or there are none.
+	t mapset.Set[Requirement]
+	// goModPath is the path of the go.mod file the requirements were read from, or the empty string
+	// if unknown.  Only the path is kept (rather than the contents) to bound the memory used by a
+	// huge graph; see [GoModBytes].
+	goModPath string
+	// goMod is the raw contents of the go.mod the requirements were read from if they did not come
+	// from a file (see [RequirementsFromGoMod]), or nil otherwise.
+	goMod []byte
 }
 
 type requirementGraph struct {
 	root Requirement
 	reqs map[Requirement]*requirementGraphReqs
+	// source is reported by [ProvenanceOf] for graphs resolved from this one.
+	source RequirementSource
+	// rootGoMod is the path of the root module's go.mod file, or the empty string if unknown.
+	rootGoMod string
 }
 
 var _ RequirementGraph = (*requirementGraph)(nil)
@@ -85,6 +108,16 @@ func (rg *requirementGraph) Req(mId ModuleId) Requirement {
 	if err := mId.Check(); err != nil {
 		panic(err)
 	}
+	if mId.IsLocal() {
+		// Local nodes are keyed by their directory too, which isn't known here.  They are rare, so a
+		// linear scan is cheap enough.
+		for m := range rg.reqs {
+			if lr, ok := m.(localRequirement); ok && lr.ModuleId == mId {
+				return m
+			}
+		}
+		return nil
+	}
 	m := requirement{mId}
 	if rg.reqs[m] == nil {
 		return nil
@@ -107,6 +140,51 @@ func (rg *requirementGraph) ImmediateIndirectReqs(m Requirement) iter.Seq[Requir
 	return mapset.Elements(rg.reqs[m].i)
 }
 
+// NewRequirementGraph returns an in-memory [RequirementGraph] rooted at root.  Each key of edges is
+// a node in the graph, and its value lists the node's outgoing edges.  Only the [Requirement.Id] of
+// each edge's To field is used; From may be nil, otherwise its [Requirement.Id] must equal the key.
+// Every edge target and the root must be a key of edges.  The returned graph is fully loaded, so
+// [RequirementGraph.Load] does nothing.
+//
+// This is useful for testing code that consumes a [RequirementGraph], and for reconstructing a
+// graph from the edges yielded by [AllRequirementEdges].
+func NewRequirementGraph(root ModuleId, edges map[ModuleId][]RequirementEdge) (RequirementGraph, error) {
+	rg := &requirementGraph{
+		root: requirement{root},
+		reqs: map[Requirement]*requirementGraphReqs{},
+	}
+	for mId := range edges {
+		if err := mId.Check(); err != nil {
+			return nil, err
+		}
+		rg.reqs[requirement{mId}] = &requirementGraphReqs{
+			d: mapset.NewThreadUnsafeSet[Requirement](),
+			i: mapset.NewThreadUnsafeSet[Requirement](),
+		}
+	}
+	if rg.reqs[rg.root] == nil {
+		return nil, fmt.Errorf("root %v is not a node", root)
+	}
+	for mId, es := range edges {
+		rs := rg.reqs[requirement{mId}]
+		for _, e := range es {
+			if e.From != nil && e.From.Id() != mId {
+				return nil, fmt.Errorf("edge %v -> %v listed under %v", e.From, e.To, mId)
+			}
+			r := requirement{e.To.Id()}
+			if rg.reqs[r] == nil {
+				return nil, fmt.Errorf("requirement %v of %v is not a node", r, mId)
+			}
+			if e.Indirect {
+				rs.i.Add(r)
+			} else {
+				rs.d.Add(r)
+			}
+		}
+	}
+	return rg, nil
+}
+
 // WalkRequirementGraph visits each node ([Requirement]) and edge in the [RequirementGraph] in
 // topological order and calls the optional visit callbacks.  The callbacks are called at most once
 // per node or edge.  Either callback (or both) may be nil.
@@ -134,6 +212,16 @@ func WalkRequirementGraph(ctx context.Context, rg RequirementGraph, start Requir
 	return walkGraph(ctx, start, nodeVisit, rg.Load, edges, edgeVisit)
 }
 
+// walkRequirementGraphSorted is like [WalkRequirementGraph] except the walk is performed
+// sequentially in a deterministic order (see walkGraphSorted).
+func walkRequirementGraphSorted(ctx context.Context, rg RequirementGraph, start Requirement,
+	nodeVisit func(ctx context.Context, m Requirement) (bool, error),
+	edgeVisit func(ctx context.Context, p, m Requirement, ind bool) error) error {
+
+	edges := func(m Requirement) iter.Seq2[Requirement, bool] { return Reqs(rg, m) }
+	return walkGraphSorted(ctx, start, nodeVisit, rg.Load, edges, edgeVisit, RequirementCompare)
+}
+
 // AllRequirements walks the given [RequirementGraph] and yields every [Requirement] it encounters.
 // The [Requirement] objects are yielded in topological order.  Every yielded [Requirement] is
 // loaded (see [RequirementGraph.Load]).  The returned done callback must be called when done
@@ -141,3 +229,61 @@ func WalkRequirementGraph(ctx context.Context, rg RequirementGraph, start Requir
 func AllRequirements(ctx context.Context, rg RequirementGraph) (iter.Seq[Requirement], func() error) {
 	return allNodes(ctx, rg, rg.Root(), WalkRequirementGraph)
 }
+
+// A RequirementEdge is an edge in a [RequirementGraph]: From requires To.  Indirect is true if To
+// is one of From's [RequirementGraph.ImmediateIndirectReqs].
+type RequirementEdge struct {
+	From, To Requirement
+	Indirect bool
+}
+
+// AllRequirementEdges walks the given [RequirementGraph] and yields every edge it encounters.  The
+// edges are yielded in topological order (see [WalkRequirementGraph]).  The returned done callback
+// must be called when done iterating; it returns the first error encountered during the walk.
+func AllRequirementEdges(ctx context.Context, rg RequirementGraph) (iter.Seq[RequirementEdge], func() error) {
+	stop := false
+	var retErr error
+	var mu sync.Mutex
+	return func(yield func(RequirementEdge) bool) {
+		retErr = WalkRequirementGraph(ctx, rg, rg.Root(), nil,
+			func(ctx context.Context, p, m Requirement, ind bool) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if stop || !yield(RequirementEdge{From: p, To: m, Indirect: ind}) {
+					stop = true
+					return walkStopErr
+				}
+				return nil
+			})
+		if errors.Is(retErr, walkStopErr) {
+			retErr = nil
+		}
+	}, func() error { return retErr }
+}
+
+// CollectRequirementGraph walks the given [RequirementGraph] and returns it as a plain map.  Each
+// key is the [Requirement.Id] of a node reachable from the root, and its value maps the
+// [Requirement.Id] of each of the node's requirements to true if it is an immediate indirect
+// requirement (see [RequirementGraph.ImmediateIndirectReqs]).  Every node is a key, even if it has
+// no requirements.  This is convenient for comparing graphs or serializing them; see
+// [NewRequirementGraph] for the reverse.
+func CollectRequirementGraph(ctx context.Context, rg RequirementGraph) (map[ModuleId]map[ModuleId]bool, error) {
+	var mu sync.Mutex
+	ret := map[ModuleId]map[ModuleId]bool{}
+	if err := WalkRequirementGraph(ctx, rg, rg.Root(),
+		func(ctx context.Context, m Requirement) (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			ret[m.Id()] = map[ModuleId]bool{}
+			return true, nil
+		},
+		func(ctx context.Context, p, m Requirement, ind bool) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ret[p.Id()][m.Id()] = ind
+			return nil
+		}); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,153 @@
+package gomoddepgraph
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAllRequirementEdges(t *testing.T) {
+	t.Parallel()
+	rg := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/i@v1.0.0": true},
+		"example.com/a@v1.0.0": {"example.com/r@v1.0.0": false},
+		"example.com/i@v1.0.0": {},
+	})
+	edges, done := AllRequirementEdges(t.Context(), rg)
+	got := []string(nil)
+	for e := range edges {
+		got = append(got, fmt.Sprintf("%v -> %v %v", e.From, e.To, e.Indirect))
+	}
+	if err := done(); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	want := []string{
+		"example.com/a@v1.0.0 -> example.com/r@v1.0.0 false",
+		"example.com/r@v1.0.0 -> example.com/a@v1.0.0 false",
+		"example.com/r@v1.0.0 -> example.com/i@v1.0.0 true",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected edges (-want +got):\n%s", diff)
+	}
+
+	// Stopping early is not an error.
+	edges, done = AllRequirementEdges(t.Context(), rg)
+	for range edges {
+		break
+	}
+	if err := done(); err != nil {
+		t.Errorf("got error %v after stopping early, want nil", err)
+	}
+}
+
+func TestNewRequirementGraph(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	orig := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/i@v1.0.0": true},
+		"example.com/a@v1.0.0": {"example.com/r@v1.0.0": false},
+		"example.com/i@v1.0.0": {},
+	})
+	// Round trip through AllRequirementEdges.
+	edges := map[ModuleId][]RequirementEdge{}
+	for r := range orig.reqs {
+		edges[r.Id()] = nil
+	}
+	es, done := AllRequirementEdges(ctx, orig)
+	for e := range es {
+		edges[e.From.Id()] = append(edges[e.From.Id()], e)
+	}
+	if err := done(); err != nil {
+		t.Fatal(err)
+	}
+	rg, err := NewRequirementGraph(orig.Root().Id(), edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := func(rg RequirementGraph) []string {
+		es, done := AllRequirementEdges(ctx, rg)
+		got := []string(nil)
+		for e := range es {
+			got = append(got, fmt.Sprintf("%v -> %v %v", e.From, e.To, e.Indirect))
+		}
+		if err := done(); err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(got)
+		return got
+	}
+	if diff := cmp.Diff(str(orig), str(rg)); diff != "" {
+		t.Errorf("unexpected edges (-want +got):\n%s", diff)
+	}
+
+	r := ParseModuleId("example.com/r@v1.0.0")
+	a := ParseModuleId("example.com/a@v1.0.0")
+	for _, tc := range []struct {
+		desc  string
+		edges map[ModuleId][]RequirementEdge
+	}{
+		{desc: "missing root", edges: map[ModuleId][]RequirementEdge{a: nil}},
+		{
+			desc:  "missing target",
+			edges: map[ModuleId][]RequirementEdge{r: {{To: requirement{a}}}},
+		},
+		{
+			desc: "mismatched From",
+			edges: map[ModuleId][]RequirementEdge{
+				r: {{From: requirement{a}, To: requirement{a}}},
+				a: nil,
+			},
+		},
+		{desc: "invalid node", edges: map[ModuleId][]RequirementEdge{r: nil, NewModuleId("example.com/x", ""): nil}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if _, err := NewRequirementGraph(r, tc.edges); err == nil {
+				t.Error("got nil error, want error")
+			}
+		})
+	}
+}
+
+func TestDirectOnly(t *testing.T) {
+	t.Parallel()
+	rg := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/i@v1.0.0": true},
+		"example.com/a@v1.0.0": {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0": {},
+		"example.com/i@v1.0.0": {"example.com/b@v1.1.0": false},
+		"example.com/b@v1.1.0": {},
+	})
+	drg := DirectOnly(rg)
+	reqs, done := AllRequirements(t.Context(), drg)
+	got := []string(nil)
+	for r := range reqs {
+		got = append(got, r.String())
+	}
+	if err := done(); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	want := []string{"example.com/a@v1.0.0", "example.com/b@v1.0.0", "example.com/r@v1.0.0"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected requirements (-want +got):\n%s", diff)
+	}
+	dg, err := ResolveMvs(t.Context(), drg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dg.Selected(ParseModuleId("example.com/b@v1.0.0")).String(), "example.com/b@v1.0.0"; got != want {
+		t.Errorf("got selected %v, want %v", got, want)
+	}
+	if DirectOnly(drg) != drg {
+		t.Error("DirectOnly of a DirectOnly view is not the same view")
+	}
+	if IncludeIndirect(drg) != RequirementGraph(rg) {
+		t.Error("IncludeIndirect did not undo DirectOnly")
+	}
+	if IncludeIndirect(rg) != RequirementGraph(rg) {
+		t.Error("IncludeIndirect changed a graph that was not from DirectOnly")
+	}
+}
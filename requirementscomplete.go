@@ -9,7 +9,9 @@ import (
 	"os"
 	"runtime"
 	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/rhansen/gomoddepgraph/internal/itertools"
@@ -31,103 +33,452 @@ import (
 // Calling the returned done callback gracefully shuts down a background goroutine, freeing some
 // resources.  Canceling the provided [context.Context] also shuts down, but does so less gracefully
 // and might log a spurious context canceled error.  Once shut down, in-progress and future calls to
-// [RequirementGraph.Load] might fail.
+// [RequirementGraph.Load] might fail.  Failing to do either leaks the goroutine until both the
+// graph and the done callback are garbage collected, at which point a warning is logged and the
+// goroutine is shut down.
+//
+// If the root module's version is [DevelVersion], its requirements are read from the go.mod in the
+// directory given by [WithRootDir].  By default, [RequirementGraph.Load] fails if a module's
+// metadata cannot be loaded; pass [WithBestEffort] to get a partial graph instead.  Pass
+// [WithMaxDepth] to limit the graph to the modules near the root, or [WithMaxNodes] to fail instead
+// of exhausting memory on a huge graph.
 //
 // [pruned]: https://go.dev/ref/mod#graph-pruning
 // [replace]: https://go.dev/ref/mod#go-mod-file-replace
 // [exclude]: https://go.dev/ref/mod#go-mod-file-exclude
-func RequirementsComplete(ctx context.Context, rootId ModuleId) (RequirementGraph, func(), error) {
+func RequirementsComplete(ctx context.Context, rootId ModuleId, opts ...RequirementsOption) (RequirementGraph, func(), error) {
 	if err := rootId.Check(); err != nil {
 		return nil, func() {}, err
 	}
+	cfg, err := newRequirementsConfig(opts)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	var rootReqs *requirementGraphReqs
+	if rootId.IsDevel() {
+		goMod, goModFile, err := cfg.develRootGoMod(rootId)
+		if err != nil {
+			return nil, func() {}, err
+		}
+		rootReqs = goModReqs(goMod)
+		rootReqs.goModPath = goModFile
+		if vendored, err := cfg.vendored(ctx, rootId); err != nil {
+			return nil, func() {}, err
+		} else if vendored {
+			slog.WarnContext(ctx, "root module is built in vendor mode, but the requirement graph "+
+				"reflects go.mod files, not vendor/modules.txt", "dir", cfg.rootDir)
+		}
+	}
+	return requirementsComplete(ctx, rootId, rootReqs, cfg)
+}
+
+// RequirementsFromGoMod is like [RequirementsComplete] except the root module's requirements are
+// read from the given go.mod contents instead of being fetched; the requirements of every other
+// module are fetched from the [module proxy] as usual.  This is useful for experimenting with a
+// go.mod that has not been published.
+//
+// The root module's path is taken from the go.mod's module directive.  Its version is
+// [DevelVersion] unless a comment of the form `//version:v1.2.3` (no space before "version" or
+// after the colon) immediately precedes the module directive, for example:
+//
+//	//version:v1.2.3
+//	module example.com/foo
+//
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+func RequirementsFromGoMod(ctx context.Context, goModData []byte, opts ...RequirementsOption) (RequirementGraph, func(), error) {
+	goMod, err := modfile.ParseLax("go.mod", goModData, nil)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	if goMod.Module == nil {
+		return nil, func() {}, fmt.Errorf("go.mod: missing module directive")
+	}
+	rootId := NewModuleId(goMod.Module.Mod.Path, DevelVersion)
+	if cs := goMod.Module.Syntax.Comments.Before; len(cs) > 0 {
+		const pfx = "//version:"
+		if v, ok := strings.CutPrefix(cs[len(cs)-1].Token, pfx); ok {
+			rootId.Version = v
+		}
+	}
+	if err := rootId.Check(); err != nil {
+		return nil, func() {}, err
+	}
+	cfg, err := newRequirementsConfig(opts)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	rootReqs := goModReqs(goMod)
+	rootReqs.goMod = goModData
+	return requirementsComplete(ctx, rootId, rootReqs, cfg)
+}
+
+// requirementsComplete constructs a [RequirementsComplete] graph.  If rootReqs is non-nil, it is
+// used as the root module's requirements instead of fetching the root module's go.mod.
+func requirementsComplete(ctx context.Context, rootId ModuleId, rootReqs *requirementGraphReqs,
+	cfg *requirementsConfig) (RequirementGraph, func(), error) {
+
+	ctx = withEnv(ctx, cfg.privateEnv...)
+	root := requirement{rootId}
+	rg, done := newRequirementGraphComplete(ctx, root)
+	rg.privateEnv = cfg.privateEnv
+	rg.bestEffort = cfg.bestEffort
+	rg.isIgnored = cfg.isIgnored
+	rg.maxNodes = cfg.maxNodes
+	if rootReqs != nil {
+		rg.immReqs.Store(root, newReqsOnce(func() (*requirementGraphReqs, error) { return rootReqs, nil }))
+	}
+	if cfg.maxDepth >= 0 {
+		if err := rg.truncate(ctx, cfg.maxDepth); err != nil {
+			done()
+			return nil, func() {}, err
+		}
+	}
+	return rg, done, nil
+}
+
+// truncate loads every module within maxDepth edges of the root, level by level, and records the
+// modules at depth maxDepth as having no requirements (see [WithMaxDepth]).  A module's depth is
+// the length of its shortest path from the root, so the result does not depend on load order.  Must
+// be called before the graph is returned to the caller.
+func (rg *requirementGraphCompleteState) truncate(ctx context.Context, maxDepth int) error {
+	rg.truncated = mapset.NewThreadUnsafeSet[Requirement]()
+	seen := mapset.NewThreadUnsafeSet(rg.root)
+	level := []Requirement{rg.root}
+	for depth := 0; len(level) > 0; depth++ {
+		if depth == maxDepth {
+			for _, m := range level {
+				rg.immReqs.Store(m, newReqsOnce(func() (*requirementGraphReqs, error) {
+					return &requirementGraphReqs{
+						d: mapset.NewThreadUnsafeSet[Requirement](),
+						i: mapset.NewThreadUnsafeSet[Requirement](),
+					}, nil
+				}))
+				rg.truncated.Add(m)
+			}
+			return nil
+		}
+		gr, grCtx := errgroup.WithContext(ctx)
+		for _, m := range level {
+			gr.Go(func() error { return rg.Load(grCtx, m) })
+		}
+		if err := gr.Wait(); err != nil {
+			return err
+		}
+		next := []Requirement(nil)
+		for _, m := range level {
+			for r := range Reqs(rg, m) {
+				if seen.Add(r) {
+					next = append(next, r)
+				}
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+// Truncated reports whether the given requirement is at the depth limit of a graph constructed with
+// [WithMaxDepth].  A truncated requirement's requirements are unknown; the graph reports it as
+// having none.
+func Truncated(rg RequirementGraph, r Requirement) bool {
+	crg, ok := rg.(*requirementGraphComplete)
+	if !ok || crg.truncated == nil {
+		return false
+	}
+	return crg.truncated.Contains(r)
+}
+
+// MultiRootId is the [ModuleId] of the synthetic root module of a graph returned from
+// [RequirementsCompleteMulti].  The path uses the reserved ".invalid" top-level domain so that it
+// cannot collide with a real module.
+var MultiRootId = NewModuleId("gomoddepgraph.invalid/multiroot", "v0.0.0")
+
+// RequirementsCompleteMulti is like [RequirementsComplete] except the graph's root is a synthetic
+// module identified by [MultiRootId] whose direct requirements are the given modules.  This is
+// useful for analyzing several modules (e.g., the modules in a workspace or monorepo) together as a
+// single graph.  The synthetic root has no go.mod; it is never downloaded.
+func RequirementsCompleteMulti(ctx context.Context, rootIds ...ModuleId) (RequirementGraph, func(), error) {
+	if len(rootIds) == 0 {
+		return nil, func() {}, fmt.Errorf("no root modules")
+	}
+	reqs := &requirementGraphReqs{
+		d: mapset.NewThreadUnsafeSet[Requirement](),
+		i: mapset.NewThreadUnsafeSet[Requirement](),
+	}
+	for _, rootId := range rootIds {
+		if err := rootId.Check(); err != nil {
+			return nil, func() {}, err
+		}
+		reqs.d.Add(requirement{rootId})
+	}
+	root := requirement{MultiRootId}
+	rg, done := newRequirementGraphComplete(ctx, root)
+	rg.immReqs.Store(root, newReqsOnce(func() (*requirementGraphReqs, error) { return reqs, nil }))
+	return rg, done, nil
+}
+
+func newRequirementGraphComplete(ctx context.Context, root Requirement) (*requirementGraphComplete, func()) {
 	gr, ctx := errgroup.WithContext(ctx)
 	shutdown := make(chan struct{})
-	rg := &requirementGraphComplete{
-		root:     requirement{rootId},
+	st := &requirementGraphCompleteState{
+		root:     root,
 		ctx:      ctx,
 		gr:       gr,
 		qCh:      make(chan *loadQ),
 		shutdown: shutdown,
 	}
+	stop := sync.OnceFunc(func() { close(shutdown) })
+	wait := func() {
+		if err := st.gr.Wait(); err != nil {
+			slog.WarnContext(ctx, "RequirementsComplete failed to shut down cleanly", "err", err)
+		}
+	}
+	rg := &requirementGraphComplete{st}
 	done := func() {
+		stop()
+		wait()
+		// The graph must not be considered leaked until the done callback is unreachable.
+		runtime.KeepAlive(rg)
+	}
+	// The background goroutine only references st, so rg can become unreachable even if done was
+	// never called.  Shut down the goroutine instead of leaking it.
+	runtime.AddCleanup(rg, func(st *requirementGraphCompleteState) {
 		select {
-		case <-shutdown:
-			rg.gr.Wait()
+		case <-st.shutdown:
+			return
 		default:
-			close(shutdown)
-			if err := rg.gr.Wait(); err != nil {
-				slog.WarnContext(ctx, "RequirementsComplete failed to shut down cleanly", "err", err)
-			}
 		}
-	}
-	gr.Go(func() error { return rg.batchify(ctx) })
-	return rg, done, nil
+		slog.WarnContext(ctx, "RequirementsComplete graph was garbage collected without calling its done callback",
+			"root", st.root)
+		stop()
+		go wait()
+	}, st)
+	gr.Go(func() error { return st.batchify(ctx) })
+	return rg, done
 }
 
+// requirementGraphComplete is the [RequirementGraph] returned from [RequirementsComplete].  It is a
+// thin wrapper around the state shared with the background goroutine so that a leaked graph can be
+// detected and shut down when it is garbage collected (see [runtime.AddCleanup]).
 type requirementGraphComplete struct {
+	*requirementGraphCompleteState
+}
+
+type requirementGraphCompleteState struct {
 	root     Requirement
-	immReqs  syncmap.Map[Requirement, func() (*requirementGraphReqs, error)]
+	immReqs  syncmap.Map[Requirement, *reqsOnce]
 	ctx      context.Context
 	gr       *errgroup.Group
 	qCh      chan *loadQ
 	shutdown <-chan struct{}
+
+	batchesMu sync.Mutex
+	batches   []LoadBatchStats
+
+	bestEffort bool
+	failures   syncmap.Map[Requirement, error]
+
+	// isIgnored reports whether a module's requirements are ignored (see [WithIgnoredPaths]).  It is
+	// nil for graphs whose constructor does not take options.
+	isIgnored func(path string) bool
+
+	// maxNodes is the limit set by [WithMaxNodes], or 0 if unlimited.  nodes holds the modules
+	// admitted under that limit (see admit).
+	maxNodes int
+	nodesMu  sync.Mutex
+	nodes    mapset.Set[Requirement]
+
+	// privateEnv holds the environment variable settings from [WithPrivateModules].  The background
+	// loader's context already applies them; they are kept for withGraphEnv.
+	privateEnv []string
+
+	// truncated is the set of modules at the depth limit if [WithMaxDepth] was given, otherwise nil.
+	// It is not modified after the graph is returned to the caller.
+	truncated mapset.Set[Requirement]
+}
+
+// LoadBatchStats describes one batched module metadata lookup (one `go list -m` invocation)
+// performed by a [RequirementsComplete] graph.
+type LoadBatchStats struct {
+	// Start is when the lookup started.
+	Start time.Time
+	// Duration is how long the lookup took.
+	Duration time.Duration
+	// Modules are the modules whose metadata was looked up, sorted by [ModuleIdCompare].
+	Modules []ModuleId
+}
+
+// LoadBatches returns statistics for each batched module metadata lookup that the given graph has
+// completed so far, in completion order.  A batch is recorded shortly after its results are
+// delivered to [RequirementGraph.Load]; call the graph's done callback first to ensure that every
+// batch is included.  This can help identify slow lookups and tune concurrency and batch sizes.
+// Returns nil if the graph was not returned from [RequirementsComplete] or
+// [RequirementsCompleteMulti].  Thread-safe.
+func LoadBatches(rg RequirementGraph) []LoadBatchStats {
+	crg, ok := rg.(*requirementGraphComplete)
+	if !ok {
+		return nil
+	}
+	crg.batchesMu.Lock()
+	defer crg.batchesMu.Unlock()
+	return slices.Clone(crg.batches)
+}
+
+// LoadErrors returns the errors encountered while loading modules in a graph constructed with
+// [WithBestEffort], one per failed module, sorted by module (see [Unresolved]).  Returns nil if the
+// graph was not returned from [RequirementsComplete] or no load has failed.  Thread-safe.
+func LoadErrors(rg RequirementGraph) []error {
+	crg, ok := rg.(*requirementGraphComplete)
+	if !ok {
+		return nil
+	}
+	failures := crg.failures.ToMap()
+	var errs []error
+	for _, m := range slices.SortedFunc(maps.Keys(failures), RequirementCompare) {
+		errs = append(errs, failures[m])
+	}
+	return errs
+}
+
+// Unresolved reports whether the given requirement failed to load in a graph constructed with
+// [WithBestEffort].  An unresolved requirement's requirements are unknown; the graph reports it as
+// having none.  Thread-safe.
+func Unresolved(rg RequirementGraph, r Requirement) bool {
+	crg, ok := rg.(*requirementGraphComplete)
+	if !ok {
+		return false
+	}
+	_, failed := crg.failures.Load(r)
+	return failed
 }
 
 var _ RequirementGraph = (*requirementGraphComplete)(nil)
 
-func (rg *requirementGraphComplete) Root() Requirement {
+func (rg *requirementGraphCompleteState) Root() Requirement {
 	return rg.root
 }
 
-func (rg *requirementGraphComplete) Req(mId ModuleId) Requirement {
+func (rg *requirementGraphCompleteState) Req(mId ModuleId) Requirement {
 	if err := mId.Check(); err != nil {
 		panic(err)
 	}
 	return requirement{mId}
 }
 
-func (rg *requirementGraphComplete) Load(ctx context.Context, m Requirement) error {
+// A reqsOnce memoizes the result of loading a module's requirements.  Entries in immReqs are
+// pointers so that they can be compared (functions are not comparable), which allows a failed entry
+// to be removed without the risk of removing a newer entry stored by a concurrent retry.
+type reqsOnce struct {
+	get func() (*requirementGraphReqs, error)
+}
+
+func newReqsOnce(f func() (*requirementGraphReqs, error)) *reqsOnce {
+	return &reqsOnce{sync.OnceValues(f)}
+}
+
+// Load loads m's requirements.  Concurrent calls for the same module share a single load.  A
+// successful load is never repeated: the successful entry is never removed from immReqs, and a new
+// entry is only stored if there is no entry.  A failed entry is removed by whichever caller
+// observes the failure first (compare-and-delete, so only that exact entry is removed), allowing
+// retries.  A call that joined another call's failed load (which might have failed only because the
+// other caller's context was canceled) retries with its own context; a call whose own load failed
+// returns the error.
+func (rg *requirementGraphCompleteState) Load(ctx context.Context, m Requirement) error {
+	if err := rg.admit(m); err != nil {
+		return err
+	}
 	for {
-		fn, loaded := rg.immReqs.LoadOrStore(m,
-			sync.OnceValues(func() (*requirementGraphReqs, error) { return rg.load(ctx, m.Id()) }))
-		if _, err := fn(); err == nil {
+		mine := newReqsOnce(func() (*requirementGraphReqs, error) { return rg.loadBestEffort(ctx, m) })
+		e, _ := rg.immReqs.LoadOrStore(m, mine)
+		_, err := e.get()
+		if err == nil {
 			return nil
-		} else if !loaded {
-			// Allow a future (or concurrent) call to retry.
-			rg.immReqs.Delete(m)
+		}
+		rg.immReqs.CompareAndDelete(m, e)
+		if e == mine {
 			return err
 		}
-		// The other call to Load that stored the [sync.Once] will delete the failed entry, allowing
-		// this invocation to retry.  Yield to the scheduler to give the other goroutine an opportunity
-		// to run before retrying.
-		runtime.Gosched()
+		if err := context.Cause(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// admit returns an error wrapping [ErrTooManyNodes] if m is not one of the first modules loaded
+// under the [WithMaxNodes] limit.  A module that has been admitted is always admitted again, so
+// retries and repeated loads of the same module are unaffected by the limit.
+func (rg *requirementGraphCompleteState) admit(m Requirement) error {
+	if rg.maxNodes == 0 {
+		return nil
+	}
+	rg.nodesMu.Lock()
+	defer rg.nodesMu.Unlock()
+	if rg.nodes == nil {
+		rg.nodes = mapset.NewThreadUnsafeSet[Requirement]()
+	}
+	if rg.nodes.Contains(m) {
+		return nil
+	}
+	if rg.nodes.Cardinality() >= rg.maxNodes {
+		return fmt.Errorf("cannot load %v: %w: limit of %d reached", m, ErrTooManyNodes, rg.maxNodes)
 	}
+	rg.nodes.Add(m)
+	return nil
 }
 
-func (rg *requirementGraphComplete) DirectReqs(m Requirement) iter.Seq[Requirement] {
+func (rg *requirementGraphCompleteState) DirectReqs(m Requirement) iter.Seq[Requirement] {
 	return mapset.Elements(rg.reqs(m).d)
 }
 
-func (rg *requirementGraphComplete) ImmediateIndirectReqs(m Requirement) iter.Seq[Requirement] {
+func (rg *requirementGraphCompleteState) ImmediateIndirectReqs(m Requirement) iter.Seq[Requirement] {
 	return mapset.Elements(rg.reqs(m).i)
 }
 
-func (rg *requirementGraphComplete) reqs(m Requirement) *requirementGraphReqs {
-	fn, _ := rg.immReqs.Load(m)
-	if fn == nil {
+func (rg *requirementGraphCompleteState) reqs(m Requirement) *requirementGraphReqs {
+	e, _ := rg.immReqs.Load(m)
+	if e == nil {
 		panic(fmt.Errorf("module %v not yet loaded", m))
 	}
-	reqs, err := fn()
+	reqs, err := e.get()
 	if err != nil {
 		panic(fmt.Errorf("previous load of module %v failed; got error %w", m, err))
 	}
 	return reqs
 }
 
-func (rg *requirementGraphComplete) load(ctx context.Context, mId ModuleId) (*requirementGraphReqs, error) {
+// loadBestEffort calls load.  If the graph was constructed with [WithBestEffort] and the failure is
+// not due to cancelation or shutdown, the error is recorded and empty requirements are returned.
+func (rg *requirementGraphCompleteState) loadBestEffort(ctx context.Context, m Requirement) (*requirementGraphReqs, error) {
+	reqs, err := rg.load(ctx, m.Id())
+	if err == nil || !rg.bestEffort || ctx.Err() != nil || rg.ctx.Err() != nil {
+		return reqs, err
+	}
+	select {
+	case <-rg.shutdown:
+		return nil, err
+	default:
+	}
+	slog.WarnContext(ctx, "RequirementsComplete: treating module as unresolved", "module", m, "err", err)
+	rg.failures.Store(m, err)
+	return &requirementGraphReqs{
+		d: mapset.NewThreadUnsafeSet[Requirement](),
+		i: mapset.NewThreadUnsafeSet[Requirement](),
+	}, nil
+}
+
+func (rg *requirementGraphCompleteState) load(ctx context.Context, mId ModuleId) (*requirementGraphReqs, error) {
 	if err := mId.Check(); err != nil {
 		return nil, err
 	}
+	if rg.isIgnored != nil && rg.isIgnored(mId.Path) && mId != rg.root.Id() {
+		return &requirementGraphReqs{
+			d: mapset.NewThreadUnsafeSet[Requirement](),
+			i: mapset.NewThreadUnsafeSet[Requirement](),
+		}, nil
+	}
+	if mId.IsDevel() {
+		return nil, fmt.Errorf("module %v has no released version and cannot be fetched", mId)
+	}
 	ch := make(chan *loadR)
 	select {
 	case <-ctx.Done():
@@ -151,7 +502,7 @@ func (rg *requirementGraphComplete) load(ctx context.Context, mId ModuleId) (*re
 	}
 	md := r.md
 	if md.Path != mId.Path {
-		return nil, fmt.Errorf("module path mismatch; got %v, want %v", md.Path, mId.Path)
+		return nil, &ModulePathMismatchError{Module: mId, Declared: md.Path}
 	}
 	if md.Version != mId.Version {
 		return nil, fmt.Errorf("module %v version mismatch; got %v, want %v",
@@ -166,6 +517,17 @@ func (rg *requirementGraphComplete) load(ctx context.Context, mId ModuleId) (*re
 	if err != nil {
 		return nil, err
 	}
+	// Go refuses to use a module whose go.mod declares a different path (e.g., after a rename).
+	if goMod.Module != nil && goMod.Module.Mod.Path != mId.Path {
+		return nil, &ModulePathMismatchError{Module: mId, Declared: goMod.Module.Mod.Path}
+	}
+	reqs := goModReqs(goMod)
+	reqs.goModPath = md.GoMod
+	return reqs, nil
+}
+
+// goModReqs returns the requirements listed in the given go.mod.
+func goModReqs(goMod *modfile.File) *requirementGraphReqs {
 	reqs := &requirementGraphReqs{
 		d: mapset.NewThreadUnsafeSet[Requirement](),
 		i: mapset.NewThreadUnsafeSet[Requirement](),
@@ -177,10 +539,11 @@ func (rg *requirementGraphComplete) load(ctx context.Context, mId ModuleId) (*re
 		}
 		rs.Add(requirement{ModuleId{r.Mod}})
 	}
-	return reqs, nil
+	reqs.t = toolReqs(goMod, reqs)
+	return reqs
 }
 
-func (rg *requirementGraphComplete) batchify(ctx context.Context) error {
+func (rg *requirementGraphCompleteState) batchify(ctx context.Context) error {
 	var qCh <-chan *loadQ = rg.qCh
 	batChOrig := make(chan map[ModuleId]*loadQ)
 	var batCh chan<- map[ModuleId]*loadQ
@@ -228,7 +591,7 @@ func (rg *requirementGraphComplete) batchify(ctx context.Context) error {
 	}
 }
 
-func (rg *requirementGraphComplete) loadBatch(ctx context.Context, bat map[ModuleId]*loadQ) {
+func (rg *requirementGraphCompleteState) loadBatch(ctx context.Context, bat map[ModuleId]*loadQ) {
 	defer func() {
 		for mId := range bat {
 			err := fmt.Errorf("batch metadata lookup missing results for %v", mId)
@@ -244,7 +607,23 @@ func (rg *requirementGraphComplete) loadBatch(ctx context.Context, bat map[Modul
 	if len(bat) == 0 {
 		return
 	}
-	lsIter, done := goListM(ctx, "/", slices.Collect(itertools.Stringify(maps.Keys(bat)))...)
+	stats := LoadBatchStats{
+		Start:   time.Now(),
+		Modules: slices.SortedFunc(maps.Keys(bat), ModuleIdCompare),
+	}
+	defer func() {
+		stats.Duration = time.Since(stats.Start)
+		slog.DebugContext(ctx, "module metadata batch lookup finished",
+			"count", len(stats.Modules), "duration", stats.Duration)
+		rg.batchesMu.Lock()
+		defer rg.batchesMu.Unlock()
+		rg.batches = append(rg.batches, stats)
+	}()
+	// The -e option causes `go list` to report a lookup failure for one module in that module's
+	// metadata (rather than failing the entire batch), which allows the error returned from
+	// [RequirementGraph.Load] to identify the problematic module.
+	args := append([]string{"-e"}, slices.Collect(itertools.Stringify(maps.Keys(bat)))...)
+	lsIter, done := goListM(ctx, "/", args...)
 	defer func() {
 		if err := done(); err != nil {
 			slog.ErrorContext(ctx, "`go list -m` failed", "err", err)
@@ -253,11 +632,15 @@ func (rg *requirementGraphComplete) loadBatch(ctx context.Context, bat map[Modul
 	for md := range lsIter {
 		slog.DebugContext(ctx, "read module metadata from Go", "metadata", md)
 		mId := NewModuleId(md.Path, md.Version)
+		if err := md.err(); err != nil {
+			rg.sendResult(mId, bat, &loadR{err: err})
+			continue
+		}
 		rg.sendResult(mId, bat, &loadR{md: md})
 	}
 }
 
-func (rg *requirementGraphComplete) sendResult(mId ModuleId, bat map[ModuleId]*loadQ, r *loadR) {
+func (rg *requirementGraphCompleteState) sendResult(mId ModuleId, bat map[ModuleId]*loadQ, r *loadR) {
 	q := bat[mId]
 	delete(bat, mId)
 	if q == nil {
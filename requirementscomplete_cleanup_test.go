@@ -0,0 +1,50 @@
+package gomoddepgraph
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRequirementsComplete_LeakedGraphShutsDown(t *testing.T) {
+	t.Parallel()
+	st := func() *requirementGraphCompleteState {
+		rg, _ := newRequirementGraphComplete(t.Context(), requirement{NewModuleId("example.com/root", "v1.0.0")})
+		return rg.requirementGraphCompleteState
+	}()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case <-st.shutdown:
+			if err := st.gr.Wait(); err != nil {
+				t.Errorf("background goroutine failed: %v", err)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("leaked graph was not shut down")
+		}
+	}
+}
+
+func TestRequirementsComplete_DoneCallbackKeepsGraphAlive(t *testing.T) {
+	t.Parallel()
+	var st *requirementGraphCompleteState
+	done := func() func() {
+		rg, done := newRequirementGraphComplete(t.Context(), requirement{NewModuleId("example.com/root", "v1.0.0")})
+		st = rg.requirementGraphCompleteState
+		return done
+	}()
+	for range 3 {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case <-st.shutdown:
+		t.Fatal("graph was shut down while its done callback was still reachable")
+	default:
+	}
+	done()
+}
@@ -3,10 +3,19 @@ package gomoddepgraph_test
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
+	"sync"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	. "github.com/rhansen/gomoddepgraph"
+	"github.com/rhansen/gomoddepgraph/internal/command"
 	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
 )
 
@@ -48,3 +57,514 @@ func TestRequirementsComplete_Load_ErrorContextCanceled(t *testing.T) {
 		t.Errorf("got error %q, want %q", got, want)
 	}
 }
+
+func TestRequirementsComplete_Load_ErrorModuleNotFound(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0")},
+	).HTTP().Context()
+	rg, _, err := RequirementsComplete(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rg.Load(ctx, rg.Req(ParseModuleId("example.com/dep@v1.9.9")))
+	if !errors.Is(got, ErrModuleNotFound) {
+		t.Errorf("got error %q, want error wrapping %q", got, ErrModuleNotFound)
+	}
+	want := regexp.MustCompile(`example\.com/dep@v1\.9\.9`)
+	if got == nil || !want.MatchString(got.Error()) {
+		t.Errorf("got error %q, want error matching %q", got, want)
+	}
+	// Modules that exist are unaffected by the failure.
+	if err := rg.Load(ctx, rg.Req(ParseModuleId("example.com/dep@v1.0.0"))); err != nil {
+		t.Errorf("got error %q loading existing module, want nil", err)
+	}
+}
+
+// TestRequirementsComplete_Load_ErrorProxy checks that a proxy failure other than 404 Not Found or
+// 410 Gone is not mistaken for a missing module.
+func TestRequirementsComplete_Load_ErrorProxy(t *testing.T) {
+	t.Parallel()
+	for _, code := range []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusForbidden} {
+		t.Run(strconv.Itoa(code), func(t *testing.T) {
+			t.Parallel()
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, http.StatusText(code), code)
+			}))
+			defer srv.Close()
+			gp := fm.NewTestFakeGoProxy(t)
+			env := append(gp.Context().Value(command.EnvKey).([]string), "GOPROXY="+srv.URL)
+			ctx := context.WithValue(t.Context(), command.EnvKey, env)
+			rg, _, err := RequirementsComplete(ctx, ParseModuleId("example.com/root@v1.0.0"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := rg.Load(ctx, rg.Root())
+			if got == nil {
+				t.Fatalf("Load() succeeded, want error")
+			}
+			if errors.Is(got, ErrModuleNotFound) {
+				t.Errorf("got error %q, want error not wrapping %q", got, ErrModuleNotFound)
+			}
+			want := regexp.MustCompile(`example\.com/root@v1\.0\.0.*` + strconv.Itoa(code))
+			if !want.MatchString(got.Error()) {
+				t.Errorf("got error %q, want error matching %q", got, want)
+			}
+			if _, err := ResolveVersion(ctx, ParseModuleId("example.com/root@latest")); err == nil ||
+				errors.Is(err, ErrModuleNotFound) {
+				t.Errorf("ResolveVersion() got error %v, want error not wrapping %q", err, ErrModuleNotFound)
+			}
+		})
+	}
+}
+
+func TestRequirementsComplete_WithBestEffort(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).Add(fm.Id("example.com/dep@v1.0.0")).HTTP().Context()
+	// The fake proxy refuses to build a module that requires a nonexistent module, so use a local
+	// root module instead.
+	dir := t.TempDir()
+	goMod := "module example.com/root\n\ngo 1.26.0\n\n" +
+		"require (\n\texample.com/dep v1.0.0\n\texample.com/missing v1.0.0\n)\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0666); err != nil {
+		t.Fatal(err)
+	}
+	rootId := NewModuleId("example.com/root", DevelVersion)
+
+	// Strict by default.
+	rg, done, err := RequirementsComplete(ctx, rootId, WithRootDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs, walkDone := AllRequirements(ctx, rg)
+	for range reqs {
+	}
+	if err := walkDone(); !errors.Is(err, ErrModuleNotFound) {
+		t.Errorf("got error %q, want error wrapping %q", err, ErrModuleNotFound)
+	}
+	done()
+
+	rg, done, err = RequirementsComplete(ctx, rootId, WithRootDir(dir), WithBestEffort())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	checkReqGraph(ctx, t, rg, tGraph{
+		"example.com/root@(devel)":   {"example.com/dep@v1.0.0": false, "example.com/missing@v1.0.0": false},
+		"example.com/dep@v1.0.0":     {},
+		"example.com/missing@v1.0.0": {},
+	})
+	missing := rg.Req(ParseModuleId("example.com/missing@v1.0.0"))
+	if !Unresolved(rg, missing) {
+		t.Errorf("%v is not marked unresolved", missing)
+	}
+	if dep := rg.Req(ParseModuleId("example.com/dep@v1.0.0")); Unresolved(rg, dep) {
+		t.Errorf("%v is marked unresolved", dep)
+	}
+	errs := LoadErrors(rg)
+	if len(errs) != 1 || !errors.Is(errs[0], ErrModuleNotFound) {
+		t.Errorf("got errors %q, want one error wrapping %q", errs, ErrModuleNotFound)
+	}
+}
+
+func TestRequirementsComplete_WithMaxDepth(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/c@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/c@v1.1.0")},
+		[]fm.Option{fm.Id("example.com/b@v1.0.0"), fm.Require("example.com/c@v1.1.0", false)},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0"), fm.Require("example.com/b@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/a@v1.0.0", false), fm.Require("example.com/c@v1.0.0", false)},
+	).Context()
+	rootId := ParseModuleId("example.com/root@v1.0.0")
+	for _, tc := range []struct {
+		depth         int
+		want          tGraph
+		wantTruncated []string
+		wantC         string
+	}{
+		{
+			depth:         0,
+			want:          tGraph{"example.com/root@v1.0.0": {}},
+			wantTruncated: []string{"example.com/root@v1.0.0"},
+		},
+		{
+			depth: 2,
+			want: tGraph{
+				"example.com/root@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/c@v1.0.0": false},
+				"example.com/a@v1.0.0":    {"example.com/b@v1.0.0": false},
+				"example.com/b@v1.0.0":    {},
+				"example.com/c@v1.0.0":    {},
+			},
+			wantTruncated: []string{"example.com/b@v1.0.0"},
+			wantC:         "example.com/c@v1.0.0",
+		},
+		{
+			depth: 3,
+			want: tGraph{
+				"example.com/root@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/c@v1.0.0": false},
+				"example.com/a@v1.0.0":    {"example.com/b@v1.0.0": false},
+				"example.com/b@v1.0.0":    {"example.com/c@v1.1.0": false},
+				"example.com/c@v1.0.0":    {},
+				"example.com/c@v1.1.0":    {},
+			},
+			wantTruncated: []string{"example.com/c@v1.1.0"},
+			wantC:         "example.com/c@v1.1.0",
+		},
+	} {
+		t.Run(strconv.Itoa(tc.depth), func(t *testing.T) {
+			t.Parallel()
+			rg, done, err := RequirementsComplete(ctx, rootId, WithMaxDepth(tc.depth))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer done()
+			checkReqGraph(ctx, t, rg, tc.want)
+			for n := range tc.want {
+				want := slices.Contains(tc.wantTruncated, n)
+				if got := Truncated(rg, rg.Req(ParseModuleId(n))); got != want {
+					t.Errorf("Truncated(%v) = %v, want %v", n, got, want)
+				}
+			}
+			dg, err := ResolveMvs(ctx, rg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if c := dg.Selected(ParseModuleId("example.com/c@v1.0.0")); c != nil {
+				got = c.String()
+			}
+			if got != tc.wantC {
+				t.Errorf("got selected %q, want %q", got, tc.wantC)
+			}
+		})
+	}
+	if _, err := RequirementsGo(ctx, rootId, WithMaxDepth(1)); err == nil {
+		t.Error("RequirementsGo accepted WithMaxDepth, want error")
+	}
+	if _, _, err := RequirementsComplete(ctx, rootId, WithMaxDepth(-1)); err == nil {
+		t.Error("got nil error for negative depth, want error")
+	}
+}
+
+func TestRequirementsComplete_WithMaxNodes(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/c@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/c@v1.1.0")},
+		[]fm.Option{fm.Id("example.com/b@v1.0.0"), fm.Require("example.com/c@v1.1.0", false)},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0"), fm.Require("example.com/b@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/a@v1.0.0", false), fm.Require("example.com/c@v1.0.0", false)},
+	).Context()
+	rootId := ParseModuleId("example.com/root@v1.0.0")
+	for _, tc := range []struct {
+		n       int
+		wantErr bool
+	}{
+		{n: 5},
+		{n: 3, wantErr: true},
+	} {
+		t.Run(strconv.Itoa(tc.n), func(t *testing.T) {
+			t.Parallel()
+			rg, done, err := RequirementsComplete(ctx, rootId, WithMaxNodes(tc.n))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer done()
+			_, err = ResolveMvs(ctx, rg)
+			if got := errors.Is(err, ErrTooManyNodes); got != tc.wantErr {
+				t.Errorf("got error %v, want ErrTooManyNodes: %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	if _, err := RequirementsGo(ctx, rootId, WithMaxNodes(1)); !errors.Is(err, ErrTooManyNodes) {
+		t.Errorf("RequirementsGo got error %v, want ErrTooManyNodes", err)
+	}
+	if _, _, err := RequirementsComplete(ctx, rootId, WithMaxNodes(0)); err == nil {
+		t.Error("got nil error for zero node count, want error")
+	}
+}
+
+func TestWithIgnoredPaths(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/d@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/c@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/sdk/b@v1.0.0"), fm.Require("example.com/d@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0"),
+			fm.Require("example.com/sdk/b@v1.0.0", false), fm.Require("example.com/c@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/a@v1.0.0", false)},
+	).Context()
+	rootId := ParseModuleId("example.com/root@v1.0.0")
+	want := tGraph{
+		"example.com/root@v1.0.0":  {"example.com/a@v1.0.0": false},
+		"example.com/a@v1.0.0":     {"example.com/sdk/b@v1.0.0": false, "example.com/c@v1.0.0": false},
+		"example.com/sdk/b@v1.0.0": {},
+		"example.com/c@v1.0.0":     {},
+	}
+	t.Run("RequirementsComplete", func(t *testing.T) {
+		t.Parallel()
+		rg, done, err := RequirementsComplete(ctx, rootId, WithIgnoredPaths("example.com/sdk"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer done()
+		checkReqGraph(ctx, t, rg, want)
+	})
+	t.Run("RequirementsGo", func(t *testing.T) {
+		t.Parallel()
+		rg, err := RequirementsGo(ctx, rootId, WithIgnoredPaths("example.com/sdk"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkReqGraph(ctx, t, rg, want)
+		if _, err := ResolveGo(ctx, rg); err == nil {
+			t.Error("ResolveGo accepted a graph built with WithIgnoredPaths, want error")
+		}
+	})
+	t.Run("IgnoredRoot", func(t *testing.T) {
+		t.Parallel()
+		rg, done, err := RequirementsComplete(ctx, rootId, WithIgnoredPaths("example.com/*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer done()
+		checkReqGraph(ctx, t, rg, tGraph{
+			"example.com/root@v1.0.0": {"example.com/a@v1.0.0": false},
+			"example.com/a@v1.0.0":    {},
+		})
+	})
+	if _, _, err := RequirementsComplete(ctx, rootId, WithIgnoredPaths("a,b")); err == nil {
+		t.Error("got nil error for pattern containing a comma, want error")
+	}
+}
+
+func TestRequirementsCompleteMulti(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/d@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/d@v1.1.0")},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0"), fm.Require("example.com/d@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/b@v1.0.0"), fm.Require("example.com/d@v1.1.0", false)},
+	).Context()
+	rg, done, err := RequirementsCompleteMulti(ctx,
+		ParseModuleId("example.com/a@v1.0.0"), ParseModuleId("example.com/b@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	if got, want := rg.Root().Id(), MultiRootId; got != want {
+		t.Errorf("got root %v, want %v", got, want)
+	}
+	checkReqGraph(ctx, t, rg, tGraph{
+		MultiRootId.String():   {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/d@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/d@v1.1.0": false},
+		"example.com/d@v1.0.0": {},
+		"example.com/d@v1.1.0": {},
+	})
+	dg, err := ResolveMvs(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dg.Selected(ParseModuleId("example.com/d@v1.0.0")).String(), "example.com/d@v1.1.0"; got != want {
+		t.Errorf("got selected %v, want %v", got, want)
+	}
+}
+
+func TestRequirementsComplete_Load_ErrorModulePathMismatch(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/old@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/old@v1.0.0", false)},
+	)
+	// Simulate a renamed module by rewriting the go.mod served by the proxy.
+	goMod := filepath.Join(gp.Dir(), "example.com/old/@v/v1.0.0.mod")
+	if err := os.WriteFile(goMod, []byte("module example.com/new\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	ctx := gp.Context()
+	rg, done, err := RequirementsComplete(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	got := rg.Load(ctx, rg.Req(ParseModuleId("example.com/old@v1.0.0")))
+	if !errors.Is(got, ErrModulePathMismatch) {
+		t.Fatalf("got error %q, want error wrapping %q", got, ErrModulePathMismatch)
+	}
+	var mErr *ModulePathMismatchError
+	if !errors.As(got, &mErr) {
+		t.Fatalf("got error %q, want a *ModulePathMismatchError", got)
+	}
+	if want := ParseModuleId("example.com/old@v1.0.0"); mErr.Module != want {
+		t.Errorf("got module %v, want %v", mErr.Module, want)
+	}
+	if want := "example.com/new"; mErr.Declared != want {
+		t.Errorf("got declared path %q, want %q", mErr.Declared, want)
+	}
+}
+
+func TestRequirementsFromGoMod(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/d@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/i@v1.0.0")},
+	).Context()
+	for _, tc := range []struct {
+		desc   string
+		prefix string
+		want   string
+	}{
+		{desc: "devel", want: "example.com/root@" + DevelVersion},
+		{desc: "version comment", prefix: "//version:v1.2.3\n", want: "example.com/root@v1.2.3"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			goMod := tc.prefix + `module example.com/root
+
+go 1.26.0
+
+require example.com/d v1.0.0
+
+require example.com/i v1.0.0 // indirect
+`
+			rg, done, err := RequirementsFromGoMod(ctx, []byte(goMod))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer done()
+			checkReqGraph(ctx, t, rg, tGraph{
+				tc.want:                {"example.com/d@v1.0.0": false, "example.com/i@v1.0.0": true},
+				"example.com/d@v1.0.0": {},
+				"example.com/i@v1.0.0": {},
+			})
+		})
+	}
+	if _, _, err := RequirementsFromGoMod(ctx, []byte("go 1.26.0\n")); err == nil {
+		t.Error("got nil error for go.mod without a module directive, want error")
+	}
+}
+
+func TestLoadBatches(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/dep@v1.0.0", false)},
+	).Context()
+	rg, done, err := RequirementsComplete(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	reqs, walkDone := AllRequirements(ctx, rg)
+	for range reqs {
+	}
+	if err := walkDone(); err != nil {
+		t.Fatal(err)
+	}
+	// A batch's statistics are recorded after its results are delivered, so wait for the background
+	// goroutines to finish.
+	done()
+	got := map[ModuleId]bool{}
+	for _, b := range LoadBatches(rg) {
+		if b.Duration <= 0 {
+			t.Errorf("batch %v has non-positive duration %v", b.Modules, b.Duration)
+		}
+		for _, mId := range b.Modules {
+			got[mId] = true
+		}
+	}
+	want := map[ModuleId]bool{
+		ParseModuleId("example.com/root@v1.0.0"): true,
+		ParseModuleId("example.com/dep@v1.0.0"):  true,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected batched modules (-want +got):\n%s", diff)
+	}
+}
+
+func TestRequirementsComplete_Load_Concurrent(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/a@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/b@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/a@v1.0.0", false), fm.Require("example.com/b@v1.0.0", false)},
+	).Context()
+	rg, done, err := RequirementsComplete(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	a := rg.Req(ParseModuleId("example.com/a@v1.0.0"))
+	b := rg.Req(ParseModuleId("example.com/b@v1.0.0"))
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	const n = 200
+	errs := make([]error, 2*n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() { errs[i] = rg.Load(ctx, a) })
+		// Mix in loads that fail due to a canceled context.  They must not affect the other callers.
+		bCtx := ctx
+		if i%4 == 0 {
+			bCtx = canceled
+		}
+		wg.Go(func() {
+			err := rg.Load(bCtx, b)
+			if bCtx == canceled && errors.Is(err, context.Canceled) {
+				err = nil
+			}
+			errs[n+i] = err
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+	// A batch's statistics are recorded after its results are delivered, so wait for the background
+	// goroutines to finish.
+	done()
+	got := 0
+	for _, bat := range LoadBatches(rg) {
+		for _, mId := range bat.Modules {
+			if mId == a.Id() {
+				got++
+			}
+		}
+	}
+	if want := 1; got != want {
+		t.Errorf("%v was loaded %v times, want %v", a, got, want)
+	}
+}
+
+func TestRequirementsComplete_UnreachableRequirements(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/other@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"), fm.Require("example.com/dep@v1.0.0", false)},
+	).Context()
+	rg, done, err := RequirementsComplete(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	if err := rg.Load(ctx, rg.Req(ParseModuleId("example.com/other@v1.0.0"))); err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnreachableRequirements(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].String() != "example.com/other@v1.0.0" {
+		t.Errorf("got unreachable requirements %v, want [example.com/other@v1.0.0]", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"strings"
 	"sync"
 
@@ -18,6 +19,21 @@ import (
 // mod graph`.
 type requirementGraphGo struct {
 	requirementGraph
+	// rootDir is the directory passed to [WithRootDir], if any.
+	rootDir string
+	// filtered is true if requirements reported by `go mod graph` were dropped (see
+	// [WithIgnoredPaths]), so the graph no longer matches Go's selection.
+	filtered bool
+	// mainModule is the value passed to [AsMainModule].
+	mainModule bool
+	// vendored is true if the root module is built in vendor mode (see [WithModFlag]), in which case
+	// the graph was read from vendor/modules.txt instead of `go mod graph`.
+	vendored bool
+	// modFlag is the -mod flag passed to the `go` commands run in the root module's filtered clone
+	// (see requirementsConfig.effectiveModFlag).
+	modFlag string
+	// privateEnv holds the environment variable settings from [WithPrivateModules].
+	privateEnv []string
 }
 
 var _ RequirementGraph = (*requirementGraphGo)(nil)
@@ -26,47 +42,92 @@ var _ RequirementGraph = (*requirementGraphGo)(nil)
 // the processed output of the `go mod graph` command run in a directory containing the extracted
 // contents of the root module, except any go.mod directives that might affect the requirement graph
 // are ignored (specifically, [replace] and [exclude]).  Go 1.25 produces a [pruned] transitive
-// closure.
+// closure.  Pass [AsMainModule] to honor those directives.
+//
+// A root module downloaded from the [module proxy] never has a vendor directory, but a
+// [DevelVersion] root module read from a local directory (see [WithRootDir]) might.  If Go would
+// build such a module in vendor mode, the graph reflects vendor/modules.txt instead of the go.mod
+// files of the dependencies; see [WithModFlag].
+//
+// Pass [WithCompleteGraph] to reuse an existing [RequirementsComplete] graph.  If the root module's
+// version is [DevelVersion], [WithRootDir] is required; the root module's go.mod and go.sum are
+// read from that directory.
 //
 // [replace]: https://go.dev/ref/mod#go-mod-file-replace
 // [exclude]: https://go.dev/ref/mod#go-mod-file-exclude
 // [pruned]: https://go.dev/ref/mod#graph-pruning
-func RequirementsGo(ctx context.Context, rootId ModuleId) (_ RequirementGraph, retErr error) {
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+func RequirementsGo(ctx context.Context, rootId ModuleId, opts ...RequirementsOption) (_ RequirementGraph, retErr error) {
 	if err := rootId.Check(); err != nil {
 		return nil, err
 	}
+	cfg, err := newRequirementsConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.maxDepth >= 0 {
+		return nil, fmt.Errorf("WithMaxDepth is not supported by RequirementsGo")
+	}
+	ctx = withEnv(ctx, cfg.privateEnv...)
+	if vendored, err := cfg.vendored(ctx, rootId); err != nil {
+		return nil, err
+	} else if vendored {
+		return requirementsVendor(ctx, rootId, cfg)
+	}
+	ctx = cfg.withModFlag(ctx)
 
 	// "go mod graph" does not report whether the requirement has an "// indirect" comment or not, so
 	// we have to parse the node's go.mod to get that information.  Reuse [RequirementsComplete] for
 	// this purpose.
-	//
-	// TODO: Maybe take an optional *requirementGraphComplete as an argument so that the requirement
-	// lookup results can be reused?  That uglies the API though.
-	crg, cancel, err := RequirementsComplete(ctx, rootId)
-	if err != nil {
-		return nil, err
+	crg := cfg.complete
+	if crg == nil {
+		var cancel func()
+		if crg, cancel, err = RequirementsComplete(ctx, rootId, opts...); err != nil {
+			return nil, err
+		}
+		defer cancel()
+	} else if got := crg.Root().Id(); got != rootId {
+		return nil, fmt.Errorf("WithCompleteGraph graph root %v does not match root module %v", got, rootId)
 	}
-	defer cancel()
-	isIndirect := func(pId, mId ModuleId) (bool, error) {
+	// replaced holds the modules replaced by the root module (see [AsMainModule]).  A replacement
+	// of every version of a module is keyed by the module path with an empty version.
+	replaced := map[ModuleId]bool{}
+	// isIndirect also reports whether the requirement provides a tool (see [IsTool]).
+	isIndirect := func(pId, mId ModuleId) (bool, bool, error) {
+		if replaced[pId] || replaced[NewModuleId(pId.Path, "")] {
+			// The requirements come from the replacement's go.mod, not from pId's go.mod.
+			return false, false, nil
+		}
 		p := crg.Req(pId)
 		m := crg.Req(mId)
 		if err := crg.Load(ctx, p); err != nil {
-			return false, err
+			return false, false, err
 		}
 		reqs := crg.(*requirementGraphComplete).reqs(p)
 		ind := reqs.i.Contains(m)
 		if !ind && !reqs.d.Contains(m) {
-			return false, fmt.Errorf(
+			if cfg.unlisted != nil {
+				cfg.unlisted(pId, mId)
+				return false, false, nil
+			}
+			return false, false, fmt.Errorf(
 				"\"go mod graph\" returned a requirement not listed in go.mod: %v -> %v", pId, mId)
 		}
-		return ind, nil
+		return ind, reqs.t != nil && reqs.t.Contains(m), nil
 	}
 
 	var (
 		mu sync.Mutex
-		rg = &requirementGraphGo{requirementGraph{reqs: map[Requirement]*requirementGraphReqs{}}}
+		rg = &requirementGraphGo{
+			requirementGraph: requirementGraph{reqs: map[Requirement]*requirementGraphReqs{}},
+			rootDir:          cfg.rootDir,
+			filtered:         cfg.ignored != "",
+			mainModule:       cfg.mainModule,
+			modFlag:          cfg.effectiveModFlag(ctx),
+			privateEnv:       cfg.privateEnv,
+		}
 	)
-	tmp, done, err := tempFilteredModClone(ctx, rootId)
+	tmp, done, err := rootClone(ctx, rootId, cfg.rootDir, cfg.mainModule)
 	if err != nil {
 		return nil, err
 	}
@@ -75,11 +136,20 @@ func RequirementsGo(ctx context.Context, rootId ModuleId) (_ RequirementGraph, r
 			retErr = err
 		}
 	}()
-	args := []string{"go", "mod", "graph"}
+	if cfg.mainModule {
+		goMod, err := readMainGoMod(filepath.Join(tmp, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range goMod.Replace {
+			replaced[NewModuleId(r.Old.Path, r.Old.Version)] = true
+		}
+	}
+	args := []string{goBin(ctx), "mod", "graph"}
 	if slog.Default().Enabled(ctx, logging.LevelVerbose) {
 		args = append(args, "-x")
 	}
-	cmd, out, err := command.Pipe(ctx, tmp, args...)
+	out, wait, err := command.Pipe(WithoutWorkspace(ctx), tmp, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -88,7 +158,7 @@ func RequirementsGo(ctx context.Context, rootId ModuleId) (_ RequirementGraph, r
 	for scn.Scan() {
 		line := scn.Text()
 		slog.DebugContext(ctx, "go mod graph output", "line", line)
-		if strings.HasPrefix(line, "go@") {
+		if isGoPseudoModule(line) {
 			continue
 		}
 		gr.Go(func() error {
@@ -103,17 +173,20 @@ func RequirementsGo(ctx context.Context, rootId ModuleId) (_ RequirementGraph, r
 			if err := pId.Check(); err != nil {
 				return err
 			}
+			if pId != rootId && cfg.isIgnored(pId.Path) {
+				return nil
+			}
 			p := requirement{pId}
 			var m Requirement
-			var ind bool
-			if !strings.HasPrefix(parts[1], "go@") {
+			var ind, tool bool
+			if !isGoPseudoModule(parts[1]) {
 				mId := ParseModuleId(parts[1])
 				if err := mId.Check(); err != nil {
 					return err
 				}
 				m = requirement{mId}
 				var err error
-				if ind, err = isIndirect(pId, mId); err != nil {
+				if ind, tool, err = isIndirect(pId, mId); err != nil {
 					return err
 				}
 			}
@@ -136,6 +209,12 @@ func RequirementsGo(ctx context.Context, rootId ModuleId) (_ RequirementGraph, r
 				} else {
 					rg.reqs[p].d.Add(m)
 				}
+				if tool {
+					if rg.reqs[p].t == nil {
+						rg.reqs[p].t = mapset.NewThreadUnsafeSet[Requirement]()
+					}
+					rg.reqs[p].t.Add(m)
+				}
 			}
 			return nil
 		})
@@ -143,7 +222,7 @@ func RequirementsGo(ctx context.Context, rootId ModuleId) (_ RequirementGraph, r
 	if err := scn.Err(); err != nil {
 		return nil, err
 	}
-	if err := cmd.Wait(); err != nil {
+	if err := wait(); err != nil {
 		return nil, fmt.Errorf("command %q failed: %w", strings.Join(args, " "), err)
 	}
 	if err := gr.Wait(); err != nil {
@@ -152,5 +231,51 @@ func RequirementsGo(ctx context.Context, rootId ModuleId) (_ RequirementGraph, r
 	if rg.root == nil {
 		return nil, fmt.Errorf("`go mod graph` did not output the root node %v", rootId)
 	}
+	if rg.filtered {
+		rg.pruneUnreachable()
+	}
 	return rg, nil
 }
+
+// pruneUnreachable removes the nodes that are not reachable from the root.
+func (rg *requirementGraph) pruneUnreachable() {
+	seen := mapset.NewThreadUnsafeSet(rg.root)
+	q := []Requirement{rg.root}
+	for len(q) > 0 {
+		p := q[0]
+		q = q[1:]
+		for m := range Reqs(rg, p) {
+			if seen.Add(m) {
+				q = append(q, m)
+			}
+		}
+	}
+	for m := range rg.reqs {
+		if !seen.Contains(m) {
+			delete(rg.reqs, m)
+		}
+	}
+}
+
+// rootClone returns a filtered clone of the root module (see tempFilteredModClone), read from
+// rootDir (see [WithRootDir]) if the root module's version is [DevelVersion].  If mainModule is
+// true, the clone keeps the directives that only apply to the main module (see [AsMainModule]).
+func rootClone(ctx context.Context, rootId ModuleId, rootDir string, mainModule bool) (string, func() error, error) {
+	if !rootId.IsDevel() {
+		return tempFilteredModClone(ctx, rootId, mainModule)
+	}
+	if rootDir == "" {
+		return "", func() error { return nil }, fmt.Errorf(
+			"root module %v has no released version; WithRootDir is required", rootId)
+	}
+	return tempFilteredClone(tempDir(ctx), rootId, filepath.Join(rootDir, "go.mod"), rootDir, mainModule)
+}
+
+// isGoPseudoModule reports whether the given "go mod graph" node (or line) is one of the "go" or
+// "toolchain" pseudo-modules that represent a go.mod's [go] and [toolchain] directives.
+//
+// [go]: https://go.dev/ref/mod#go-mod-file-go
+// [toolchain]: https://go.dev/ref/mod#go-mod-file-toolchain
+func isGoPseudoModule(node string) bool {
+	return strings.HasPrefix(node, "go@") || strings.HasPrefix(node, "toolchain@")
+}
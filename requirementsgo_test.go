@@ -1,6 +1,9 @@
 package gomoddepgraph_test
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 
@@ -17,3 +20,293 @@ func TestRequirementsGo_ErrorVersionQuery(t *testing.T) {
 		t.Errorf("got error %q, want error matching %q", got, want)
 	}
 }
+
+func TestRequirementsGo_WithCompleteGraph(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/ind@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/dep@v1.0.0", false),
+			fm.Require("example.com/ind@v1.0.0", true)},
+	).Context()
+	rootId := ParseModuleId("example.com/root@v1.0.0")
+	crg, done, err := RequirementsComplete(ctx, rootId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	rg, err := RequirementsGo(ctx, rootId, WithCompleteGraph(crg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReqGraph(ctx, t, rg, tGraph{
+		"example.com/root@v1.0.0": {"example.com/dep@v1.0.0": false, "example.com/ind@v1.0.0": true},
+		"example.com/dep@v1.0.0":  {},
+		"example.com/ind@v1.0.0":  {},
+	})
+
+	_, got := RequirementsGo(ctx, ParseModuleId("example.com/dep@v1.0.0"), WithCompleteGraph(crg))
+	want := regexp.MustCompile(`does not match root module`)
+	if got == nil || !want.MatchString(got.Error()) {
+		t.Errorf("got error %q, want error matching %q", got, want)
+	}
+}
+
+func TestRequirementsGo_DevelRoot(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/ind@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0"), fm.Require("example.com/ind@v1.0.0", false)},
+	).Context()
+	dir := t.TempDir()
+	goMod := "module example.com/local\n\ngo 1.26.0\n\n" +
+		"require (\n\texample.com/dep v1.0.0\n\texample.com/ind v1.0.0 // indirect\n)\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0666); err != nil {
+		t.Fatal(err)
+	}
+	rootId := NewModuleId("example.com/local", DevelVersion)
+	// -mod=mod lets Go fill in the local module's go.sum.
+	rg, err := RequirementsGo(ctx, rootId, WithRootDir(dir), WithModFlag("mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReqGraph(ctx, t, rg, tGraph{
+		"example.com/local@(devel)": {"example.com/dep@v1.0.0": false, "example.com/ind@v1.0.0": true},
+		"example.com/dep@v1.0.0":    {"example.com/ind@v1.0.0": false},
+		"example.com/ind@v1.0.0":    {},
+	})
+	dg, err := ResolveGo(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDepGraph(t, dg, tGraph{
+		"example.com/local@(devel)": {"example.com/dep@v1.0.0": false},
+		"example.com/dep@v1.0.0":    {"example.com/ind@v1.0.0": false},
+		"example.com/ind@v1.0.0":    {},
+	})
+
+	_, got := RequirementsGo(ctx, rootId)
+	want := regexp.MustCompile(`WithRootDir is required`)
+	if got == nil || !want.MatchString(got.Error()) {
+		t.Errorf("got error %q, want error matching %q", got, want)
+	}
+	_, got = RequirementsGo(ctx, NewModuleId("example.com/other", DevelVersion), WithRootDir(dir))
+	want = regexp.MustCompile(`module path mismatch`)
+	if got == nil || !want.MatchString(got.Error()) {
+		t.Errorf("got error %q, want error matching %q", got, want)
+	}
+}
+
+func TestRequirementsGo_Vendor(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/ind@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0"), fm.Require("example.com/ind@v1.0.0", false)},
+	).Context()
+	// Only the packages of dep are vendored, so ind is absent from vendor/modules.txt.
+	writeRoot := func(t *testing.T, modulesTxt string) string {
+		t.Helper()
+		dir := t.TempDir()
+		goMod := "module example.com/local\n\ngo 1.26.0\n\nrequire example.com/dep v1.0.0\n"
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Mkdir(filepath.Join(dir, "vendor"), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "vendor", "modules.txt"), []byte(modulesTxt), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	dir := writeRoot(t, "# example.com/dep v1.0.0\n## explicit; go 1.26.0\nexample.com/dep\n")
+	rootId := NewModuleId("example.com/local", DevelVersion)
+	for _, tc := range []struct {
+		desc    string
+		opts    []RequirementsOption
+		wantReq tGraph
+		wantDep tGraph
+	}{
+		{
+			desc: "default",
+			wantReq: tGraph{
+				"example.com/local@(devel)": {"example.com/dep@v1.0.0": false},
+				"example.com/dep@v1.0.0":    {},
+			},
+			wantDep: tGraph{
+				"example.com/local@(devel)": {"example.com/dep@v1.0.0": false},
+				"example.com/dep@v1.0.0":    {},
+			},
+		},
+		{
+			desc: "mod",
+			opts: []RequirementsOption{WithModFlag("mod")},
+			wantReq: tGraph{
+				"example.com/local@(devel)": {"example.com/dep@v1.0.0": false},
+				"example.com/dep@v1.0.0":    {"example.com/ind@v1.0.0": false},
+				"example.com/ind@v1.0.0":    {},
+			},
+			wantDep: tGraph{
+				"example.com/local@(devel)": {"example.com/dep@v1.0.0": false},
+				"example.com/dep@v1.0.0":    {"example.com/ind@v1.0.0": false},
+				"example.com/ind@v1.0.0":    {},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			rg, err := RequirementsGo(ctx, rootId, append(tc.opts, WithRootDir(dir))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkReqGraph(ctx, t, rg, tc.wantReq)
+			dg, err := ResolveGo(ctx, rg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDepGraph(t, dg, tc.wantDep)
+		})
+	}
+
+	stale := writeRoot(t, "# example.com/dep v0.9.0\n## explicit; go 1.26.0\nexample.com/dep\n")
+	_, got := RequirementsGo(ctx, rootId, WithRootDir(stale))
+	// The go command explains the inconsistency on stderr.
+	want := regexp.MustCompile(`-mod=vendor`)
+	if got == nil || !want.MatchString(got.Error()) {
+		t.Errorf("got error %q, want error matching %q", got, want)
+	}
+	_, got = RequirementsGo(ctx, ParseModuleId("example.com/dep@v1.0.0"), WithModFlag("vendor"))
+	want = regexp.MustCompile(`never serves vendor directories`)
+	if got == nil || !want.MatchString(got.Error()) {
+		t.Errorf("got error %q, want error matching %q", got, want)
+	}
+	if _, got := RequirementsGo(ctx, rootId, WithModFlag("bogus")); got == nil {
+		t.Errorf("RequirementsGo with an invalid -mod flag succeeded")
+	}
+}
+
+func TestRequirementsGo_AsMainModule(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/c@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/a@v1.1.0"), fm.Require("example.com/c@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/b@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/b@v1.1.0")},
+		[]fm.Option{fm.Id("example.com/r@v1.0.0"), fm.Require("example.com/b@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/d@v1.0.0")},
+	).Context()
+	dir := t.TempDir()
+	goMod := "module example.com/local\n\ngo 1.26.0\n\n" +
+		"require (\n\texample.com/a v1.0.0\n\texample.com/d v1.0.0\n\texample.com/r v1.0.0\n\texample.com/b v1.1.0 // indirect\n)\n\n" +
+		"exclude example.com/b v1.0.0\n\n" +
+		"replace example.com/a v1.0.0 => example.com/a v1.1.0\n\n" +
+		"replace example.com/d => ./d\n"
+	dGoMod := "module example.com/d\n\ngo 1.26.0\n\nrequire example.com/c v1.0.0\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "d"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "d", "go.mod"), []byte(dGoMod), 0666); err != nil {
+		t.Fatal(err)
+	}
+	rootId := NewModuleId("example.com/local", DevelVersion)
+	for _, tc := range []struct {
+		mainModule bool
+		want       tGraph
+	}{
+		{
+			mainModule: false,
+			want: tGraph{
+				"example.com/local@(devel)": {"example.com/a@v1.0.0": false, "example.com/d@v1.0.0": false, "example.com/r@v1.0.0": false, "example.com/b@v1.1.0": true},
+				"example.com/a@v1.0.0":      {},
+				"example.com/d@v1.0.0":      {},
+				"example.com/r@v1.0.0":      {"example.com/b@v1.0.0": false},
+				"example.com/b@v1.0.0":      {},
+				"example.com/b@v1.1.0":      {},
+			},
+		},
+		{
+			mainModule: true,
+			want: tGraph{
+				"example.com/local@(devel)": {"example.com/a@v1.0.0": false, "example.com/d@v1.0.0": false, "example.com/r@v1.0.0": false, "example.com/b@v1.1.0": true},
+				"example.com/a@v1.0.0":      {"example.com/c@v1.0.0": false},
+				"example.com/d@v1.0.0":      {"example.com/c@v1.0.0": false},
+				"example.com/c@v1.0.0":      {},
+				"example.com/r@v1.0.0":      {},
+				"example.com/b@v1.1.0":      {},
+			},
+		},
+	} {
+		t.Run(fmt.Sprint(tc.mainModule), func(t *testing.T) {
+			t.Parallel()
+			// -mod=mod lets Go fill in the local module's go.sum.
+			rg, err := RequirementsGo(ctx, rootId, WithRootDir(dir), AsMainModule(tc.mainModule),
+				WithModFlag("mod"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkReqGraph(ctx, t, rg, tc.want)
+			dg, err := ResolveGo(ctx, rg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dg.Selected(ParseModuleId("example.com/c@v1.0.0")) != nil; got != tc.mainModule {
+				t.Errorf("got example.com/c selected %v, want %v", got, tc.mainModule)
+			}
+		})
+	}
+}
+
+func TestRequirementsGo_OmitsGoPseudoModules(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.GoModData([]byte(`//version:v1.0.0
+module example.com/root
+
+go 1.26.0
+
+toolchain go1.26.1
+
+require example.com/dep v1.0.0
+`))},
+	).Context()
+	rootId := ParseModuleId("example.com/root@v1.0.0")
+	want := tGraph{
+		"example.com/root@v1.0.0": {"example.com/dep@v1.0.0": false},
+		"example.com/dep@v1.0.0":  {},
+	}
+	crg, done, err := RequirementsComplete(ctx, rootId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	checkReqGraph(ctx, t, crg, want)
+	rg, err := RequirementsGo(ctx, rootId, WithCompleteGraph(crg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReqGraph(ctx, t, rg, want)
+}
+
+func TestCrossCheck(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/ind@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0"), fm.Require("example.com/ind@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/dep@v1.0.0", false),
+			fm.Require("example.com/ind@v1.0.0", true)},
+	).Context()
+	got, err := CrossCheck(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got discrepancies %v, want none", got)
+	}
+}
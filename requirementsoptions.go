@@ -0,0 +1,276 @@
+package gomoddepgraph
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+type requirementsConfig struct {
+	complete   RequirementGraph
+	rootDir    string
+	bestEffort bool
+	// maxDepth is negative if unlimited.
+	maxDepth int
+	// maxNodes is 0 if unlimited.
+	maxNodes int
+	// unlisted, if non-nil, is called by [RequirementsGo] for each requirement reported by `go mod
+	// graph` that is not listed in the requiring module's go.mod, instead of failing.
+	unlisted func(p, m ModuleId)
+	// ignored is the comma-separated list of module path patterns passed to [WithIgnoredPaths].
+	ignored string
+	// mainModule is the value passed to [AsMainModule].
+	mainModule bool
+	// modFlag is the value passed to [WithModFlag], or the empty string if none.
+	modFlag string
+	// privateEnv holds the environment variable settings from [WithPrivateModules].
+	privateEnv []string
+}
+
+// isIgnored reports whether the requirements of the module with the given path are to be ignored
+// (see [WithIgnoredPaths]).
+func (cfg *requirementsConfig) isIgnored(path string) bool {
+	return cfg.ignored != "" && module.MatchPrefixPatterns(cfg.ignored, path)
+}
+
+// A RequirementsOption customizes the construction of a [RequirementGraph].  Each option documents
+// which constructors honor it.
+type RequirementsOption func(*requirementsConfig) error
+
+func newRequirementsConfig(opts []RequirementsOption) (*requirementsConfig, error) {
+	cfg := &requirementsConfig{maxDepth: -1}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// WithCompleteGraph returns a [RequirementsOption] for [RequirementsGo] that reuses the given graph
+// (which must have been returned from [RequirementsComplete] for the same root module) instead of
+// constructing a new one.  [RequirementsGo] uses a [RequirementsComplete] graph internally to
+// determine whether each requirement is marked "// indirect", so callers that already have such a
+// graph can avoid duplicate go.mod downloads and processing.  The caller remains responsible for
+// calling the graph's done callback, which must not be called until [RequirementsGo] returns.
+func WithCompleteGraph(crg RequirementGraph) RequirementsOption {
+	return func(cfg *requirementsConfig) error {
+		if _, ok := crg.(*requirementGraphComplete); !ok {
+			return fmt.Errorf("graph passed to WithCompleteGraph is not from RequirementsComplete")
+		}
+		cfg.complete = crg
+		return nil
+	}
+}
+
+// WithRootDir returns a [RequirementsOption] for [RequirementsComplete] and [RequirementsGo] that
+// reads the root module's go.mod (and go.sum, for [RequirementsGo]) from the given local directory
+// instead of fetching the root module from the [module proxy].  It is required if the root module's
+// version is [DevelVersion] and ignored otherwise.  The module path declared in the go.mod must
+// match the root module's path.
+//
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+func WithRootDir(dir string) RequirementsOption {
+	return func(cfg *requirementsConfig) error {
+		if dir == "" {
+			return fmt.Errorf("empty directory passed to WithRootDir")
+		}
+		cfg.rootDir = dir
+		return nil
+	}
+}
+
+// WithBestEffort returns a [RequirementsOption] for [RequirementsComplete] that makes
+// [RequirementGraph.Load] succeed even if a module's metadata cannot be fetched or parsed (e.g.,
+// the version does not exist).  Such a module is treated as having no requirements; use
+// [Unresolved] to identify it and [LoadErrors] to retrieve the collected errors.  This trades
+// correctness for the ability to analyze most of a large graph despite a few broken modules.
+// Errors caused by context cancelation or graph shutdown are still returned from
+// [RequirementGraph.Load].
+func WithBestEffort() RequirementsOption {
+	return func(cfg *requirementsConfig) error {
+		cfg.bestEffort = true
+		return nil
+	}
+}
+
+// WithMaxDepth returns a [RequirementsOption] for [RequirementsComplete] that limits the graph to
+// the modules within n requirement edges of the root module (the root module is at depth 0).  The
+// modules at depth n are included but their requirements are not fetched; they are reported as
+// having no requirements and [Truncated] reports true for them.  The graph is built eagerly, so
+// [RequirementsComplete] does not return until every module above depth n has been loaded.
+//
+// This trades completeness for speed.  [ResolveMvs] and [ResolveSat] work on a truncated graph,
+// but because requirements below the cutoff are unknown, the selected versions might be lower than
+// those selected from the complete graph, and some modules might be missing.  [RequirementsGo]
+// needs the complete graph and rejects this option.
+func WithMaxDepth(n int) RequirementsOption {
+	return func(cfg *requirementsConfig) error {
+		if n < 0 {
+			return fmt.Errorf("negative depth passed to WithMaxDepth: %v", n)
+		}
+		cfg.maxDepth = n
+		return nil
+	}
+}
+
+// ErrTooManyNodes is wrapped by the error returned from [RequirementGraph.Load] when loading a
+// module would exceed the limit set by [WithMaxNodes].
+var ErrTooManyNodes = errors.New("too many modules in requirement graph")
+
+// WithMaxNodes returns a [RequirementsOption] for [RequirementsComplete] and [RequirementsGo] that
+// limits the number of modules that can be loaded (see [RequirementGraph.Load]) to n.  Once n
+// modules have been loaded, loading any other module fails with an error wrapping
+// [ErrTooManyNodes], so operations that walk the graph (such as the resolvers) abort instead of
+// consuming all available memory.  This is a guardrail for analyzing untrusted or unexpectedly huge
+// modules, such as in a hosted service.  For [RequirementsGo], the limit applies to the go.mod
+// files read to classify each requirement, which are read via a [RequirementsComplete] graph.
+//
+// Unlike [WithMaxDepth], the limit does not produce a partial graph; the error is not suppressed by
+// [WithBestEffort].
+func WithMaxNodes(n int) RequirementsOption {
+	return func(cfg *requirementsConfig) error {
+		if n < 1 {
+			return fmt.Errorf("non-positive node count passed to WithMaxNodes: %v", n)
+		}
+		cfg.maxNodes = n
+		return nil
+	}
+}
+
+// WithIgnoredPaths returns a [RequirementsOption] for [RequirementsComplete] and [RequirementsGo]
+// that treats every module (other than the root module) whose path matches one of the given
+// patterns as a leaf node:  the module is in the graph, but its requirements are not fetched and it
+// is reported as having none.  Each pattern is a glob pattern matched against module path prefixes,
+// like the patterns in GOPRIVATE (see [PrivateModules]); for example, "example.com/sdk" matches
+// both "example.com/sdk" and "example.com/sdk/service/foo".
+//
+// This is useful for focusing on the rest of the graph when some modules (e.g., a huge SDK) are not
+// of interest, and can shrink the graph dramatically.  The resulting graph is not faithful to Go's
+// actual resolution:  modules that are only required via an ignored module are omitted, and the
+// versions selected by [ResolveMvs] or [ResolveSat] might be lower than Go would select.
+// [ResolveGo] rejects a [RequirementsGo] graph built with this option because Go's own selection
+// would not match the graph.
+func WithIgnoredPaths(patterns ...string) RequirementsOption {
+	return func(cfg *requirementsConfig) error {
+		for _, p := range patterns {
+			if p == "" || strings.Contains(p, ",") {
+				return fmt.Errorf("invalid pattern passed to WithIgnoredPaths: %q", p)
+			}
+		}
+		cfg.ignored = strings.Join(slices.DeleteFunc(
+			append(strings.Split(cfg.ignored, ","), patterns...),
+			func(p string) bool { return p == "" }), ",")
+		return nil
+	}
+}
+
+// AsMainModule returns a [RequirementsOption] for [RequirementsGo] that controls whether the root
+// module's [replace] and [exclude] directives are honored.  Those directives only take effect when
+// the module is the [main module], so by default (false) they are ignored and the graph shows how
+// the root module contributes to a build as a dependency of some other module.  Passing true shows
+// how Go builds the root module as the main module instead, which is how its developers see it.
+// Compare the two to see the effect of the directives.
+//
+// When true, [ResolveGo] resolves the graph with the directives in effect as well.  Replaced
+// modules keep their original [ModuleId] in the graph, but their requirements are those of the
+// replacement.  The replacement's go.mod is not read, so all of a replaced module's requirements
+// are reported as direct requirements.  A replacement directory given as a relative path is
+// resolved against the root module's directory (see [WithRootDir]); such directories usually only
+// exist next to a local checkout, not next to a copy of the module downloaded from the
+// [module proxy].
+//
+// [replace]: https://go.dev/ref/mod#go-mod-file-replace
+// [exclude]: https://go.dev/ref/mod#go-mod-file-exclude
+// [main module]: https://go.dev/ref/mod#glos-main-module
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+func AsMainModule(on bool) RequirementsOption {
+	return func(cfg *requirementsConfig) error {
+		cfg.mainModule = on
+		return nil
+	}
+}
+
+// WithModFlag returns a [RequirementsOption] for [RequirementsGo] that sets the `go` command's
+// [-mod flag] for the root module to mode, which must be "mod", "readonly", or "vendor".  Without
+// this option the flag is taken from GOFLAGS, and if GOFLAGS does not set it either, Go's default
+// applies:  "vendor" if the root module is read from a local directory (see [WithRootDir]) that has
+// a vendor directory and its go.mod's go directive is 1.14 or higher, "readonly" otherwise.
+//
+// In vendor mode Go builds the root module with the modules listed in its vendor/modules.txt file
+// and ignores every other go.mod, so [RequirementsGo] returns a graph in which the root module
+// directly requires each vendored module and the vendored modules have no requirements, and
+// [ResolveGo] selects exactly the vendored modules.  As with `go build`, an error is returned if
+// vendor/modules.txt is inconsistent with the root module's go.mod.  A module downloaded from the
+// [module proxy] never has a vendor directory, so "vendor" is an error unless the root module's
+// version is [DevelVersion].  The other modes are passed through to the `go` commands run for the
+// root module.
+//
+// [RequirementsComplete] reads every go.mod regardless of this option, so its graph does not
+// reflect vendoring; it logs a warning if the root module would be built in vendor mode.
+//
+// [-mod flag]: https://go.dev/ref/mod#build-commands
+// [module proxy]: https://go.dev/ref/mod#module-proxy
+func WithModFlag(mode string) RequirementsOption {
+	return func(cfg *requirementsConfig) error {
+		switch mode {
+		case "mod", "readonly", "vendor":
+		default:
+			return fmt.Errorf("invalid mode passed to WithModFlag: %q", mode)
+		}
+		cfg.modFlag = mode
+		return nil
+	}
+}
+
+// WithPrivateModules returns a [RequirementsOption] for [RequirementsComplete],
+// [RequirementsFromGoMod], [RequirementsGo], and [ResolveVersion] that causes the `go` commands
+// they run to use the given [PrivateModules] settings.  The settings are recorded in the returned
+// [RequirementGraph], so the `go` commands run later for the graph or for a [DependencyGraph]
+// resolved from it (e.g., by [ResolveGo], [ModuleSums], and [DetectLicense]) use them too.
+func WithPrivateModules(pm PrivateModules) RequirementsOption {
+	return func(cfg *requirementsConfig) error {
+		cfg.privateEnv = pm.env()
+		return nil
+	}
+}
+
+// withUnlistedReqs returns a [RequirementsOption] for [RequirementsGo] that calls fn for each
+// requirement that `go mod graph` reports but the requiring module's go.mod does not list, instead
+// of returning an error.  Such requirements are treated as direct requirements.
+func withUnlistedReqs(fn func(p, m ModuleId)) RequirementsOption {
+	return func(cfg *requirementsConfig) error {
+		cfg.unlisted = fn
+		return nil
+	}
+}
+
+// develRootGoMod reads the go.mod of the devel root module rootId from the directory given by
+// [WithRootDir] and returns it along with the path of the file.
+func (cfg *requirementsConfig) develRootGoMod(rootId ModuleId) (*modfile.File, string, error) {
+	if cfg.rootDir == "" {
+		return nil, "", fmt.Errorf("root module %v has no released version; WithRootDir is required", rootId)
+	}
+	goModFile := filepath.Join(cfg.rootDir, "go.mod")
+	goModData, err := os.ReadFile(goModFile)
+	if err != nil {
+		return nil, "", err
+	}
+	goMod, err := modfile.ParseLax(goModFile, goModData, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if goMod.Module == nil {
+		return nil, "", fmt.Errorf("%v: missing module directive", goModFile)
+	}
+	if got := goMod.Module.Mod.Path; got != rootId.Path {
+		return nil, "", fmt.Errorf("%v: %w", cfg.rootDir, &ModulePathMismatchError{Module: rootId, Declared: got})
+	}
+	return goMod, goModFile, nil
+}
@@ -0,0 +1,144 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/rhansen/gomoddepgraph/internal/command"
+	"github.com/rhansen/gomoddepgraph/internal/logging"
+)
+
+type jsonPackage struct {
+	ImportPath string
+	Standard   bool
+	Imports    []string
+	Module     *struct {
+		Path, Version, GoMod string
+		Main                 bool
+		Replace              *struct {
+			Path, Version, Dir string
+		}
+	}
+}
+
+// RequirementsForPackages returns a [RequirementGraph] limited to the modules that provide the
+// packages matched by the given [package patterns] (e.g., "./cmd/server") or any package in their
+// import closure.  The patterns are interpreted relative to dir, which must be inside a local
+// module (the main module).  This corresponds to the package-level "all" rather than the
+// module-level "all" used by the other requirement graph constructors.
+//
+// The graph is derived from the output of `go list -deps -json` run in dir, so dir's go.mod
+// (including any [replace] and [exclude] directives) is honored.  The root [Requirement] is the
+// main module with version [DevelVersion].  Each module's requirements are the modules
+// that provide the packages imported by that module's packages, at the versions Go selected; all
+// requirements are direct requirements.  Consequently, resolving the returned graph selects the
+// same versions that Go selected when building the packages.
+//
+// A module that the main module's go.mod replaces with a local directory (e.g., `replace
+// example.com/m => ../m`) has version [LocalVersion], and [LocalPath] returns the directory for
+// the [Dependency] selected for it.  This is common in monorepos.
+//
+// If the main module has a vendor/modules.txt file and its go directive is 1.14 or higher, Go
+// defaults to [-mod=vendor], so the graph reflects the vendored selection just as `go build` would.
+// To override this, set GOFLAGS (e.g., GOFLAGS=-mod=mod) in the environment passed via the
+// [context.Context].
+//
+// Like `go build`, this honors the [workspace] that dir is in (found by searching dir and its
+// parents for a go.work file, or named by GOWORK), so the versions are those the workspace selects
+// and an error is returned if the packages span more than one workspace module.  Use
+// [WithoutWorkspace] to analyze dir's module on its own.
+//
+// Build constraints are evaluated for the current GOOS and GOARCH with no extra build tags unless
+// overridden with [WithBuildConstraints].
+//
+// [package patterns]: https://pkg.go.dev/cmd/go#hdr-Package_lists_and_patterns
+// [replace]: https://go.dev/ref/mod#go-mod-file-replace
+// [exclude]: https://go.dev/ref/mod#go-mod-file-exclude
+// [-mod=vendor]: https://go.dev/ref/mod#vendoring
+// [workspace]: https://go.dev/ref/mod#workspaces
+func RequirementsForPackages(ctx context.Context, dir string, patterns ...string) (RequirementGraph, error) {
+	args := []string{goBin(ctx), "list", "-deps", "-json"}
+	if slog.Default().Enabled(ctx, logging.LevelVerbose) {
+		args = append(args, "-x")
+	}
+	args = append(args, "--")
+	args = append(args, patterns...)
+	pkgIter, done := command.DecodeJsonStream[*jsonPackage](ctx, dir, args...)
+	pkgs := map[string]*jsonPackage{}
+	for pkg := range pkgIter {
+		slog.DebugContext(ctx, "read package metadata from Go", "package", pkg.ImportPath)
+		pkgs[pkg.ImportPath] = pkg
+	}
+	if err := done(); err != nil {
+		return nil, err
+	}
+
+	rg := &requirementGraph{
+		reqs:   map[Requirement]*requirementGraphReqs{},
+		source: RequirementSourcePackages,
+	}
+	modReq := func(pkg *jsonPackage) (Requirement, error) {
+		if pkg.Standard || pkg.Module == nil {
+			return nil, nil
+		}
+		mId := NewModuleId(pkg.Module.Path, pkg.Module.Version)
+		// A replacement with no version is a directory in the local filesystem.
+		repl := pkg.Module.Replace
+		local := repl != nil && repl.Version == ""
+		switch {
+		case pkg.Module.Main:
+			mId.Version = DevelVersion
+		case local:
+			mId.Version = LocalVersion
+		}
+		if err := mId.Check(); err != nil {
+			return nil, fmt.Errorf("package %v: %w", pkg.ImportPath, err)
+		}
+		m := Requirement(requirement{mId})
+		if local {
+			m = localRequirement{mId, repl.Dir}
+		}
+		if rg.reqs[m] == nil {
+			rg.reqs[m] = &requirementGraphReqs{
+				d: mapset.NewThreadUnsafeSet[Requirement](),
+				i: mapset.NewThreadUnsafeSet[Requirement](),
+			}
+		}
+		if pkg.Module.Main {
+			if rg.root != nil && rg.root != m {
+				return nil, fmt.Errorf("packages from multiple main modules: %v, %v", rg.root, m)
+			}
+			rg.root = m
+			rg.rootGoMod = pkg.Module.GoMod
+		}
+		return m, nil
+	}
+	for _, pkg := range pkgs {
+		p, err := modReq(pkg)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			continue
+		}
+		for _, imp := range pkg.Imports {
+			ipkg := pkgs[imp]
+			if ipkg == nil {
+				return nil, fmt.Errorf("package %v imports %v, which was not listed", pkg.ImportPath, imp)
+			}
+			m, err := modReq(ipkg)
+			if err != nil {
+				return nil, err
+			}
+			if m != nil && m != p {
+				rg.reqs[p].d.Add(m)
+			}
+		}
+	}
+	if rg.root == nil {
+		return nil, fmt.Errorf("no packages from the main module matched %q", patterns)
+	}
+	return rg, nil
+}
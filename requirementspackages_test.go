@@ -0,0 +1,214 @@
+package gomoddepgraph_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/rhansen/gomoddepgraph"
+	"github.com/rhansen/gomoddepgraph/internal/command"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestRequirementsForPackages(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/b@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/c@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0"), fm.Require("example.com/b@v1.0.0", false)},
+	)
+	// -mod=mod lets Go fill in the local module's go.sum.
+	env := append(gp.Environ(os.Environ()), "GOFLAGS=-mod=mod")
+	ctx := context.WithValue(t.Context(), command.EnvKey, env)
+	dir := t.TempDir()
+	for fn, data := range map[string]string{
+		"go.mod": "module example.com/local\n\ngo 1.26.0\n\n" +
+			"require (\n\texample.com/a v1.0.0\n\texample.com/c v1.0.0\n)\n",
+		"cmd/server/main.go": "package main\n\nimport _ \"example.com/a\"\n\nfunc main() {}\n",
+		"other/other.go":     "package other\n\nimport _ \"example.com/c\"\n",
+	} {
+		fn = filepath.Join(dir, fn)
+		if err := os.MkdirAll(filepath.Dir(fn), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rg, err := RequirementsForPackages(ctx, dir, "./cmd/server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReqGraph(ctx, t, rg, tGraph{
+		"example.com/local@(devel)": {"example.com/a@v1.0.0": false},
+		"example.com/a@v1.0.0":      {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0":      {},
+	})
+}
+
+func TestRequirementsForPackages_LocalReplace(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/b@v1.0.0")},
+	)
+	// -mod=mod lets Go fill in the local module's go.sum.
+	env := append(gp.Environ(os.Environ()), "GOFLAGS=-mod=mod")
+	ctx := context.WithValue(t.Context(), command.EnvKey, env)
+	root := t.TempDir()
+	dir := filepath.Join(root, "main")
+	sibling := filepath.Join(root, "sibling")
+	for fn, data := range map[string]string{
+		"main/go.mod": "module example.com/local\n\ngo 1.26.0\n\n" +
+			"require (\n\texample.com/b v1.0.0\n\texample.com/s v0.0.0\n)\n\n" +
+			"replace example.com/s => ../sibling\n",
+		"main/main.go":   "package main\n\nimport _ \"example.com/s\"\n\nfunc main() {}\n",
+		"sibling/go.mod": "module example.com/s\n\ngo 1.26.0\n\nrequire example.com/b v1.0.0\n",
+		"sibling/s.go":   "package s\n\nimport _ \"example.com/b\"\n",
+	} {
+		fn = filepath.Join(root, fn)
+		if err := os.MkdirAll(filepath.Dir(fn), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rg, err := RequirementsForPackages(ctx, dir, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkReqGraph(ctx, t, rg, tGraph{
+		"example.com/local@(devel)": {"example.com/s@(local)": false},
+		"example.com/s@(local)":     {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0":      {},
+	})
+	urg, err := UnifyRequirements(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct {
+		rg      RequirementGraph
+		resolve func(context.Context, RequirementGraph, ...ResolveOption) (DependencyGraph, error)
+	}{
+		"mvs":         {rg, ResolveMvs},
+		"sat":         {rg, ResolveSat},
+		"unified mvs": {urg, ResolveMvs},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dg, err := tc.resolve(ctx, tc.rg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := dg.Selected(NewModuleId("example.com/s", LocalVersion))
+			if s == nil {
+				t.Fatal("example.com/s is not selected")
+			}
+			if got, ok := LocalPath(s); !ok || got != sibling {
+				t.Errorf("LocalPath(%v) = %q, %v; want %q, true", s, got, ok, sibling)
+			}
+			b := dg.Selected(NewModuleId("example.com/b", "v1.0.0"))
+			if got, ok := LocalPath(b); ok {
+				t.Errorf("LocalPath(%v) = %q, true; want false", b, got)
+			}
+		})
+	}
+}
+
+func TestRequirementsForPackages_Workspace(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/b@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/b@v1.1.0")},
+	)
+	env := gp.Environ(os.Environ())
+	root := t.TempDir()
+	for fn, data := range map[string]string{
+		"go.work":        "go 1.26.0\n\nuse (\n\t./main\n\t./other\n)\n",
+		"main/go.mod":    "module example.com/local\n\ngo 1.26.0\n\nrequire example.com/b v1.0.0\n",
+		"main/main.go":   "package main\n\nimport _ \"example.com/b\"\n\nfunc main() {}\n",
+		"other/go.mod":   "module example.com/other\n\ngo 1.26.0\n\nrequire example.com/b v1.1.0\n",
+		"other/other.go": "package other\n\nimport _ \"example.com/b\"\n",
+	} {
+		fn = filepath.Join(root, fn)
+		if err := os.MkdirAll(filepath.Dir(fn), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Workspace mode rejects -mod=mod, so fill in each module's go.sum up front.
+	tidyCtx := context.WithValue(t.Context(), command.EnvKey, append(env, "GOWORK=off", "GOFLAGS=-mod=mod"))
+	for _, d := range []string{"main", "other"} {
+		if err := command.Run(tidyCtx, filepath.Join(root, d), "go", "mod", "tidy"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.WithValue(t.Context(), command.EnvKey, append(env, "GOFLAGS="))
+	dir := filepath.Join(root, "main")
+	for name, tc := range map[string]struct {
+		ctx  context.Context
+		want string
+	}{
+		"workspace":   {ctx, "example.com/b@v1.1.0"},
+		"noWorkspace": {WithoutWorkspace(ctx), "example.com/b@v1.0.0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rg, err := RequirementsForPackages(tc.ctx, dir, ".")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkReqGraph(tc.ctx, t, rg, tGraph{
+				"example.com/local@(devel)": {tc.want: false},
+				tc.want:                     {},
+			})
+		})
+	}
+}
+
+func TestRequirementsForPackages_BuildConstraints(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/b@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/c@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/d@v1.0.0")},
+	)
+	// -mod=mod lets Go fill in the local module's go.sum.
+	env := append(gp.Environ(os.Environ()), "GOFLAGS=-mod=mod")
+	ctx := context.WithValue(t.Context(), command.EnvKey, env)
+	dir := t.TempDir()
+	for fn, data := range map[string]string{
+		"go.mod": "module example.com/local\n\ngo 1.26.0\n\n" +
+			"require (\n\texample.com/b v1.0.0\n\texample.com/c v1.0.0\n\texample.com/d v1.0.0\n)\n",
+		"main.go":        "package main\n\nfunc main() {}\n",
+		"sys_linux.go":   "package main\n\nimport _ \"example.com/b\"\n",
+		"sys_windows.go": "package main\n\nimport _ \"example.com/c\"\n",
+		"extra.go":       "//go:build extra\n\npackage main\n\nimport _ \"example.com/d\"\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, fn), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, tc := range map[string]struct {
+		ctx  context.Context
+		want []string
+	}{
+		"linux":       {WithBuildConstraints(ctx, "linux", "amd64"), []string{"example.com/b@v1.0.0"}},
+		"windows":     {WithBuildConstraints(ctx, "windows", "amd64"), []string{"example.com/c@v1.0.0"}},
+		"linux+extra": {WithBuildConstraints(ctx, "linux", "", "extra"), []string{"example.com/b@v1.0.0", "example.com/d@v1.0.0"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rg, err := RequirementsForPackages(tc.ctx, dir, ".")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tGraph{"example.com/local@(devel)": {}}
+			for _, m := range tc.want {
+				want["example.com/local@(devel)"][m] = false
+				want[m] = map[string]bool{}
+			}
+			checkReqGraph(tc.ctx, t, rg, want)
+		})
+	}
+}
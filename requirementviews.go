@@ -0,0 +1,45 @@
+package gomoddepgraph
+
+import (
+	"iter"
+)
+
+// DirectOnly returns a view of rg that omits every immediate indirect requirement edge:  its
+// [RequirementGraph.ImmediateIndirectReqs] never yields anything, so walks of the view (e.g.,
+// [WalkRequirementGraph]) only follow direct requirements.  This shows the "intended" requirement
+// structure, as opposed to the full one (see [IncludeIndirect]).
+//
+// The view is a cheap wrapper, not a copy:  every other method, including
+// [RequirementGraph.Load], is delegated to rg, so loading a node through the view loads it in rg.
+// Nodes that are reachable from the root only via indirect requirements are still returned from
+// [RequirementGraph.Req] if rg returns them, but they are not reachable in the view.
+//
+// The view is not a [RequirementsGo] graph, so it cannot be passed to [ResolveGo].
+func DirectOnly(rg RequirementGraph) RequirementGraph {
+	if dv, ok := rg.(*requirementGraphDirectOnly); ok {
+		return dv
+	}
+	return &requirementGraphDirectOnly{rg}
+}
+
+// IncludeIndirect returns a view of rg that includes immediate indirect requirement edges.  It
+// undoes [DirectOnly]:  if rg was returned from [DirectOnly], the graph that was passed to
+// [DirectOnly] is returned.  Otherwise rg itself is returned, since every [RequirementGraph]
+// already includes its indirect requirements.
+func IncludeIndirect(rg RequirementGraph) RequirementGraph {
+	if dv, ok := rg.(*requirementGraphDirectOnly); ok {
+		return dv.RequirementGraph
+	}
+	return rg
+}
+
+// A requirementGraphDirectOnly is the [RequirementGraph] returned from [DirectOnly].
+type requirementGraphDirectOnly struct {
+	RequirementGraph
+}
+
+var _ RequirementGraph = (*requirementGraphDirectOnly)(nil)
+
+func (rg *requirementGraphDirectOnly) ImmediateIndirectReqs(m Requirement) iter.Seq[Requirement] {
+	return func(yield func(Requirement) bool) {}
+}
@@ -3,25 +3,24 @@ package gomoddepgraph
 import (
 	"context"
 	"fmt"
-	"sync"
-
-	mapset "github.com/deckarep/golang-set/v2"
-	"golang.org/x/sync/errgroup"
+	"strings"
 )
 
 // ResolveGo returns a [DependencyGraph] that represents the dependencies reported by running `go
 // list -m all` in the root module.  As of Go 1.25, this is the result of running the [Minimal
 // Version Selection (MVS) algorithm] on a [pruned] requirement graph.
 //
-// The [RequirementGraph] argument must be a graph returned from [RequirementsGo].
+// The [RequirementGraph] argument must be a graph returned from [RequirementsGo].  If that graph
+// was built in vendor mode (see [WithModFlag]), the vendored modules are selected.
 //
 // [Minimal Version Selection (MVS) algorithm]: https://go.dev/ref/mod#minimal-version-selection
 // [pruned]: https://go.dev/ref/mod#graph-pruning
-func ResolveGo(ctx context.Context, rg RequirementGraph) (_ DependencyGraph, retErr error) {
+func ResolveGo(ctx context.Context, rg RequirementGraph, opts ...ResolveOption) (_ DependencyGraph, retErr error) {
 	// Approach:
 	//
 	//   1. Create a temporary dummy module.
-	//   2. Copy the root module's go.mod, dropping non-requirement directives.
+	//   2. Copy the root module's go.mod, dropping non-requirement directives (unless the graph was
+	//      built with [AsMainModule]).
 	//   3. Run "go list -m all" in the dummy module.
 	//   4. Filter out the dummy module from the results.
 	//   5. Add this root module to the results.
@@ -37,7 +36,12 @@ func ResolveGo(ctx context.Context, rg RequirementGraph) (_ DependencyGraph, ret
 	// would affect the pruning that is done by Go's graph pruning algorithm, resulting in a different
 	// subgraph for the MVS selection.
 
-	if _, ok := rg.(*requirementGraphGo); !ok {
+	cfg, err := newResolveConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	grg, ok := rg.(*requirementGraphGo)
+	if !ok {
 		// The returned [DependencyGraph] does not use anything other than the [RequirementGraph]
 		// interface (it does not reach into implementation details of the *goRequirementGraph type),
 		// but the requirements must be consistent with what Go selects as reported by `go list -m all`.
@@ -47,8 +51,34 @@ func ResolveGo(ctx context.Context, rg RequirementGraph) (_ DependencyGraph, ret
 		// the output of `go mod graph` is consistent with the output of `go list -m all`.
 		return nil, fmt.Errorf("RequirementGraph passed to ResolveGo is not from RequirementsGo")
 	}
+	if grg.filtered {
+		return nil, fmt.Errorf("RequirementGraph passed to ResolveGo was built with WithIgnoredPaths")
+	}
 	rootId := rg.Root().Id()
-	tmp, tmpDone, err := tempFilteredModClone(ctx, rootId)
+	dg := &dependencyGraph{
+		rg:  rg,
+		sel: map[string]Dependency{},
+		prov: Provenance{
+			Requirements:     requirementSource(rg),
+			Resolver:         "ResolveGo",
+			WithoutSurprises: cfg.withoutSurprises,
+		},
+	}
+	if grg.vendored {
+		// In vendor mode Go builds with exactly the vendored modules.
+		for r := range grg.reqs {
+			dg.sel[r.Id().Path] = dependency{r.Id()}
+		}
+		if err := dg.computeAllSurpriseDeps(ctx); err != nil {
+			return nil, err
+		}
+		return dg, nil
+	}
+	ctx = withEnv(ctx, grg.privateEnv...)
+	if grg.modFlag != "" {
+		ctx = withEnv(ctx, "GOFLAGS="+strings.TrimSpace(getenv(ctx, "GOFLAGS")+" -mod="+grg.modFlag))
+	}
+	tmp, tmpDone, err := rootClone(ctx, rootId, grg.rootDir, grg.mainModule)
 	if err != nil {
 		return nil, err
 	}
@@ -57,17 +87,12 @@ func ResolveGo(ctx context.Context, rg RequirementGraph) (_ DependencyGraph, ret
 			retErr = err
 		}
 	}()
-	lsJson, lsmDone := goListM(ctx, tmp, "all")
+	lsJson, lsmDone := goListM(WithoutWorkspace(ctx), tmp, "all")
 	defer func() {
 		if err := lsmDone(); err != nil {
 			retErr = err
 		}
 	}()
-	dg := &dependencyGraph{
-		rg:       rg,
-		sel:      map[string]Dependency{},
-		surprise: map[Dependency]mapset.Set[Dependency]{},
-	}
 	for md := range lsJson {
 		dId := rootId
 		if md.Path != rootId.Path || md.Version != "" {
@@ -83,25 +108,7 @@ func ResolveGo(ctx context.Context, rg RequirementGraph) (_ DependencyGraph, ret
 		d := dependency{dId}
 		dg.sel[dId.Path] = d
 	}
-	// Compute the set of surprise dependencies for each dependency in the selection set.
-	//
-	// TODO: This implementation is O(|V|*(|V|+|E|)), which can be improved.  However, a more
-	// efficient implementation might be tricky due to possible dependency cycles.
-	var mu sync.Mutex
-	gr, ctx := errgroup.WithContext(ctx)
-	for _, d := range dg.sel {
-		gr.Go(func() error {
-			surprise, err := computeSurpriseDeps(ctx, rg, dg, d)
-			if err != nil {
-				return err
-			}
-			mu.Lock()
-			defer mu.Unlock()
-			dg.surprise[d] = surprise
-			return nil
-		})
-	}
-	if err := gr.Wait(); err != nil {
+	if err := dg.computeAllSurpriseDeps(ctx); err != nil {
 		return nil, err
 	}
 	return dg, nil
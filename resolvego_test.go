@@ -39,3 +39,24 @@ func TestResolveGo_ErrorContextCanceled(t *testing.T) {
 		t.Errorf("got error %q, want %q", got, want)
 	}
 }
+
+func TestVerifyAgainstGo(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/c@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/c@v1.1.0")},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0"), fm.Require("example.com/c@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/b@v1.0.0"), fm.Require("example.com/c@v1.1.0", false)},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/a@v1.0.0", false),
+			fm.Require("example.com/b@v1.0.0", false),
+			fm.Require("example.com/c@v1.1.0", true)},
+	).Context()
+	equal, diff, err := VerifyAgainstGo(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		t.Errorf("ResolveGo and ResolveMvs disagree:\n%s", diff)
+	}
+}
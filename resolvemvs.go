@@ -3,10 +3,6 @@ package gomoddepgraph
 import (
 	"context"
 	"sync"
-
-	mapset "github.com/deckarep/golang-set/v2"
-	"golang.org/x/mod/semver"
-	"golang.org/x/sync/errgroup"
 )
 
 // ResolveMvs performs the [Minimal Version Selection (MVS) algorithm] on the given
@@ -15,20 +11,28 @@ import (
 // if Go's dependency resolution algorithm changes.
 //
 // [Minimal Version Selection (MVS) algorithm]: https://go.dev/ref/mod#minimal-version-selection
-func ResolveMvs(ctx context.Context, rg RequirementGraph) (DependencyGraph, error) {
+func ResolveMvs(ctx context.Context, rg RequirementGraph, opts ...ResolveOption) (DependencyGraph, error) {
+	cfg, err := newResolveConfig(opts)
+	if err != nil {
+		return nil, err
+	}
 	var mu sync.Mutex
 	dg := &dependencyGraph{
-		rg:       rg,
-		sel:      map[string]Dependency{},
-		surprise: map[Dependency]mapset.Set[Dependency]{},
+		rg:  rg,
+		sel: map[string]Dependency{},
+		prov: Provenance{
+			Requirements:     requirementSource(rg),
+			Resolver:         "ResolveMvs",
+			WithoutSurprises: cfg.withoutSurprises,
+		},
 	}
 	if err := WalkRequirementGraph(ctx, rg, rg.Root(),
 		func(ctx context.Context, m Requirement) (bool, error) {
 			mId := m.Id()
 			mu.Lock()
 			defer mu.Unlock()
-			if d := dg.sel[mId.Path]; d == nil || semver.Compare(mId.Version, d.Id().Version) > 0 {
-				d = dependency{mId}
+			if d := dg.sel[mId.Path]; d == nil || versionCompare(mId.Version, d.Id().Version) > 0 {
+				d = newDependency(m)
 				dg.sel[mId.Path] = d
 			}
 			return true, nil
@@ -36,25 +40,33 @@ func ResolveMvs(ctx context.Context, rg RequirementGraph) (DependencyGraph, erro
 		nil); err != nil {
 		return nil, err
 	}
-	// Compute the set of surprise dependencies for each dependency in the selection set.
-	//
-	// TODO: This implementation is O(|V|*(|V|+|E|)), which can be improved.  However, a more
-	// efficient implementation might be tricky due to possible dependency cycles.
-	gr, ctx := errgroup.WithContext(ctx)
-	for _, d := range dg.sel {
-		gr.Go(func() error {
-			surprise, err := computeSurpriseDeps(ctx, rg, dg, d)
-			if err != nil {
-				return err
-			}
-			mu.Lock()
-			defer mu.Unlock()
-			dg.surprise[d] = surprise
-			return nil
-		})
-	}
-	if err := gr.Wait(); err != nil {
+	if err := dg.computeAllSurpriseDeps(ctx); err != nil {
 		return nil, err
 	}
 	return dg, nil
 }
+
+// VerifyAgainstGo builds the [RequirementsGo] graph for the given root module (with the given
+// options), resolves it with both [ResolveGo] and [ResolveMvs], and reports whether the two
+// [DependencyGraph] values are equal (see [EqualDependencyGraphs]).  If they differ, the returned
+// string describes the differences, with "-" marking nodes or edges found only by [ResolveGo] and
+// "+" marking those found only by [ResolveMvs].
+//
+// The two are expected to agree.  A disagreement indicates a bug in [ResolveMvs] or a change in
+// Go's dependency resolution algorithm.
+func VerifyAgainstGo(ctx context.Context, rootId ModuleId, opts ...RequirementsOption) (bool, string, error) {
+	rg, err := RequirementsGo(ctx, rootId, opts...)
+	if err != nil {
+		return false, "", err
+	}
+	goDg, err := ResolveGo(ctx, rg)
+	if err != nil {
+		return false, "", err
+	}
+	mvsDg, err := ResolveMvs(ctx, rg)
+	if err != nil {
+		return false, "", err
+	}
+	equal, diff := EqualDependencyGraphs(goDg, mvsDg)
+	return equal, diff, nil
+}
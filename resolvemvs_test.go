@@ -0,0 +1,87 @@
+package gomoddepgraph_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func BenchmarkResolveMvs(b *testing.B) {
+	for _, shape := range []string{"chain", "fanout"} {
+		for _, n := range []int{10, 100, 500} {
+			b.Run(fmt.Sprintf("%v/%v", shape, n), func(b *testing.B) {
+				gp := fm.NewTestFakeGoProxy(b)
+				var rootId ModuleId
+				switch shape {
+				case "chain":
+					rootId = gp.AddChain("example.com/chain", n)
+				case "fanout":
+					rootId = gp.AddFanOut("example.com/fanout", n)
+				}
+				ctx := gp.Context()
+				rg, done, err := RequirementsComplete(ctx, rootId)
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer done()
+				// Load the whole requirement graph up front so that only resolution is measured.
+				reqs, walkDone := AllRequirements(ctx, rg)
+				for range reqs {
+				}
+				if err := walkDone(); err != nil {
+					b.Fatal(err)
+				}
+				for b.Loop() {
+					if _, err := ResolveMvs(ctx, rg); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestResolve_DevelRootWinsOverReleasedVersion(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/y@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/local@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0"), fm.Require("example.com/local@v1.0.0", false)},
+	).Context()
+	dir := t.TempDir()
+	goMod := "module example.com/local\n\ngo 1.26.0\n\n" +
+		"require (\n\texample.com/dep v1.0.0\n\texample.com/y v1.0.0\n)\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0666); err != nil {
+		t.Fatal(err)
+	}
+	rg, done, err := RequirementsComplete(ctx, NewModuleId("example.com/local", DevelVersion), WithRootDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	want := tGraph{
+		"example.com/local@(devel)": {"example.com/dep@v1.0.0": false, "example.com/y@v1.0.0": false},
+		"example.com/dep@v1.0.0":    {"example.com/local@(devel)": false},
+		"example.com/y@v1.0.0":      {},
+	}
+	for name, resolve := range map[string]func(context.Context, RequirementGraph, ...ResolveOption) (DependencyGraph, error){
+		"mvs": ResolveMvs,
+		"sat": ResolveSat,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dg, err := resolve(ctx, rg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDepGraph(t, dg, want)
+			if got, want := dg.Selected(ParseModuleId("example.com/local@v1.0.0")), dg.Root(); got != want {
+				t.Errorf("Selected(example.com/local@v1.0.0) = %v, want the root %v", got, want)
+			}
+		})
+	}
+}
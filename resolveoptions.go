@@ -0,0 +1,38 @@
+package gomoddepgraph
+
+type resolveConfig struct {
+	withoutSurprises bool
+}
+
+// A ResolveOption customizes how [ResolveGo], [ResolveMvs], and [ResolveSat] resolve a
+// [RequirementGraph].
+type ResolveOption func(*resolveConfig) error
+
+func newResolveConfig(opts []ResolveOption) (*resolveConfig, error) {
+	cfg := &resolveConfig{}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// WithoutSurprises returns a [ResolveOption] that skips computing surprise dependencies (see the
+// "Surprise Dependencies" section of the package-level documentation).
+// [DependencyGraph.SurpriseDeps] of the resolved graph yields nothing, even for dependencies that
+// do have surprise dependencies, so [Deps] yields only the direct dependencies.  The option is
+// recorded in the graph's [Provenance].
+//
+// The graph's topology is therefore incomplete: [AllDependencies], [WalkDependencyGraph], and the
+// other functions that walk the graph from [DependencyGraph.Root] do not visit a selected module
+// that is only reachable via surprise dependencies.  [Nodes] still yields every selected module.
+//
+// Computing surprise dependencies dominates the resolvers' running time for large graphs, so this
+// is a big speedup for callers that only need the selection set (see [Nodes]).
+func WithoutSurprises() ResolveOption {
+	return func(cfg *resolveConfig) error {
+		cfg.withoutSurprises = true
+		return nil
+	}
+}
@@ -6,17 +6,18 @@ import (
 	"iter"
 	"maps"
 	"slices"
-	"sync"
 
 	"github.com/crillab/gophersat/solver"
-	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/rhansen/gomoddepgraph/internal/itertools"
-	"golang.org/x/sync/errgroup"
 )
 
 // ResolveSat constructs a Boolean satisfiability (SAT) problem from the given [RequirementGraph]
 // and uses a SAT solver to select the dependencies.
-func ResolveSat(ctx context.Context, rg RequirementGraph) (DependencyGraph, error) {
+func ResolveSat(ctx context.Context, rg RequirementGraph, opts ...ResolveOption) (DependencyGraph, error) {
+	cfg, err := newResolveConfig(opts)
+	if err != nil {
+		return nil, err
+	}
 	prob, nodes, _, err := buildSatProblem(ctx, rg)
 	if err != nil {
 		return nil, err
@@ -34,30 +35,15 @@ func ResolveSat(ctx context.Context, rg RequirementGraph) (DependencyGraph, erro
 				trueVars,
 				func(v solver.Var) (string, Dependency) {
 					m := nodes[v]
-					mId := m.Id()
-					return mId.Path, dependency{mId}
+					return m.Id().Path, newDependency(m)
 				})),
-		surprise: map[Dependency]mapset.Set[Dependency]{},
-	}
-	// Compute the set of surprise dependencies for each dependency in the selection set.
-	//
-	// TODO: This implementation is O(|V|*(|V|+|E|)), which can be improved.  However, a more
-	// efficient implementation might be tricky due to possible dependency cycles.
-	var mu sync.Mutex
-	gr, ctx := errgroup.WithContext(ctx)
-	for _, d := range dg.sel {
-		gr.Go(func() error {
-			surprise, err := computeSurpriseDeps(ctx, rg, dg, d)
-			if err != nil {
-				return err
-			}
-			mu.Lock()
-			defer mu.Unlock()
-			dg.surprise[d] = surprise
-			return nil
-		})
+		prov: Provenance{
+			Requirements:     requirementSource(rg),
+			Resolver:         "ResolveSat",
+			WithoutSurprises: cfg.withoutSurprises,
+		},
 	}
-	if err := gr.Wait(); err != nil {
+	if err := dg.computeAllSurpriseDeps(ctx); err != nil {
 		return nil, err
 	}
 	return dg, nil
@@ -0,0 +1,32 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"strings"
+)
+
+// Retractions returns the [retraction] rationale for each selected [Dependency] (see [Nodes]) whose
+// version has been retracted by the module's author.  Dependencies whose versions have not been
+// retracted are not included in the returned map.  If a version is retracted by multiple
+// [retract directive]s, the rationales are joined with "; ".  [DevelVersion] and [LocalVersion]
+// nodes and the synthetic root of a multi-root graph are never included.
+//
+// Go only warns about retracted versions when running commands such as `go get` or `go list -u`,
+// so a retracted version can go unnoticed when it is selected to satisfy a requirement elsewhere in
+// the requirement graph.
+//
+// [retraction]: https://go.dev/ref/mod#go-mod-file-retract
+// [retract directive]: https://go.dev/ref/mod#go-mod-file-retract
+func Retractions(ctx context.Context, dg DependencyGraph) (map[Dependency]string, error) {
+	mds, err := listReleased(ctx, dg, "-retracted")
+	if err != nil {
+		return nil, err
+	}
+	ret := map[Dependency]string{}
+	for d, md := range mds {
+		if len(md.Retracted) > 0 {
+			ret[d] = strings.Join(md.Retracted, "; ")
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,88 @@
+package gomoddepgraph_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestRetractions(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/dep@v1.1.0"), fm.Retract("v1.0.0", "broken")},
+		[]fm.Option{fm.Id("example.com/other@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/dep@v1.0.0", false),
+			fm.Require("example.com/other@v1.0.0", false)},
+	).Context()
+	rg, err := RequirementsGo(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dg, err := ResolveGo(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Retractions(ctx, dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotStr := map[string]string{}
+	for d, why := range got {
+		gotStr[d.String()] = why
+	}
+	want := map[string]string{"example.com/dep@v1.0.0": "broken"}
+	if diff := cmp.Diff(want, gotStr); diff != "" {
+		t.Errorf("unexpected retractions (-want +got):\n%s", diff)
+	}
+
+	// A (devel) root is not looked up.
+	dir := t.TempDir()
+	goMod := "module example.com/local\n\ngo 1.26.0\n\nrequire example.com/dep v1.0.0\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0666); err != nil {
+		t.Fatal(err)
+	}
+	crg, done, err := RequirementsComplete(ctx, NewModuleId("example.com/local", DevelVersion), WithRootDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	if dg, err = ResolveMvs(ctx, crg); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = Retractions(ctx, dg); err != nil {
+		t.Fatal(err)
+	}
+	gotStr = map[string]string{}
+	for d, why := range got {
+		gotStr[d.String()] = why
+	}
+	if diff := cmp.Diff(want, gotStr); diff != "" {
+		t.Errorf("unexpected retractions with a devel root (-want +got):\n%s", diff)
+	}
+}
+
+func TestRetractions_LocalReplace(t *testing.T) {
+	t.Parallel()
+	gp := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/b@v1.0.0"), fm.Retract("v1.0.0", "broken")},
+	)
+	ctx, dg := resolveLocalReplace(t, gp)
+	got, err := Retractions(ctx, dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotStr := map[string]string{}
+	for d, why := range got {
+		gotStr[d.String()] = why
+	}
+	want := map[string]string{"example.com/b@v1.0.0": "broken"}
+	if diff := cmp.Diff(want, gotStr); diff != "" {
+		t.Errorf("unexpected retractions (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,67 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// SelectionReason returns the module in rg whose requirement caused the version of the module with
+// the given path selected in dg to win:  a node reachable from [RequirementGraph.Root] with a
+// direct or immediate indirect requirement on exactly the selected version.  This answers the
+// common question "I required v1.2.0, so why was v1.5.0 selected?"
+//
+// The explanation follows the [Minimal Version Selection] algorithm, which selects the maximum of
+// the versions required by all reachable nodes, so it is only meaningful if dg was resolved from rg
+// by [ResolveMvs] or [ResolveGo].  If more than one node requires the selected version, the one
+// closest to the root is returned, preferring nodes whose own version was selected (ties are broken
+// by [RequirementCompare]).  Returns an error if no module with the given path is selected, or if
+// it is selected only because it is the root module.
+//
+// [Minimal Version Selection]: https://go.dev/ref/mod#minimal-version-selection
+func SelectionReason(ctx context.Context, rg RequirementGraph, dg DependencyGraph, path string) (Requirement, error) {
+	var sel Dependency
+	for d := range Nodes(dg) {
+		if d.Id().Path == path {
+			sel = d
+			break
+		}
+	}
+	if sel == nil {
+		return nil, fmt.Errorf("no version of module %v is selected", path)
+	}
+	root := rg.Root()
+	seen := map[Requirement]bool{root: true}
+	var fallback Requirement
+	for level := []Requirement{root}; len(level) > 0; {
+		next := []Requirement(nil)
+		for _, p := range level {
+			if err := rg.Load(ctx, p); err != nil {
+				return nil, err
+			}
+			for m := range Reqs(rg, p) {
+				if m.Id() == sel.Id() {
+					if d := dg.Selected(p.Id()); d != nil && d.Id() == p.Id() {
+						return p, nil
+					}
+					if fallback == nil {
+						fallback = p
+					}
+				}
+				if !seen[m] {
+					seen[m] = true
+					next = append(next, m)
+				}
+			}
+		}
+		if fallback != nil {
+			return fallback, nil
+		}
+		slices.SortFunc(next, RequirementCompare)
+		level = next
+	}
+	if sel.Id() == root.Id() {
+		return nil, fmt.Errorf("%v is selected because it is the root module", sel)
+	}
+	return nil, fmt.Errorf("bug: no module requires the selected version %v", sel)
+}
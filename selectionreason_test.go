@@ -0,0 +1,55 @@
+package gomoddepgraph
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSelectionReason(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	rg := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false, "example.com/s@v1.2.0": false},
+		"example.com/a@v1.0.0": {"example.com/c@v1.0.0": false, "example.com/x@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/x@v1.1.0": false},
+		"example.com/c@v1.0.0": {"example.com/s@v1.5.0": true},
+		"example.com/x@v1.0.0": {"example.com/t@v1.1.0": false},
+		"example.com/x@v1.1.0": {"example.com/t@v1.0.0": false},
+		"example.com/s@v1.2.0": {},
+		"example.com/s@v1.5.0": {},
+		"example.com/t@v1.0.0": {},
+		"example.com/t@v1.1.0": {},
+	})
+	dg, err := ResolveMvs(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		path, want string
+		wantErr    *regexp.Regexp
+	}{
+		{path: "example.com/s", want: "example.com/c@v1.0.0"},
+		{path: "example.com/x", want: "example.com/b@v1.0.0"},
+		// Only the unselected x@v1.0.0 requires t@v1.1.0.
+		{path: "example.com/t", want: "example.com/x@v1.0.0"},
+		{path: "example.com/r", wantErr: regexp.MustCompile(`because it is the root module`)},
+		{path: "example.com/nope", wantErr: regexp.MustCompile(`no version .* is selected`)},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			t.Parallel()
+			got, err := SelectionReason(ctx, rg, dg, tc.path)
+			if tc.wantErr != nil {
+				if err == nil || !tc.wantErr.MatchString(err.Error()) {
+					t.Errorf("got error %v, want error matching %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"slices"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// SelfRequirements returns the nodes reachable from [RequirementGraph.Root] that require, directly
+// or transitively, a different version of their own module, sorted by [RequirementCompare].  For
+// example, if example.com/root@v1.1.0 requires example.com/dep@v1.0.0, which in turn requires
+// example.com/root@v1.0.0, then example.com/root@v1.1.0 is returned.  A direct self-requirement
+// (a go.mod that requires another version of the module itself) is the simplest case.
+//
+// Such requirements are valid, and version selection picks a single version of the module, but they
+// create the dependency cycles described in the package-level documentation and are worth flagging
+// for packagers who must pick one version of each module.  Every reachable node is loaded (see
+// [AllRequirements]).
+func SelfRequirements(ctx context.Context, rg RequirementGraph) ([]Requirement, error) {
+	byPath := map[string][]Requirement{}
+	reqs, done := AllRequirements(ctx, rg)
+	for r := range reqs {
+		byPath[r.Id().Path] = append(byPath[r.Id().Path], r)
+	}
+	if err := done(); err != nil {
+		return nil, err
+	}
+	ret := []Requirement(nil)
+	for path, rs := range byPath {
+		if len(rs) < 2 {
+			continue
+		}
+		for _, r := range rs {
+			if requiresOtherVersion(rg, r, path) {
+				ret = append(ret, r)
+			}
+		}
+	}
+	slices.SortFunc(ret, RequirementCompare)
+	return ret, nil
+}
+
+// requiresOtherVersion reports whether a node with the given module path other than start is
+// reachable from start.  Every node reachable from start must already be loaded.
+func requiresOtherVersion(rg RequirementGraph, start Requirement, path string) bool {
+	seen := mapset.NewThreadUnsafeSet(start)
+	q := []Requirement{start}
+	for len(q) > 0 {
+		p := q[0]
+		q = q[1:]
+		for m := range Reqs(rg, p) {
+			if m.Id().Path == path && m != start {
+				return true
+			}
+			if seen.Add(m) {
+				q = append(q, m)
+			}
+		}
+	}
+	return false
+}
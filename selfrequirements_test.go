@@ -0,0 +1,31 @@
+package gomoddepgraph
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSelfRequirements(t *testing.T) {
+	t.Parallel()
+	rg := newTestRequirementGraph(t, "example.com/r@v1.1.0", map[string]map[string]bool{
+		"example.com/r@v1.1.0": {"example.com/d@v1.0.0": false, "example.com/s@v1.1.0": false},
+		"example.com/d@v1.0.0": {"example.com/r@v1.0.0": false},
+		"example.com/r@v1.0.0": {},
+		// s requires an older version of itself directly.
+		"example.com/s@v1.1.0": {"example.com/s@v1.0.0": true},
+		"example.com/s@v1.0.0": {},
+	})
+	got, err := SelfRequirements(t.Context(), rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotStr := []string(nil)
+	for _, r := range got {
+		gotStr = append(gotStr, r.String())
+	}
+	want := []string{"example.com/r@v1.1.0", "example.com/s@v1.1.0"}
+	if diff := cmp.Diff(want, gotStr); diff != "" {
+		t.Errorf("unexpected self requirements (-want +got):\n%s", diff)
+	}
+}
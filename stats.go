@@ -0,0 +1,40 @@
+package gomoddepgraph
+
+// DependencyGraphStats summarizes the size and shape of a [DependencyGraph].  See
+// [DependencyStats].
+type DependencyGraphStats struct {
+	// Modules is the number of selected modules (see [AllDependencies]), not counting the root.
+	Modules int
+	// SurpriseEdges is the number of surprise dependency edges (see [AllSurpriseDependencies]).
+	SurpriseEdges int
+	// Cycles is the number of dependency cycles (see [FindCycles]).
+	Cycles int
+	// MaxDepth is the largest number of edges on the shortest path from the root to any selected
+	// module, following both direct and surprise dependency edges (see [Deps]).  It is 0 if the root
+	// has no dependencies.
+	MaxDepth int
+}
+
+// DependencyStats computes summary statistics for the given [DependencyGraph], which is useful for
+// tracking dependency bloat over time.
+func DependencyStats(dg DependencyGraph) DependencyGraphStats {
+	ret := DependencyGraphStats{Cycles: len(FindCycles(dg))}
+	for range AllSurpriseDependencies(dg) {
+		ret.SurpriseEdges++
+	}
+	dist := map[Dependency]int{dg.Root(): 0}
+	q := []Dependency{dg.Root()}
+	for len(q) > 0 {
+		p := q[0]
+		q = q[1:]
+		for d := range Deps(dg, p) {
+			if _, ok := dist[d]; !ok {
+				dist[d] = dist[p] + 1
+				ret.MaxDepth = max(ret.MaxDepth, dist[d])
+				q = append(q, d)
+			}
+		}
+	}
+	ret.Modules = len(dist) - 1
+	return ret
+}
@@ -0,0 +1,40 @@
+package gomoddepgraph
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDependencyStats(t *testing.T) {
+	t.Parallel()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": true},
+		"example.com/a@v1.0.0": {"example.com/b@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/c@v1.0.0": {"example.com/a@v1.0.0": false},
+		"example.com/s@v1.0.0": {},
+	})
+	want := DependencyGraphStats{Modules: 4, SurpriseEdges: 1, Cycles: 1, MaxDepth: 3}
+	if diff := cmp.Diff(want, DependencyStats(dg)); diff != "" {
+		t.Errorf("DependencyStats() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDependencyStats_EdgeCases(t *testing.T) {
+	t.Parallel()
+	want := map[string]DependencyGraphStats{
+		"empty":      {},
+		"cycles":     {Modules: 3, Cycles: 2, MaxDepth: 2},
+		"multi-root": {Modules: 3, MaxDepth: 2},
+	}
+	for _, tc := range edgeCaseGraphs {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			got := DependencyStats(newTestDependencyGraph(t, tc.root, tc.g))
+			if diff := cmp.Diff(want[tc.desc], got); diff != "" {
+				t.Errorf("DependencyStats() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
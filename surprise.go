@@ -0,0 +1,139 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// An Explanation describes why a surprise dependency (see the "Surprise Dependencies" section of
+// the package-level documentation) is in a [DependencyGraph].  It is returned from
+// [ExplainSurprise].
+type Explanation struct {
+	// Surprise is the surprise dependency being explained.
+	Surprise Dependency
+	// Path is a shortest path of dependency edges (see [Deps]) from [DependencyGraph.Root] to
+	// Dependent, inclusive.
+	Path []Dependency
+	// Dependent is the module with the "// indirect" requirement that caused Surprise to be a
+	// surprise dependency.
+	Dependent Dependency
+	// Requirement is Dependent's "// indirect" requirement that is satisfied by Surprise.  Its
+	// version might be lower than Surprise's version.
+	Requirement Requirement
+}
+
+// String returns a single-line human-readable form of the explanation, such as:
+//
+//	example.com/root@v1.0.0 -> example.com/a@v1.0.0 -> example.com/s@v1.0.0 // indirect (selected v1.2.0)
+//
+// The exact format is subject to change.
+func (e *Explanation) String() string {
+	parts := []string{}
+	for _, d := range e.Path {
+		parts = append(parts, d.Id().String())
+	}
+	parts = append(parts, e.Requirement.Id().String()+" // indirect")
+	s := strings.Join(parts, " -> ")
+	if got, want := e.Surprise.Id().Version, e.Requirement.Id().Version; got != want {
+		s += fmt.Sprintf(" (selected %v)", got)
+	}
+	return s
+}
+
+// ExplainSurprise returns the minimal set of requirement edges that explain why the given surprise
+// dependency was selected: a shortest path from the root to a module that has surprise as one of
+// its [DependencyGraph.SurpriseDeps], plus that module's "// indirect" requirement.  If more than
+// one module has surprise as a surprise dependency, the one closest to the root is chosen (ties are
+// broken by [DependencyCompare]).  Returns an error if surprise is not a surprise dependency of any
+// module reachable from the root.
+//
+// The [DependencyGraph] must have been returned from one of this package's resolvers (e.g.,
+// [ResolveMvs]) so that the [RequirementGraph] it was resolved from is available.
+func ExplainSurprise(ctx context.Context, dg DependencyGraph, surprise Dependency) (*Explanation, error) {
+	idg, ok := dg.(*dependencyGraph)
+	if !ok {
+		return nil, fmt.Errorf("cannot explain a surprise dependency in a %T", dg)
+	}
+	root := dg.Root()
+	parent := map[Dependency]Dependency{root: nil}
+	q := []Dependency{root}
+	for len(q) > 0 {
+		p := q[0]
+		q = q[1:]
+		if slices.Contains(slices.Collect(dg.SurpriseDeps(p)), surprise) {
+			return explainSurprise(ctx, idg.rg, surprise, p, parent)
+		}
+		for _, d := range slices.SortedFunc(maps.Keys(maps.Collect(Deps(dg, p))), DependencyCompare) {
+			if _, seen := parent[d]; !seen {
+				parent[d] = p
+				q = append(q, d)
+			}
+		}
+	}
+	return nil, fmt.Errorf("%v is not a surprise dependency", surprise)
+}
+
+func explainSurprise(ctx context.Context, rg RequirementGraph, surprise, dependent Dependency,
+	parent map[Dependency]Dependency) (*Explanation, error) {
+
+	e := &Explanation{Surprise: surprise, Dependent: dependent}
+	for d := dependent; d != nil; d = parent[d] {
+		e.Path = append(e.Path, d)
+	}
+	slices.Reverse(e.Path)
+	r := rg.Req(dependent.Id())
+	if err := rg.Load(ctx, r); err != nil {
+		return nil, err
+	}
+	for ir := range rg.ImmediateIndirectReqs(r) {
+		if ir.Id().Path == surprise.Id().Path {
+			e.Requirement = ir
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("bug: %v has no indirect requirement on %v", dependent, surprise.Id().Path)
+}
+
+// errSurpriseFound stops [HasSurprise]'s search once a surprise dependency is found.
+var errSurpriseFound = errors.New("surprise dependency found")
+
+// HasSurprise reports whether resolving the given [RequirementGraph] with [ResolveMvs] would yield
+// any surprise dependencies (see the "Surprise Dependencies" section of the package-level
+// documentation).  It is much faster than resolving the graph and checking
+// [AllSurpriseDependencies] when surprise dependencies exist, because the search stops (and the
+// remaining work is canceled) as soon as the first one is found.  This is useful for a lint that
+// only needs a yes or no answer.
+func HasSurprise(ctx context.Context, rg RequirementGraph) (bool, error) {
+	dg, err := ResolveMvs(ctx, rg, WithoutSurprises())
+	if err != nil {
+		return false, err
+	}
+	gr, gctx := errgroup.WithContext(ctx)
+	// Without surprise edges, only the modules reachable via direct dependencies are visited.  That
+	// is enough:  any other module is only reachable via a surprise dependency of a visited module.
+	for d := range AllDependencies(dg) {
+		gr.Go(func() error {
+			s, err := computeSurpriseDeps(gctx, rg, dg, d)
+			if err != nil {
+				return err
+			}
+			if !s.IsEmpty() {
+				return errSurpriseFound
+			}
+			return nil
+		})
+	}
+	if err := gr.Wait(); err != nil {
+		if errors.Is(err, errSurpriseFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
@@ -0,0 +1,71 @@
+package gomoddepgraph
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestExplainSurprise(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	dg := newTestDependencyGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+		"example.com/a@v1.0.0": {"example.com/c@v1.0.0": false},
+		"example.com/b@v1.0.0": {"example.com/s@v1.1.0": false},
+		"example.com/c@v1.0.0": {"example.com/s@v1.0.0": true},
+		"example.com/s@v1.0.0": {},
+		"example.com/s@v1.1.0": {},
+	})
+	s := dg.Selected(NewModuleId("example.com/s", "v1.1.0"))
+	got, err := ExplainSurprise(ctx, dg, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "example.com/r@v1.0.0 -> example.com/a@v1.0.0 -> example.com/c@v1.0.0 -> " +
+		"example.com/s@v1.0.0 // indirect (selected v1.1.0)"
+	if got := got.String(); got != want {
+		t.Errorf("got explanation %q, want %q", got, want)
+	}
+	if got, want := got.Dependent, dg.Selected(NewModuleId("example.com/c", "v1.0.0")); got != want {
+		t.Errorf("got dependent %v, want %v", got, want)
+	}
+
+	b := dg.Selected(NewModuleId("example.com/b", "v1.0.0"))
+	wantErr := regexp.MustCompile(`not a surprise dependency`)
+	if _, err := ExplainSurprise(ctx, dg, b); err == nil || !wantErr.MatchString(err.Error()) {
+		t.Errorf("got error %q, want error matching %q", err, wantErr)
+	}
+}
+
+func TestHasSurprise(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		g    map[string]map[string]bool
+		want bool
+	}{
+		"surprise": {map[string]map[string]bool{
+			"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+			"example.com/a@v1.0.0": {"example.com/c@v1.0.0": false},
+			"example.com/b@v1.0.0": {"example.com/s@v1.1.0": false},
+			"example.com/c@v1.0.0": {"example.com/s@v1.0.0": true},
+			"example.com/s@v1.0.0": {},
+			"example.com/s@v1.1.0": {},
+		}, true},
+		"none": {map[string]map[string]bool{
+			"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/s@v1.0.0": true},
+			"example.com/a@v1.0.0": {"example.com/s@v1.0.0": false},
+			"example.com/s@v1.0.0": {},
+		}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := HasSurprise(t.Context(), newTestRequirementGraph(t, "example.com/r@v1.0.0", tc.g))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("HasSurprise() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
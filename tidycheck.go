@@ -0,0 +1,222 @@
+package gomoddepgraph
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/rhansen/gomoddepgraph/internal/command"
+	"github.com/rhansen/gomoddepgraph/internal/logging"
+	"golang.org/x/mod/modfile"
+)
+
+// A TidyRequirement is a requirement in a go.mod [require directive].
+//
+// [require directive]: https://go.dev/ref/mod#go-mod-file-require
+type TidyRequirement struct {
+	ModuleId
+	// Indirect is true if the requirement is marked with an "// indirect" comment.
+	Indirect bool
+}
+
+// A TidyChange is a requirement whose version or "// indirect" comment `go mod tidy` would change.
+type TidyChange struct {
+	Old, New TidyRequirement
+}
+
+// A TidyReport describes how `go mod tidy` would change a module's go.mod and go.sum.  See
+// [TidyCheck].
+type TidyReport struct {
+	// Added lists the requirements `go mod tidy` would add, sorted by [ModuleIdCompare].
+	Added []TidyRequirement
+	// Removed lists the requirements `go mod tidy` would remove, sorted by [ModuleIdCompare].
+	Removed []TidyRequirement
+	// Changed lists the requirements whose version or "// indirect" comment `go mod tidy` would
+	// change, sorted by the [ModuleIdCompare] order of Old.
+	Changed []TidyChange
+	// GoSumChanged is true if `go mod tidy` would change go.sum (including creating it).
+	GoSumChanged bool
+}
+
+// Tidy reports whether `go mod tidy` would leave both go.mod's requirements and go.sum unchanged.
+func (r *TidyReport) Tidy() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0 && !r.GoSumChanged
+}
+
+// TidyCheck reports how `go mod tidy` would change the requirements in the go.mod (and the go.sum)
+// of the module in rootDir, without modifying any files in rootDir.  The module is copied to a
+// temporary directory (skipping version control directories, nested modules, and anything that is
+// not a regular file or directory) and `go mod tidy` is run on the copy.  Only the requirements are
+// compared; changes to other directives (e.g., go or toolchain) are ignored.  Relative replacement
+// directories in [replace] directives are made absolute in the copy (by resolving them against
+// rootDir) so that they still name the same directories.
+//
+// [replace]: https://go.dev/ref/mod#go-mod-file-replace
+func TidyCheck(ctx context.Context, rootDir string) (_ *TidyReport, retErr error) {
+	goModFile := filepath.Join(rootDir, "go.mod")
+	before, err := readGoMod(goModFile)
+	if err != nil {
+		return nil, err
+	}
+	if before.Module == nil {
+		return nil, fmt.Errorf("%v: missing module directive", goModFile)
+	}
+	tmp, err := os.MkdirTemp(tempDir(ctx), "gomoddepgraph-tidy-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmp); retErr == nil {
+			retErr = err
+		}
+	}()
+	if err := copyModuleTree(rootDir, tmp); err != nil {
+		return nil, err
+	}
+	if err := absReplaceDirs(filepath.Join(tmp, "go.mod"), rootDir); err != nil {
+		return nil, err
+	}
+	cmd := []string{goBin(ctx), "mod", "tidy"}
+	if slog.Default().Enabled(ctx, logging.LevelVerbose) {
+		cmd = append(cmd, "-x")
+	}
+	// Tidy must not be affected by a go.work file above the temporary directory.
+	if err := command.Run(WithoutWorkspace(ctx), tmp, cmd...); err != nil {
+		return nil, fmt.Errorf("command %q failed: %w", cmd, err)
+	}
+	after, err := readGoMod(filepath.Join(tmp, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	ret := diffGoModRequirements(before, after)
+	oldSum, err := os.ReadFile(filepath.Join(rootDir, "go.sum"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	newSum, err := os.ReadFile(filepath.Join(tmp, "go.sum"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	ret.GoSumChanged = !bytes.Equal(oldSum, newSum)
+	return ret, nil
+}
+
+// absReplaceDirs rewrites the go.mod file at goModFile so that each relative replacement directory
+// is resolved against dir instead of the go.mod file's own directory, like filterGoMod does.
+func absReplaceDirs(goModFile, dir string) error {
+	goMod, err := readMainGoMod(goModFile)
+	if err != nil {
+		return err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, r := range goMod.Replace {
+		if r.New.Version != "" || filepath.IsAbs(r.New.Path) {
+			continue
+		}
+		newPath := filepath.Join(absDir, r.New.Path)
+		if err := goMod.AddReplace(r.Old.Path, r.Old.Version, newPath, ""); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	data, err := goMod.Format()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(goModFile, data, 0666)
+}
+
+// diffGoModRequirements compares the require directives in the two go.mod files.
+func diffGoModRequirements(before, after *modfile.File) *TidyReport {
+	reqs := func(goMod *modfile.File) map[string]TidyRequirement {
+		ret := map[string]TidyRequirement{}
+		for _, r := range goMod.Require {
+			ret[r.Mod.Path] = TidyRequirement{ModuleId{r.Mod}, r.Indirect}
+		}
+		return ret
+	}
+	b, a := reqs(before), reqs(after)
+	ret := &TidyReport{}
+	for _, p := range slices.Sorted(maps.Keys(b)) {
+		switch ar, ok := a[p]; {
+		case !ok:
+			ret.Removed = append(ret.Removed, b[p])
+		case ar != b[p]:
+			ret.Changed = append(ret.Changed, TidyChange{Old: b[p], New: ar})
+		}
+	}
+	for _, p := range slices.Sorted(maps.Keys(a)) {
+		if _, ok := b[p]; !ok {
+			ret.Added = append(ret.Added, a[p])
+		}
+	}
+	return ret
+}
+
+// copyModuleTree copies the module rooted at src to the existing directory dst.  Version control
+// directories, nested modules (subdirectories with their own go.mod), and files that are not
+// regular files or directories are skipped.
+func copyModuleTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			if rel == "." {
+				return nil
+			}
+			switch d.Name() {
+			case ".git", ".hg", ".svn", ".bzr":
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return os.Mkdir(target, 0777)
+		case d.Type().IsRegular():
+			return copyFile(path, target)
+		default:
+			return nil
+		}
+	})
+}
+
+func copyFile(src, dst string) (retErr error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := out.Close(); retErr == nil {
+			retErr = err
+		}
+	}()
+	_, err = io.Copy(out, in)
+	return err
+}
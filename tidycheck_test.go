@@ -0,0 +1,102 @@
+package gomoddepgraph_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestTidyCheck(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/ind@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/dep@v1.0.0"), fm.Require("example.com/ind@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/unused@v1.0.0")},
+	).Context()
+	dir := t.TempDir()
+	goMod := `module example.com/local
+
+go 1.26.0
+
+require (
+	example.com/dep v1.0.0 // indirect
+	example.com/unused v1.0.0
+)
+`
+	for name, data := range map[string]string{
+		"go.mod":       goMod,
+		"local.go":     "package local\n\nimport _ \"example.com/dep\"\n",
+		".git/config":  "not a module file\n",
+		"sub/go.mod":   "module example.com/local/sub\n",
+		"sub/sub.go":   "package sub\n\nimport _ \"example.com/missing\"\n",
+		"pkg/pkg.go":   "package pkg\n",
+		"pkg/notes.md": "notes\n",
+	} {
+		fn := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fn), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := TidyCheck(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &TidyReport{
+		Added:   []TidyRequirement{{ParseModuleId("example.com/ind@v1.0.0"), true}},
+		Removed: []TidyRequirement{{ParseModuleId("example.com/unused@v1.0.0"), false}},
+		Changed: []TidyChange{{
+			Old: TidyRequirement{ParseModuleId("example.com/dep@v1.0.0"), true},
+			New: TidyRequirement{ParseModuleId("example.com/dep@v1.0.0"), false},
+		}},
+		GoSumChanged: true,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+	if got.Tidy() {
+		t.Error("Tidy() = true, want false")
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err != nil {
+		t.Fatal(err)
+	} else if string(data) != goMod {
+		t.Errorf("go.mod was modified:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.sum")); err == nil {
+		t.Error("go.sum was created")
+	}
+}
+
+func TestTidyCheck_RelativeReplace(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).Context()
+	root := t.TempDir()
+	for name, data := range map[string]string{
+		"main/go.mod": "module example.com/local\n\ngo 1.26.0\n\nrequire example.com/s v0.0.0\n\n" +
+			"replace example.com/s => ../sibling\n",
+		"main/local.go":  "package local\n\nimport _ \"example.com/s\"\n",
+		"sibling/go.mod": "module example.com/s\n\ngo 1.26.0\n",
+		"sibling/s.go":   "package s\n",
+	} {
+		fn := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(fn), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := TidyCheck(ctx, filepath.Join(root, "main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&TidyReport{}, got); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,91 @@
+package gomoddepgraph
+
+import (
+	"strconv"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"golang.org/x/mod/modfile"
+)
+
+// IsTool reports whether r is one of p's requirements and r's module provides a [tool] declared by
+// a tool directive in p's go.mod.  (The `go get -tool` command marks a tool's module as an indirect
+// requirement, so such a requirement is often the reason for a surprise dependency.)  Tool
+// directives only take effect in the [main module], but they are recorded for every module.
+//
+// The tool information is only available in graphs returned from [RequirementsComplete],
+// [RequirementsFromGoMod], and [RequirementsGo]; IsTool returns false for other graphs.  p must be
+// loaded (see [RequirementGraph.Load]).
+//
+// [tool]: https://go.dev/doc/modules/managing-dependencies#tools
+// [main module]: https://go.dev/ref/mod#glos-main-module
+func IsTool(rg RequirementGraph, p, r Requirement) bool {
+	var reqs *requirementGraphReqs
+	switch rg := rg.(type) {
+	case *requirementGraph:
+		reqs = rg.reqs[p]
+	case *requirementGraphGo:
+		reqs = rg.reqs[p]
+	case *requirementGraphComplete:
+		reqs = rg.reqs(p)
+	}
+	return reqs != nil && reqs.t != nil && reqs.t.Contains(r)
+}
+
+// goModTools returns the package paths named in goMod's tool directives.  [modfile.ParseLax] does
+// not populate [modfile.File.Tool], so the directives are read from the syntax tree.
+func goModTools(goMod *modfile.File) []string {
+	var ret []string
+	add := func(tok []string) {
+		if len(tok) != 1 {
+			return
+		}
+		p := tok[0]
+		if uq, err := strconv.Unquote(p); err == nil {
+			p = uq
+		}
+		ret = append(ret, p)
+	}
+	for _, stmt := range goMod.Syntax.Stmt {
+		switch stmt := stmt.(type) {
+		case *modfile.Line:
+			if len(stmt.Token) > 0 && stmt.Token[0] == "tool" {
+				add(stmt.Token[1:])
+			}
+		case *modfile.LineBlock:
+			if len(stmt.Token) == 1 && stmt.Token[0] == "tool" {
+				for _, l := range stmt.Line {
+					add(l.Token)
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// toolReqs returns the requirements in reqs that provide the tools declared in goMod, or nil if
+// there are none.  A tool is provided by the requirement with the longest module path that is a
+// prefix of the tool's package path.  Tools provided by the module itself are ignored.
+func toolReqs(goMod *modfile.File, reqs *requirementGraphReqs) mapset.Set[Requirement] {
+	var ret mapset.Set[Requirement]
+	for _, tool := range goModTools(goMod) {
+		var best Requirement
+		for r := range mapset.Elements(reqs.d.Union(reqs.i)) {
+			mp := r.Id().Path
+			if tool != mp && !strings.HasPrefix(tool, mp+"/") {
+				continue
+			}
+			if best == nil || len(mp) > len(best.Id().Path) {
+				best = r
+			}
+		}
+		if best == nil {
+			continue
+		}
+		if ret == nil {
+			ret = mapset.NewThreadUnsafeSet[Requirement]()
+		}
+		ret.Add(best)
+	}
+	return ret
+}
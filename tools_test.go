@@ -0,0 +1,55 @@
+package gomoddepgraph_test
+
+import (
+	"testing"
+
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestIsTool(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/d@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/t@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/t/sub@v1.0.0")},
+	).Context()
+	goMod := `module example.com/root
+
+go 1.26.0
+
+require example.com/d v1.0.0
+
+require (
+	example.com/t v1.0.0 // indirect
+	example.com/t/sub v1.0.0 // indirect
+)
+
+tool (
+	example.com/root/cmd/self
+	example.com/t/sub/cmd/x
+)
+`
+	rg, done, err := RequirementsFromGoMod(ctx, []byte(goMod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done()
+	root := rg.Root()
+	if err := rg.Load(ctx, root); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		req  string
+		want bool
+	}{
+		{req: "example.com/d@v1.0.0", want: false},
+		// The longest module path prefix provides the tool.
+		{req: "example.com/t@v1.0.0", want: false},
+		{req: "example.com/t/sub@v1.0.0", want: true},
+	} {
+		if got := IsTool(rg, root, rg.Req(ParseModuleId(tc.req))); got != tc.want {
+			t.Errorf("IsTool(%v) = %v, want %v", tc.req, got, tc.want)
+		}
+	}
+}
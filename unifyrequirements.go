@@ -6,7 +6,6 @@ import (
 	"sync"
 
 	mapset "github.com/deckarep/golang-set/v2"
-	"golang.org/x/mod/semver"
 )
 
 // UnifyRequirements walks the input graph and returns a [RequirementGraph] that has the same rough
@@ -45,36 +44,75 @@ import (
 // from it—may change depending on which requirements in the input graph are traversed first by this
 // function.  This implementation performs a non-deterministic graph walk, so different runs on the
 // same input requirement graph might produce different returned graphs.  If reproducibility is
-// important, do not use this function.
+// important, use [UnifyRequirementsDeterministic] instead.
 //
 // [module proxy]: https://go.dev/ref/mod#module-proxy
 func UnifyRequirements(ctx context.Context, rg RequirementGraph) (RequirementGraph, error) {
+	ret, _, err := unifyRequirements(ctx, rg, WalkRequirementGraph)
+	return ret, err
+}
+
+// UnifyStats describes the work done by [UnifyRequirementsWithStats].
+type UnifyStats struct {
+	// Restarts is the number of times the graph walk was repeated because a newer version of an
+	// already visited module was discovered.
+	Restarts int
+	// NodesVisited holds the number of requirements visited during each pass over the graph.  Its
+	// length is always Restarts+1.
+	NodesVisited []int
+}
+
+// UnifyRequirementsWithStats is like [UnifyRequirements] but also returns statistics about the
+// unification, which can help diagnose slow unification of a large requirement graph.  A large
+// number of restarts indicates that the walk keeps discovering newer versions of modules it has
+// already visited.
+func UnifyRequirementsWithStats(ctx context.Context, rg RequirementGraph) (RequirementGraph, *UnifyStats, error) {
+	return unifyRequirements(ctx, rg, WalkRequirementGraph)
+}
+
+// UnifyRequirementsDeterministic is like [UnifyRequirements] except it walks the input graph
+// breadth-first in a single goroutine, visiting requirements in [RequirementCompare] order.  The
+// same input requirement graph always produces the same returned graph.  This is slower than
+// [UnifyRequirements] because no go.mod files are loaded in parallel.
+func UnifyRequirementsDeterministic(ctx context.Context, rg RequirementGraph) (RequirementGraph, error) {
+	ret, _, err := unifyRequirements(ctx, rg, walkRequirementGraphSorted)
+	return ret, err
+}
+
+func unifyRequirements(ctx context.Context, rg RequirementGraph, walk walkGraphFn[Requirement, RequirementGraph, bool]) (RequirementGraph, *UnifyStats, error) {
 	max := map[string]string{}
+	stats := &UnifyStats{}
 	for {
-		unified, restart, err := unifyRequirementsInner(ctx, rg, max)
+		unified, restart, n, err := unifyRequirementsInner(ctx, rg, max, walk)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		stats.NodesVisited = append(stats.NodesVisited, n)
 		if restart {
 			slog.DebugContext(ctx, "UnifyRequirements: restart")
+			stats.Restarts++
 			rg = unified
 			continue
 		}
-		return unified, nil
+		return unified, stats, nil
 	}
 }
 
-func unifyRequirementsInner(ctx context.Context, rg RequirementGraph, max map[string]string) (_ RequirementGraph, restart bool, _ error) {
-	var mu sync.Mutex // Protects max and the returned graph.
-	ret := &requirementGraph{reqs: map[Requirement]*requirementGraphReqs{}}
-	err := WalkRequirementGraph(ctx, rg, rg.Root(),
+func unifyRequirementsInner(ctx context.Context, rg RequirementGraph, max map[string]string, walk walkGraphFn[Requirement, RequirementGraph, bool]) (_ RequirementGraph, restart bool, visited int, _ error) {
+	var mu sync.Mutex // Protects max, visited, and the returned graph.
+	ret := &requirementGraph{
+		reqs:   map[Requirement]*requirementGraphReqs{},
+		source: RequirementSourceUnified,
+	}
+	err := walk(ctx, rg, rg.Root(),
 		func(ctx context.Context, m Requirement) (bool, error) {
 			mId := m.Id()
 			mu.Lock()
 			defer mu.Unlock()
+			visited++
 			mv, ok := max[mId.Path]
 			if ok {
-				if cmp := semver.Compare(mId.Version, mv); cmp < 0 {
+				if cmp := versionCompare(mId.Version, mv); cmp < 0 {
 					return false, nil
 				} else if cmp > 0 {
 					slog.DebugContext(ctx, "unifyRequirementsInner: restart", "old", mv, "new", mId)
@@ -92,7 +130,7 @@ func unifyRequirementsInner(ctx context.Context, rg RequirementGraph, max map[st
 				// the same version in the same walk so there's no harm in continuing if cmp == 0.
 			}
 			max[mId.Path] = mId.Version
-			m2 := requirement{mId}
+			m2 := withId(m, mId)
 			if mId == rg.Root().Id() {
 				ret.root = m2
 			}
@@ -104,7 +142,7 @@ func unifyRequirementsInner(ctx context.Context, rg RequirementGraph, max map[st
 		},
 		func(ctx context.Context, p, m Requirement, ind bool) error {
 			pId := p.Id()
-			p2 := requirement{pId}
+			p2 := withId(p, pId)
 			mId := m.Id()
 			mu.Lock()
 			defer mu.Unlock()
@@ -118,7 +156,7 @@ func unifyRequirementsInner(ctx context.Context, rg RequirementGraph, max map[st
 				return nil
 			}
 			mId.Version = max[mId.Path]
-			m2 := requirement{mId}
+			m2 := withId(m, mId)
 			if ind {
 				ret.reqs[p2].i.Add(m2)
 			} else {
@@ -127,7 +165,7 @@ func unifyRequirementsInner(ctx context.Context, rg RequirementGraph, max map[st
 			return nil
 		})
 	if err != nil {
-		return nil, false, err
+		return nil, false, 0, err
 	}
-	return ret, restart, nil
+	return ret, restart, visited, nil
 }
@@ -0,0 +1,61 @@
+package gomoddepgraph
+
+import (
+	"testing"
+)
+
+func TestUnifyRequirementsWithStats(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		desc string
+		g    map[string]map[string]bool
+		// wantFirst is the number of nodes visited in the first pass.  Whether a restart happens
+		// depends on the order of the parallel walk, so later passes are only checked for consistency.
+		wantFirst int
+		// conflict is true if the graph requires more than one version of a module.
+		conflict bool
+	}{
+		{
+			desc: "no restart needed",
+			g: map[string]map[string]bool{
+				"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false},
+				"example.com/a@v1.0.0": {"example.com/b@v1.0.0": false},
+				"example.com/b@v1.0.0": {},
+			},
+			wantFirst: 3,
+		},
+		{
+			desc: "version conflict",
+			g: map[string]map[string]bool{
+				"example.com/r@v1.0.0": {
+					"example.com/a@v1.0.0": false,
+					"example.com/b@v1.0.0": false,
+				},
+				"example.com/a@v1.0.0": {"example.com/c@v1.0.0": false},
+				"example.com/b@v1.0.0": {"example.com/c@v1.1.0": false},
+				"example.com/c@v1.0.0": {},
+				"example.com/c@v1.1.0": {},
+			},
+			wantFirst: 5,
+			conflict:  true,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			rg := newTestRequirementGraph(t, "example.com/r@v1.0.0", tc.g)
+			_, stats, err := UnifyRequirementsWithStats(t.Context(), rg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(stats.NodesVisited), stats.Restarts+1; got != want {
+				t.Errorf("got %v passes, want %v (restarts: %v)", got, want, stats.Restarts)
+			}
+			if got := stats.NodesVisited[0]; got != tc.wantFirst {
+				t.Errorf("first pass visited %v nodes, want %v", got, tc.wantFirst)
+			}
+			if !tc.conflict && stats.Restarts != 0 {
+				t.Errorf("got %v restarts for a graph without version conflicts, want 0", stats.Restarts)
+			}
+		})
+	}
+}
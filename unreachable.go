@@ -0,0 +1,66 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// UnreachableRequirements returns the nodes that the given [RequirementGraph] knows about (for
+// lazily loaded graphs such as those from [RequirementsComplete], the nodes that have been
+// successfully loaded) but that are not reachable from [RequirementGraph.Root], sorted by
+// [RequirementCompare].  The reachable nodes are found with [AllRequirements], which loads them.  A
+// non-empty result is usually a sign of a bug in whatever built or loaded the graph.
+//
+// Only the [RequirementGraph] implementations in this package are supported; an error is returned
+// for other implementations because there is no way to enumerate their nodes.
+func UnreachableRequirements(ctx context.Context, rg RequirementGraph) ([]Requirement, error) {
+	reachable := mapset.NewThreadUnsafeSet[Requirement]()
+	reqs, done := AllRequirements(ctx, rg)
+	for r := range reqs {
+		reachable.Add(r)
+	}
+	if err := done(); err != nil {
+		return nil, err
+	}
+	known, err := knownRequirements(rg)
+	if err != nil {
+		return nil, err
+	}
+	ret := []Requirement(nil)
+	for r := range known {
+		if !reachable.Contains(r) {
+			ret = append(ret, r)
+		}
+	}
+	slices.SortFunc(ret, RequirementCompare)
+	return ret, nil
+}
+
+// knownRequirements enumerates the nodes held in memory by one of this package's [RequirementGraph]
+// implementations.
+func knownRequirements(rg RequirementGraph) (iter.Seq[Requirement], error) {
+	switch rg := rg.(type) {
+	case *requirementGraph:
+		return maps.Keys(rg.reqs), nil
+	case *requirementGraphGo:
+		return maps.Keys(rg.reqs), nil
+	case *requirementGraphComplete:
+		return func(yield func(Requirement) bool) {
+			for r, e := range rg.immReqs.ToMap() {
+				if _, err := e.get(); err != nil {
+					continue
+				}
+				if !yield(r) {
+					return
+				}
+			}
+		}, nil
+	default:
+		return nil, fmt.Errorf("cannot enumerate the nodes of a %T", rg)
+	}
+}
@@ -0,0 +1,27 @@
+package gomoddepgraph
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnreachableRequirements(t *testing.T) {
+	t.Parallel()
+	rg := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0":      {"example.com/a@v1.0.0": false},
+		"example.com/a@v1.0.0":      {},
+		"example.com/orphan@v1.0.0": {"example.com/a@v1.0.0": false},
+	})
+	got, err := UnreachableRequirements(t.Context(), rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotStr := []string(nil)
+	for _, r := range got {
+		gotStr = append(gotStr, r.String())
+	}
+	if diff := cmp.Diff([]string{"example.com/orphan@v1.0.0"}, gotStr); diff != "" {
+		t.Errorf("unexpected unreachable requirements (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,38 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// UpgradedDependencies reports which of the root module's own requirements (both direct and
+// immediate indirect; see [Reqs]) were satisfied by a newer version than the root module requires,
+// which happens when some other module in the graph requires a newer version (MVS selects the
+// greatest required version).  For each such requirement, A of the returned [VersionChange] is the
+// [Dependency] the root module requires and B is the [Dependency] selected in dg.  The changes are
+// sorted by [ModuleId.Path].
+//
+// This answers "which of my requirements got bumped, and to what?", which often explains unexpected
+// behavior after a `go get`.  dg should have been resolved from rg.
+func UpgradedDependencies(ctx context.Context, rg RequirementGraph, dg DependencyGraph) ([]VersionChange, error) {
+	root := rg.Root()
+	if err := rg.Load(ctx, root); err != nil {
+		return nil, err
+	}
+	ret := []VersionChange(nil)
+	for r := range Reqs(rg, root) {
+		d := dg.Selected(r.Id())
+		if d == nil {
+			return nil, fmt.Errorf("requirement %v of the root module is not satisfied by the selected dependencies", r)
+		}
+		if semver.Compare(d.Id().Version, r.Id().Version) > 0 {
+			ret = append(ret, VersionChange{A: newDependency(r), B: d})
+		}
+	}
+	slices.SortFunc(ret, func(a, b VersionChange) int { return strings.Compare(a.A.Id().Path, b.A.Id().Path) })
+	return ret, nil
+}
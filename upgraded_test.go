@@ -0,0 +1,100 @@
+package gomoddepgraph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUpgradedDependencies(t *testing.T) {
+	t.Parallel()
+	rg := newTestRequirementGraph(t, "example.com/r@v1.0.0", map[string]map[string]bool{
+		"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": true, "example.com/c@v1.0.0": false},
+		"example.com/a@v1.0.0": {},
+		"example.com/a@v1.2.0": {},
+		"example.com/b@v1.0.0": {},
+		"example.com/b@v1.1.0": {},
+		"example.com/c@v1.0.0": {"example.com/a@v1.2.0": false, "example.com/b@v1.1.0": false},
+	})
+	dg, err := ResolveMvs(t.Context(), rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := UpgradedDependencies(t.Context(), rg, dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string(nil)
+	for _, c := range changes {
+		got = append(got, fmt.Sprintf("%v -> %v", c.A, c.B))
+	}
+	want := []string{
+		"example.com/a@v1.0.0 -> example.com/a@v1.2.0",
+		"example.com/b@v1.0.0 -> example.com/b@v1.1.0",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected upgrades (-want +got):\n%s", diff)
+	}
+}
+
+func TestUpgradedDependencies_EdgeCases(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		desc string
+		root string
+		g    map[string]map[string]bool
+		want []string
+	}{
+		{
+			desc: "empty",
+			root: "example.com/r@v1.0.0",
+			g:    map[string]map[string]bool{"example.com/r@v1.0.0": {}},
+		},
+		{
+			// a requires a newer version of the root, which does not count as an upgrade of one of the
+			// root's requirements.
+			desc: "cycles",
+			root: "example.com/r@v1.0.0",
+			g: map[string]map[string]bool{
+				"example.com/r@v1.0.0": {"example.com/a@v1.0.0": false, "example.com/b@v1.0.0": false},
+				"example.com/r@v1.1.0": {},
+				"example.com/a@v1.0.0": {"example.com/r@v1.1.0": false, "example.com/b@v1.1.0": false},
+				"example.com/b@v1.0.0": {},
+				"example.com/b@v1.1.0": {},
+			},
+			want: []string{"example.com/b@v1.0.0 -> example.com/b@v1.1.0"},
+		},
+		{
+			desc: "multi-root",
+			root: MultiRootId.String(),
+			g: map[string]map[string]bool{
+				MultiRootId.String():   {"example.com/x@v1.0.0": false, "example.com/y@v1.0.0": false},
+				"example.com/x@v1.0.0": {},
+				"example.com/x@v1.1.0": {},
+				"example.com/y@v1.0.0": {"example.com/x@v1.1.0": false},
+			},
+			want: []string{"example.com/x@v1.0.0 -> example.com/x@v1.1.0"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			rg := newTestRequirementGraph(t, tc.root, tc.g)
+			dg, err := ResolveMvs(t.Context(), rg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes, err := UpgradedDependencies(t.Context(), rg, dg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string(nil)
+			for _, c := range changes {
+				got = append(got, fmt.Sprintf("%v -> %v", c.A, c.B))
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected upgrades (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"fmt"
+	"go/version"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// effectiveModFlag returns the value of the `go` command's -mod flag that applies to the root
+// module:  the value passed to [WithModFlag], else the last -mod flag in GOFLAGS, else the empty
+// string (meaning Go's default).
+func (cfg *requirementsConfig) effectiveModFlag(ctx context.Context) string {
+	if cfg.modFlag != "" {
+		return cfg.modFlag
+	}
+	flag := ""
+	for _, f := range strings.Fields(getenv(ctx, "GOFLAGS")) {
+		if v, ok := strings.CutPrefix(strings.TrimLeft(f, "-"), "mod="); ok {
+			flag = v
+		}
+	}
+	return flag
+}
+
+// vendored reports whether Go would build the root module in vendor mode (see [WithModFlag]).
+func (cfg *requirementsConfig) vendored(ctx context.Context, rootId ModuleId) (bool, error) {
+	flag := cfg.effectiveModFlag(ctx)
+	if !rootId.IsDevel() {
+		if flag == "vendor" {
+			return false, fmt.Errorf(
+				"-mod=vendor: root module %v comes from the module proxy, which never serves vendor directories", rootId)
+		}
+		return false, nil
+	}
+	if flag != "" {
+		return flag == "vendor", nil
+	}
+	goMod, _, err := cfg.develRootGoMod(rootId)
+	if err != nil {
+		return false, err
+	}
+	if fi, err := os.Stat(filepath.Join(cfg.rootDir, "vendor")); err != nil || !fi.IsDir() {
+		return false, nil
+	}
+	return goMod.Go != nil && version.Compare("go"+goMod.Go.Version, "go1.14") >= 0, nil
+}
+
+// withModFlag returns a copy of ctx that passes the effective -mod flag (see effectiveModFlag) to
+// the `go` commands run in the root module's filtered clone.  The clone never has a vendor
+// directory, so this must not be used in vendor mode.
+func (cfg *requirementsConfig) withModFlag(ctx context.Context) context.Context {
+	flag := cfg.effectiveModFlag(ctx)
+	if flag == "" {
+		return ctx
+	}
+	return withEnv(ctx, "GOFLAGS="+strings.TrimSpace(getenv(ctx, "GOFLAGS")+" -mod="+flag))
+}
+
+// requirementsVendor implements [RequirementsGo] for a root module built in vendor mode.  In that
+// mode Go only consults the root module's go.mod and vendor/modules.txt, so the returned graph has
+// an edge from the root module to each vendored module and nothing else.  The vendored modules' own
+// requirements are unknown, so every edge is a direct requirement; otherwise each requirement that
+// go.mod marks "// indirect" would be a surprise dependency.  The versions come from `go list
+// -mod=vendor -m` run in the root module's directory, which also fails (like `go build`) if
+// vendor/modules.txt is inconsistent with go.mod.
+func requirementsVendor(ctx context.Context, rootId ModuleId, cfg *requirementsConfig) (*requirementGraphGo, error) {
+	data, err := os.ReadFile(filepath.Join(cfg.rootDir, "vendor", "modules.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("-mod=vendor: %w", err)
+	}
+	// Listing the root module as well makes the go command check consistency even if nothing is
+	// vendored.
+	paths := []string{rootId.Path}
+	for line := range strings.Lines(string(data)) {
+		// A vendored module is recorded as "# path version" (optionally followed by "=> replacement").
+		// A line of the form "# path => replacement" records a replacement of every version and does
+		// not name a vendored module.
+		if f := strings.Fields(line); len(f) >= 3 && f[0] == "#" && f[2] != "=>" {
+			paths = append(paths, f[1])
+		}
+	}
+	root := requirement{rootId}
+	rootReqs := &requirementGraphReqs{
+		d: mapset.NewThreadUnsafeSet[Requirement](),
+		i: mapset.NewThreadUnsafeSet[Requirement](),
+	}
+	rg := &requirementGraphGo{
+		requirementGraph: requirementGraph{
+			root: root,
+			reqs: map[Requirement]*requirementGraphReqs{root: rootReqs},
+		},
+		rootDir:    cfg.rootDir,
+		mainModule: cfg.mainModule,
+		vendored:   true,
+		privateEnv: cfg.privateEnv,
+	}
+	// Avoid hitting ARG_MAX.
+	for batch := range slices.Chunk(paths, 500) {
+		lsIter, done := goListM(WithoutWorkspace(ctx), cfg.rootDir, append([]string{"-mod=vendor"}, batch...)...)
+		for md := range lsIter {
+			if md.Path == rootId.Path {
+				continue
+			}
+			mId := NewModuleId(md.Path, md.Version)
+			if err := mId.Check(); err != nil {
+				done()
+				return nil, err
+			}
+			m := requirement{mId}
+			rg.reqs[m] = &requirementGraphReqs{
+				d: mapset.NewThreadUnsafeSet[Requirement](),
+				i: mapset.NewThreadUnsafeSet[Requirement](),
+			}
+			rootReqs.d.Add(m)
+		}
+		if err := done(); err != nil {
+			return nil, err
+		}
+	}
+	slog.DebugContext(ctx, "built requirement graph from vendor/modules.txt",
+		"root", rootId, "modules", len(rg.reqs)-1)
+	return rg, nil
+}
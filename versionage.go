@@ -0,0 +1,30 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"time"
+)
+
+// VersionAge returns how long ago each selected [Dependency] (see [Nodes]) was published, measured
+// from now.  The publication time is the Time field reported by `go list -m -json`, which comes
+// from the [module proxy]'s .info response (or the commit time for modules fetched directly from
+// version control).
+//
+// Dependencies whose versions have no publication time are not included in the returned map.  This
+// includes [DevelVersion] and [LocalVersion] nodes and the synthetic root of a multi-root graph.
+//
+// [module proxy]: https://go.dev/ref/mod#goproxy-protocol
+func VersionAge(ctx context.Context, dg DependencyGraph) (map[Dependency]time.Duration, error) {
+	mds, err := listReleased(ctx, dg)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	ret := map[Dependency]time.Duration{}
+	for d, md := range mds {
+		if md.Time != nil {
+			ret[d] = now.Sub(*md.Time)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,46 @@
+package gomoddepgraph_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestVersionAge(t *testing.T) {
+	t.Parallel()
+	old := time.Now().Add(-90 * 24 * time.Hour)
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/old@v1.0.0"), fm.Time(old)},
+		[]fm.Option{fm.Id("example.com/new@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/old@v1.0.0", false),
+			fm.Require("example.com/new@v1.0.0", false)},
+	).Context()
+	rg, err := RequirementsGo(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dg, err := ResolveGo(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	got, err := VersionAge(ctx, dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Errorf("got ages for %d dependencies, want 3", len(got))
+	}
+	// VersionAge measures from its own notion of now, which is slightly after start.
+	slack := time.Since(start) + time.Second
+	wantOld := start.Sub(old)
+	if a := got[dg.Selected(ParseModuleId("example.com/old@v1.0.0"))]; a < wantOld-slack || a > wantOld+slack {
+		t.Errorf("got age %v for old, want about %v", a, wantOld)
+	}
+	if a := got[dg.Selected(ParseModuleId("example.com/new@v1.0.0"))]; a < 0 || a > time.Hour {
+		t.Errorf("got age %v for new, want under an hour", a)
+	}
+}
@@ -0,0 +1,43 @@
+package gomoddepgraph
+
+import (
+	"context"
+	"slices"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"golang.org/x/mod/semver"
+)
+
+// VersionConflicts walks the given [RequirementGraph] and returns every module path that is
+// required at more than one distinct version (the classic "diamond"), mapped to the distinct
+// versions in ascending [semantic version] order.  Module paths required at only one version are
+// not included in the returned map.
+//
+// [Minimal version selection] picks the highest of each path's versions, but the spread can help
+// explain why a particular version was selected and whether [UnifyRequirements] would change
+// anything.
+//
+// [semantic version]: https://semver.org/
+// [Minimal version selection]: https://go.dev/ref/mod#minimal-version-selection
+func VersionConflicts(ctx context.Context, rg RequirementGraph) (map[string][]string, error) {
+	vers := map[string]mapset.Set[string]{}
+	reqs, done := AllRequirements(ctx, rg)
+	for r := range reqs {
+		mId := r.Id()
+		if vers[mId.Path] == nil {
+			vers[mId.Path] = mapset.NewThreadUnsafeSet[string]()
+		}
+		vers[mId.Path].Add(mId.Version)
+	}
+	if err := done(); err != nil {
+		return nil, err
+	}
+	ret := map[string][]string{}
+	for p, vs := range vers {
+		if vs.Cardinality() < 2 {
+			continue
+		}
+		ret[p] = slices.SortedFunc(mapset.Elements(vs), semver.Compare)
+	}
+	return ret, nil
+}
@@ -0,0 +1,41 @@
+package gomoddepgraph_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/rhansen/gomoddepgraph"
+	fm "github.com/rhansen/gomoddepgraph/internal/test/fakemodule"
+)
+
+func TestVersionConflicts(t *testing.T) {
+	t.Parallel()
+	ctx := fm.NewTestFakeGoProxy(t).AddAll(
+		[]fm.Option{fm.Id("example.com/d@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/d@v1.2.0")},
+		[]fm.Option{fm.Id("example.com/d@v1.10.0")},
+		[]fm.Option{fm.Id("example.com/x@v1.0.0")},
+		[]fm.Option{fm.Id("example.com/a@v1.0.0"),
+			fm.Require("example.com/d@v1.10.0", false),
+			fm.Require("example.com/x@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/b@v1.0.0"),
+			fm.Require("example.com/d@v1.2.0", false),
+			fm.Require("example.com/x@v1.0.0", false)},
+		[]fm.Option{fm.Id("example.com/root@v1.0.0"),
+			fm.Require("example.com/a@v1.0.0", false),
+			fm.Require("example.com/b@v1.0.0", false),
+			fm.Require("example.com/d@v1.0.0", false)},
+	).Context()
+	rg, _, err := RequirementsComplete(ctx, ParseModuleId("example.com/root@v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := VersionConflicts(ctx, rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"example.com/d": {"v1.0.0", "v1.2.0", "v1.10.0"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected version conflicts (-want +got):\n%s", diff)
+	}
+}
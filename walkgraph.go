@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"iter"
 	"log/slog"
+	"slices"
 	"sync"
 	"sync/atomic"
 
@@ -140,6 +141,71 @@ func walkGraph[N comparable, E any](ctx context.Context, start N,
 	return gr.Wait()
 }
 
+// walkGraphSorted is like walkGraph except it walks the graph breadth-first in a single goroutine,
+// visiting each node's children in the order given by cmp.  The order of node and edge visits is
+// therefore fully determined by the graph, at the cost of no parallelism.
+func walkGraphSorted[N comparable, E any](ctx context.Context, start N,
+	nodeVisit func(ctx context.Context, m N) (bool, error),
+	load func(ctx context.Context, m N) error,
+	edges func(m N) iter.Seq2[N, E],
+	edgeVisit func(ctx context.Context, p, m N, color E) error,
+	cmp func(a, b N) int) error {
+
+	type edge struct {
+		m     N
+		color E
+	}
+	seen := map[N]struct{}{}
+	q := []N(nil)
+	visit := func(m N) error {
+		seen[m] = struct{}{}
+		descend := true
+		if nodeVisit != nil {
+			var err error
+			if descend, err = nodeVisit(ctx, m); err != nil {
+				return err
+			}
+		}
+		if descend {
+			q = append(q, m)
+		}
+		return nil
+	}
+	if err := visit(start); err != nil {
+		return err
+	}
+	for len(q) > 0 {
+		if err := context.Cause(ctx); err != nil {
+			return err
+		}
+		p := q[0]
+		q = q[1:]
+		if load != nil {
+			if err := load(ctx, p); err != nil {
+				return err
+			}
+		}
+		children := []edge(nil)
+		for m, color := range edges(p) {
+			children = append(children, edge{m, color})
+		}
+		slices.SortStableFunc(children, func(a, b edge) int { return cmp(a.m, b.m) })
+		for _, c := range children {
+			if _, ok := seen[c.m]; !ok {
+				if err := visit(c.m); err != nil {
+					return err
+				}
+			}
+			if edgeVisit != nil {
+				if err := edgeVisit(ctx, p, c.m, c.color); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 type walkGraphFn[N comparable, G, E any] = func(ctx context.Context, g G, start N,
 	nodeVisit func(ctx context.Context, m N) (bool, error),
 	edgeVisit func(ctx context.Context, p, m N, color E) error) error
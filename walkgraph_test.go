@@ -436,6 +436,46 @@ func TestWalkGraph_ContextCancel(t *testing.T) {
 	}
 }
 
+func TestWalkGraphSorted(t *testing.T) {
+	t.Parallel()
+	g := tGraph{
+		"a": tEdges{"c": "red", "b": "blue"},
+		"b": tEdges{"d": "red", "a": "blue"},
+		"c": tEdges{"d": "blue"},
+		"d": tEdges{},
+	}
+	for i := range 10 {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+			got := []string(nil)
+			nodeVisit := func(ctx context.Context, n tNode) (bool, error) {
+				got = append(got, string(n))
+				return true, nil
+			}
+			edges := func(n tNode) iter.Seq2[tNode, tColor] { return maps.All(g[n]) }
+			edgeVisit := func(ctx context.Context, p, n tNode, color tColor) error {
+				got = append(got, fmt.Sprintf("%v->%v:%v", p, n, color))
+				return nil
+			}
+			if err := walkGraphSorted(t.Context(), "a", nodeVisit, nil, edges, edgeVisit,
+				func(a, b tNode) int { return strings.Compare(string(a), string(b)) }); err != nil {
+				t.Fatal(err)
+			}
+			want := []string{
+				"a",
+				"b", "a->b:blue",
+				"c", "a->c:red",
+				"b->a:blue",
+				"d", "b->d:red",
+				"c->d:blue",
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected visit order (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func newHighFanOutFanInGraph(t *testing.T) tGraph {
 	t.Helper()
 	g := tGraph{